@@ -1,9 +1,18 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/pokanop/nostromo/task"
 	"github.com/spf13/cobra"
-	"os"
+)
+
+var (
+	subMatch    string
+	subPosition int
+	subSplit    bool
+	subGlobal   bool
 )
 
 // addsubCmd represents the addsub command
@@ -17,13 +26,39 @@ be substituted out with "cmd-alias sub1 sub2" by adding subs for the args.
 
 This will create the substitution for scopes beneath levels in
 the provided key path. A command scope can a tree of sub commands
-and substitutions.`,
-	Args: cobra.MinimumNArgs(3),
+and substitutions.
+
+Aliases match arguments exactly by default. Use --match regex or glob to
+match patterns where the name can reference capture groups, e.g.,
+  nostromo add sub git 'refs/pull/$1/head' 'pr-(\d+)' --match regex
+
+Use --position to only substitute the argument at that position, --split
+to expand the name into several arguments and --global to add it for all
+commands without a key path.`,
+	Args: addSubArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		os.Exit(task.AddSubstitution(args[0], args[1], args[2]))
+		if subGlobal {
+			args = append([]string{""}, args...)
+		}
+		os.Exit(task.AddSubstitution(args[0], args[1], args[2], subMatch, subPosition, subSplit))
 	},
 }
 
 func init() {
 	addCmd.AddCommand(addsubCmd)
+
+	// Flags
+	addsubCmd.Flags().StringVarP(&subMatch, "match", "m", "", "How the alias matches arguments (exact, regex, glob)")
+	addsubCmd.Flags().IntVarP(&subPosition, "position", "p", 0, "Only substitute the argument at this position starting from 1")
+	addsubCmd.Flags().BoolVarP(&subSplit, "split", "s", false, "Split the substituted value into several arguments")
+	addsubCmd.Flags().BoolVarP(&subGlobal, "global", "g", false, "Add the substitution for all commands in the manifest")
+}
+
+func addSubArgs(cmd *cobra.Command, args []string) error {
+	if subGlobal && len(args) < 2 {
+		return fmt.Errorf("must provide name and alias")
+	} else if !subGlobal && len(args) < 3 {
+		return fmt.Errorf("must provide key path, name and alias")
+	}
+	return nil
 }
@@ -18,8 +18,8 @@ var destroyCmd = &cobra.Command{
 By default the core manifest is only destroyed and recreated.
 
 Optionally delete the entire installation using -n flag. Note that
-this does not remove shell init file entries added by nostromo. 
-You'll have to delete those manually.`,
+this does not remove shell init file entries added by nostromo.
+Run "nostromo profile uninstall" first to remove those.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		os.Exit(task.DestroyConfig(nuke))
 	},
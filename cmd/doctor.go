@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+var fix bool
+
+// doctorCmd represents the doctor command
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose problems with the nostromo installation",
+	Long: `Diagnose problems with the nostromo installation.
+
+Checks that manifests parse, the spaceport only references docked
+manifests, shell profiles have a valid nostromo section, init files
+and man pages are in place and nostromo is on your PATH.
+
+Use the --fix flag to repair what can be safely fixed.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.Doctor(fix))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+
+	doctorCmd.Flags().BoolVar(&fix, "fix", false, "Repair problems that can be safely fixed")
+}
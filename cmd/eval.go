@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"os"
+	"strings"
 
 	"github.com/pokanop/nostromo/task"
 	"github.com/spf13/cobra"
@@ -32,10 +33,38 @@ your own tool. Imagine composing commands to simplify a workflow:
 
 The root "build" command can do things like cd to a folder, set env vars, and
 run the main command. Lastly, substitutions can further shorten any sets of
-commands that need to be run across the scope of the command.`,
+commands that need to be run across the scope of the command.
+
+Pass --help to show usage for a command instead of running it. Use
+-- --help to pass the flag through to the command itself.
+
+Use --dry-run, optionally with --json, before the command to explain
+how it resolves instead, the same as nostromo explain.
+
+Commands that need confirmation prompt before running. Use --yes before
+the command or set NOSTROMO_YES=true to skip the prompt like in CI.
+
+Use --shell=fish or --shell=powershell before the command to print it
+for those shells, which the generated init files do for you.`,
 	Args:               cobra.MinimumNArgs(1),
 	DisableFlagParsing: true,
 	Run: func(cmd *cobra.Command, args []string) {
+		// Flag parsing is disabled so arguments reach commands untouched
+		dryRun, asJSON, sh := false, false, ""
+		for len(args) > 0 && (args[0] == "--dry-run" || args[0] == "--json" || args[0] == "--yes" || strings.HasPrefix(args[0], "--shell=")) {
+			dryRun = dryRun || args[0] == "--dry-run"
+			asJSON = asJSON || args[0] == "--json"
+			assumeYes = assumeYes || args[0] == "--yes"
+			if strings.HasPrefix(args[0], "--shell=") {
+				sh = strings.TrimPrefix(args[0], "--shell=")
+			}
+			args = args[1:]
+		}
+		task.SetAssumeYes(assumeYes)
+		task.SetEvalShell(sh)
+		if dryRun {
+			os.Exit(task.Explain(args, asJSON))
+		}
 		os.Exit(task.EvalString(args))
 	},
 }
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+// execCmd represents the exec command
+var execCmd = &cobra.Command{
+	Use:   "exec [command] [args]",
+	Short: "Execute a command from manifest directly",
+	Long: `Execute a command from manifest directly.
+
+Unlike eval which prints a command for your shell to evaluate, exec runs
+it in a new shell. Changes like cd or exported variables won't stick but
+the exit code and duration are recorded in history.
+
+Pass --help to show usage for a command instead of running it.
+
+Commands that need confirmation prompt before running. Use --yes before
+the command or set NOSTROMO_YES=true to skip the prompt like in CI.`,
+	Args:               cobra.MinimumNArgs(1),
+	DisableFlagParsing: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Flag parsing is disabled so arguments reach commands untouched
+		for len(args) > 0 && args[0] == "--yes" {
+			assumeYes = true
+			args = args[1:]
+		}
+		task.SetAssumeYes(assumeYes)
+		os.Exit(task.Exec(args))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(execCmd)
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+var explainJSON bool
+
+// explainCmd represents the explain command
+var explainCmd = &cobra.Command{
+	Use:   "explain [command] [args]",
+	Short: "Explain how a command is resolved",
+	Long: `Explain how a command is resolved without running it.
+
+Shows the manifest and key path that matched, what each command in
+the key path contributes with its mode, substitutions and the scope
+they came from, placeholder replacements and the final command.
+
+Flags for explain must come before the command:
+  nostromo explain --json build ios --clean`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.Explain(args, explainJSON))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(explainCmd)
+
+	// Arguments after the command belong to it
+	explainCmd.Flags().SetInterspersed(false)
+	explainCmd.Flags().BoolVarP(&explainJSON, "json", "j", false, "Print explanation as JSON")
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/pokanop/nostromo/exporter"
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFormat string
+	exportOutput string
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export [manifest] [options]",
+	Short: "Export a manifest as a standalone script",
+	Long: `Export a manifest as a standalone script that runs without nostromo.
+
+Shell formats define a function for each root command that can be loaded
+with 'source'. Make and just formats have a target or recipe for each
+command that takes arguments like:
+  make deploy.staging ARGS="--force"
+  just deploy-staging --force
+
+Commands are expanded with their modes and substitutions in scope just like
+nostromo does. Flags can't be parsed without nostromo so their placeholders
+use default values.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.Export(args[0], exportFormat, exportOutput))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", exporter.Bash, "Format to export: "+strings.Join(exporter.Formats, ", "))
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "File to write instead of printing")
+}
@@ -1,24 +1,43 @@
 package cmd
 
 import (
+	"os"
+	"strings"
+
+	"github.com/pokanop/nostromo/model"
 	"github.com/pokanop/nostromo/task"
 	"github.com/spf13/cobra"
-	"os"
 )
 
+var findOptions model.SearchOptions
+var findJSON bool
+
 // findCmd represents the find command
 var findCmd = &cobra.Command{
-	Use:   "find [name]",
+	Use:   "find [query]",
 	Short: "Find matching commands and substitutions",
 	Long: `Find matching commands and substitutions in nostromo.
 
-Searches for "name" in commands and substitutions and prints matches.`,
+Searches key paths, commands, aliases, descriptions, code snippets and
+substitutions for "query" and prints matches with the manifest they are in.
+Queries are case insensitive substrings by default. Use --regex to match a
+regular expression or --fuzzy to match runes in order ranked by relevance.
+
+Limit the fields searched with --in, for example:
+  nostromo find --in description,code deploy`,
 	Args: cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		os.Exit(task.Find(args[0]))
+		findOptions.Query = strings.Join(args, " ")
+		os.Exit(task.Find(findOptions, findJSON))
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(findCmd)
+
+	findCmd.Flags().BoolVarP(&findOptions.Regex, "regex", "r", false, "Match the query as a regular expression")
+	findCmd.Flags().BoolVarP(&findOptions.Fuzzy, "fuzzy", "z", false, "Match the query fuzzily and rank results")
+	findCmd.Flags().StringSliceVarP(&findOptions.Fields, "in", "i", nil, "Fields to search: "+strings.Join(model.SearchFields, ", "))
+	findCmd.Flags().StringVarP(&findOptions.Manifest, "manifest", "m", "", "Only search the named manifest")
+	findCmd.Flags().BoolVarP(&findJSON, "json", "j", false, "Print results as JSON")
 }
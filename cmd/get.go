@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"os"
+	"strings"
 
+	"github.com/pokanop/nostromo/secret"
 	"github.com/pokanop/nostromo/task"
 	"github.com/spf13/cobra"
 )
@@ -17,9 +19,17 @@ Nostromo config items are saved in the manifest.
 Use this command to get keys to examine these settings:
 verbose: boolean
 aliasesOnly: boolean
-backupCount: number`,
+backupCount: number
+startupFiles: comma separated paths
+backupDir: path
+preferredShells: comma separated shells
+disableHistory: boolean
+historySize: number
+historyDays: number
+secretBackend: ` + strings.Join(secret.Backends(), " | ") + `
+confirmSources: comma separated manifest sources`,
 	Args:      cobra.MinimumNArgs(1),
-	ValidArgs: []string{"verbose", "aliasesOnly", "mode", "backupCount", "theme"},
+	ValidArgs: []string{"verbose", "aliasesOnly", "mode", "backupCount", "theme", "startupFiles", "backupDir", "preferredShells", "disableHistory", "historySize", "historyDays", "secretBackend", "confirmSources"},
 	Run: func(cmd *cobra.Command, args []string) {
 		os.Exit(task.GetConfig(args[0]))
 	},
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+var historyClear bool
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history [query | !n]",
+	Short: "Show or replay command history",
+	Long: `Show or replay command history.
+
+Lists commands resolved by nostromo matching the optional query with the
+number of each entry. Replay an entry by number with !n, count back from
+the most recent with !-n or replay the last one with !!. Quote the
+reference so your shell doesn't expand it:
+  nostromo history '!12'
+
+Replayed commands run directly like nostromo exec. History is stored under
+NOSTROMO_HOME and can be turned off with:
+  nostromo set disableHistory true`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 1 && strings.HasPrefix(args[0], "!") {
+			os.Exit(task.ReplayHistory(args[0]))
+		}
+		os.Exit(task.History(strings.Join(args, " "), historyClear))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+
+	historyCmd.Flags().BoolVar(&historyClear, "clear", false, "Remove all history entries")
+}
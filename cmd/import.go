@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import commands from other tools",
+	Long: `Import commands defined by other tools into the core manifest.
+
+Imported commands are previewed before they are added and existing
+commands are never replaced.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		printUsage(cmd)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+}
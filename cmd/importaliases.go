@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importFiles  []string
+	importFlat   bool
+	importYes    bool
+	importDryRun bool
+)
+
+// importaliasesCmd represents the import aliases command
+var importaliasesCmd = &cobra.Command{
+	Use:   "aliases [options]",
+	Short: "Import shell aliases and simple functions",
+	Long: `Import shell aliases and simple functions into the core manifest.
+
+By default aliases are read from the shell startup files nostromo manages.
+Use --file to read other files or '-' to read output of the alias command:
+  alias | nostromo import aliases -f -
+
+Names like 'git_log' or 'git.log' become key paths like 'git.log' so they
+can be run as 'git log'. Use --flat to keep underscores in names.
+
+Functions with a single line body or a few simple lines are imported as
+long as they only use positional parameters like $1. A trailing "$@" is
+dropped since nostromo appends arguments to commands.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.ImportAliases(importFiles, importFlat, importYes, importDryRun))
+	},
+}
+
+func init() {
+	importCmd.AddCommand(importaliasesCmd)
+
+	// Flags
+	importaliasesCmd.Flags().StringSliceVarP(&importFiles, "file", "f", nil, "Files to read aliases from or '-' for stdin")
+	importaliasesCmd.Flags().BoolVar(&importFlat, "flat", false, "Keep underscores in names instead of building key paths")
+	importaliasesCmd.Flags().BoolVarP(&importYes, "yes", "y", false, "Import without confirming")
+	importaliasesCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Preview commands without importing")
+}
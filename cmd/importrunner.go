@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pokanop/nostromo/importer"
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+var importRoot string
+
+// importRunnerCmds represent import commands for task runners
+var importRunnerCmds = []*cobra.Command{
+	newImportRunnerCmd(importer.MakeRunner, "Makefile", "make targets"),
+	newImportRunnerCmd(importer.JustRunner, "justfile", "just recipes"),
+	newImportRunnerCmd(importer.NpmRunner, "package.json", "package.json scripts"),
+	newImportRunnerCmd(importer.TaskRunner, "Taskfile.yml", "Taskfile tasks"),
+}
+
+func newImportRunnerCmd(runner, file, tasks string) *cobra.Command {
+	return &cobra.Command{
+		Use:   fmt.Sprintf("%s [path] [options]", runner),
+		Short: fmt.Sprintf("Import %s", tasks),
+		Long: fmt.Sprintf(`Import %[1]s from a %[2]s into the core manifest.
+
+The path can be the %[2]s or a directory containing one and defaults to
+the current directory. Commands are added under a key path named after the
+directory unless --root is provided, use '.' to import at the root.
+
+Imported commands run from the directory of the %[2]s so they work from
+anywhere. Run the import again to refresh commands when tasks change.`, tasks, file),
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var path string
+			if len(args) > 0 {
+				path = args[0]
+			}
+			os.Exit(task.ImportRunner(runner, path, importRoot, importYes, importDryRun))
+		},
+	}
+}
+
+func init() {
+	for _, c := range importRunnerCmds {
+		importCmd.AddCommand(c)
+
+		// Flags
+		c.Flags().StringVarP(&importRoot, "root", "r", "", "Key path to import commands under, defaults to the directory name")
+		c.Flags().BoolVarP(&importYes, "yes", "y", false, "Import without confirming")
+		c.Flags().BoolVar(&importDryRun, "dry-run", false, "Preview commands without importing")
+	}
+}
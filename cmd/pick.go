@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+var pickExecute bool
+var pickShell string
+
+// pickCmd represents the pick command
+var pickCmd = &cobra.Command{
+	Use:   "pick [query]",
+	Short: "Pick a command interactively",
+	Long: `Pick a command interactively from all manifests.
+
+Fuzzy searches key paths, descriptions and command bodies with a preview
+of the expanded command. Use the arrow keys or ctrl-p and ctrl-n to move,
+enter to pick and esc or ctrl-c to cancel.
+
+The picked command is printed so it can be evaluated in your shell:
+  eval "$(nostromo pick)"
+
+Use --shell=fish or --shell=powershell to print it for those shells:
+  nostromo pick --shell=fish | source
+
+Use --exec or pick with ctrl-x to run the command in a new shell instead.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		task.SetEvalShell(pickShell)
+		os.Exit(task.Pick(strings.Join(args, " "), pickExecute))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pickCmd)
+
+	pickCmd.Flags().BoolVarP(&pickExecute, "exec", "x", false, "Execute the picked command instead of printing it")
+	pickCmd.Flags().StringVar(&pickShell, "shell", "", "Shell the picked command is printed for")
+}
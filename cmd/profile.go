@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// profileCmd represents the profile command
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage nostromo in shell profiles",
+	Long: `Manage nostromo in shell profiles.
+
+Shell initialization files source the generated nostromo init file.
+Configure which files are managed with these settings:
+  nostromo set startupFiles ~/.config/bash/bashrc,~/.zshrc
+  nostromo set preferredShells zsh,fish
+  nostromo set backupDir ~/backups`,
+	Run: func(cmd *cobra.Command, args []string) {
+		printUsage(cmd)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(profileCmd)
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+// profileinstallCmd represents the profile install command
+var profileinstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Add nostromo to shell profiles",
+	Long: `Add nostromo to shell profiles.
+
+Writes the nostromo section to preferred shell initialization files
+and removes it from the others. Symlinked files are edited in place
+and a backup is saved before any change.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.InstallProfile())
+	},
+}
+
+func init() {
+	profileCmd.AddCommand(profileinstallCmd)
+}
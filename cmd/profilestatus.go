@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+// profilestatusCmd represents the profile status command
+var profilestatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show shell profiles managed by nostromo",
+	Long: `Show shell profiles managed by nostromo.
+
+Lists each shell initialization file with its shell, symlink target
+and whether the nostromo section is installed or has problems.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.ProfileStatus())
+	},
+}
+
+func init() {
+	profileCmd.AddCommand(profilestatusCmd)
+}
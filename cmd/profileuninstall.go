@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+// profileuninstallCmd represents the profile uninstall command
+var profileuninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove nostromo from shell profiles",
+	Long: `Remove nostromo from shell profiles.
+
+Removes the nostromo section from all shell initialization files.
+Your commands and manifests are kept.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.UninstallProfile())
+	},
+}
+
+func init() {
+	profileCmd.AddCommand(profileuninstallCmd)
+}
@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+// recordCmd represents the record command
+var recordCmd = &cobra.Command{
+	Use:                "record [command] [args]",
+	Short:              "Record a command in history",
+	Long:               `Record a command in history once the shell ran it after it was confirmed.`,
+	Hidden:             true,
+	Args:               cobra.MinimumNArgs(1),
+	DisableFlagParsing: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.RecordHistory(args))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(recordCmd)
+}
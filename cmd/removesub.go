@@ -1,11 +1,15 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/pokanop/nostromo/task"
 	"github.com/spf13/cobra"
-	"os"
 )
 
+var removeSubGlobal bool
+
 // removesubCmd represents the removesub command
 var removesubCmd = &cobra.Command{
 	Use:   "sub [key.path] [alias]",
@@ -17,13 +21,28 @@ be substituted out with "cmd-alias sub1 sub2" by adding subs for the args.
 
 This will remove the substitution for scopes beneath levels in
 the provided key path. A command scope can a tree of sub commands
-and substitutions.`,
-	Args: cobra.MinimumNArgs(2),
+and substitutions.
+
+Use --global to remove a global substitution without a key path.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if removeSubGlobal && len(args) < 1 {
+			return fmt.Errorf("must provide alias")
+		} else if !removeSubGlobal && len(args) < 2 {
+			return fmt.Errorf("must provide key path and alias")
+		}
+		return nil
+	},
 	Run: func(cmd *cobra.Command, args []string) {
+		if removeSubGlobal {
+			args = append([]string{""}, args...)
+		}
 		os.Exit(task.RemoveSubstitution(args[0], args[1]))
 	},
 }
 
 func init() {
 	removeCmd.AddCommand(removesubCmd)
+
+	// Flags
+	removesubCmd.Flags().BoolVarP(&removeSubGlobal, "global", "g", false, "Remove a global substitution from the manifest")
 }
@@ -14,6 +14,7 @@ import (
 
 var ver *version.Info
 var verbose bool
+var assumeYes bool
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
@@ -30,6 +31,7 @@ substitutions to simplify calls.`,
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
 		log.SetVerbose(verbose)
 		model.SetVerbose(verbose)
+		task.SetAssumeYes(assumeYes)
 	},
 	SilenceErrors: true,
 	SilenceUsage:  true,
@@ -59,7 +61,11 @@ func init() {
 	// Disable default completion command
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 
+	// Init files include completions for nostromo itself
+	task.SetRootCommand(rootCmd)
+
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "show verbose logging")
+	rootCmd.PersistentFlags().BoolVar(&assumeYes, "yes", false, "run commands without asking for confirmation")
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// secretCmd represents the secret command
+var secretCmd = &cobra.Command{
+	Use:   "secret",
+	Short: "Manage secrets referenced by commands",
+	Long: `Manage secrets referenced by commands like ${secret:github_token}.
+
+References are resolved only when a command runs so values never end up
+in manifests, backups or the output of show and explain.
+
+Secrets are stored in an encrypted vault under NOSTROMO_HOME by default.
+The vault is unlocked with a passphrase that is prompted for, read from
+$NOSTROMO_VAULT_PASSPHRASE or from a key file at $NOSTROMO_VAULT_KEY_FILE.
+age keys aren't supported, use a key file holding a random key instead.
+Read secrets from NOSTROMO_SECRET_<NAME> environment variables instead with:
+  nostromo set secretBackend env`,
+	Run: func(cmd *cobra.Command, args []string) {
+		printUsage(cmd)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(secretCmd)
+}
@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+// secretgetCmd represents the secret get command
+var secretgetCmd = &cobra.Command{
+	Use:   "get [name]",
+	Short: "Print the value of a secret",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.GetSecret(args[0]))
+	},
+}
+
+func init() {
+	secretCmd.AddCommand(secretgetCmd)
+}
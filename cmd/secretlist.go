@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+// secretlistCmd represents the secret list command
+var secretlistCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List secret names without their values",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.ListSecrets())
+	},
+}
+
+func init() {
+	secretCmd.AddCommand(secretlistCmd)
+}
@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+// secretremoveCmd represents the secret rm command
+var secretremoveCmd = &cobra.Command{
+	Use:   "rm [name]",
+	Short: "Remove a secret",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.RemoveSecret(args[0]))
+	},
+}
+
+func init() {
+	secretCmd.AddCommand(secretremoveCmd)
+}
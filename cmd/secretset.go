@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+// secretsetCmd represents the secret set command
+var secretsetCmd = &cobra.Command{
+	Use:   "set [name] [value]",
+	Short: "Set a secret",
+	Long: `Set a secret creating the vault if needed.
+
+The value is read from stdin when piped or prompted for without echoing
+if not provided so it doesn't end up in your shell history.`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		var value string
+		if len(args) > 1 {
+			value = args[1]
+		}
+		os.Exit(task.SetSecret(args[0], value))
+	},
+}
+
+func init() {
+	secretCmd.AddCommand(secretsetCmd)
+}
@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"os"
+	"strings"
 
+	"github.com/pokanop/nostromo/secret"
 	"github.com/pokanop/nostromo/task"
 	"github.com/spf13/cobra"
 )
@@ -19,9 +21,17 @@ Use this command to set values for these settings:
   aliasesOnly: boolean
   mode: concatenate | independent | exclusive
   backupCount: number
-	theme: default | grayscale | emoji`,
+	theme: default | grayscale | emoji
+  startupFiles: comma separated paths, empty for defaults
+  backupDir: path, empty for default
+  preferredShells: comma separated bash | zsh | fish | powershell
+  confirmSources: comma separated manifest sources that always confirm, * as a wildcard
+  disableHistory: boolean
+  historySize: number of entries kept, 0 for default
+  historyDays: number of days entries are kept, 0 to keep all
+  secretBackend: ` + strings.Join(secret.Backends(), " | ") + `, empty for vault`,
 	Args:      cobra.MinimumNArgs(2),
-	ValidArgs: []string{"verbose", "aliasesOnly", "mode", "backupCount", "theme"},
+	ValidArgs: []string{"verbose", "aliasesOnly", "mode", "backupCount", "theme", "startupFiles", "backupDir", "preferredShells", "confirmSources", "disableHistory", "historySize", "historyDays", "secretBackend"},
 	Run: func(cmd *cobra.Command, args []string) {
 		os.Exit(task.SetConfig(args[0], args[1]))
 	},
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pokanop/nostromo/task"
+	"github.com/spf13/cobra"
+)
+
+var statsManifest string
+var statsLimit int
+var statsJSON bool
+
+// statsCmd represents the stats command
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show command usage from history",
+	Long: `Show command usage from history.
+
+Prints the most used commands, commands that failed when run directly and
+commands that were never used, which helps prune manifests. A command
+counts as used if it or any of its children show up in history.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(task.Stats(statsManifest, statsLimit, statsJSON))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+
+	statsCmd.Flags().StringVarP(&statsManifest, "manifest", "m", "", "Only show stats for the named manifest")
+	statsCmd.Flags().IntVarP(&statsLimit, "limit", "l", 10, "Number of most used and failing commands to show")
+	statsCmd.Flags().BoolVarP(&statsJSON, "json", "j", false, "Print stats as JSON")
+}
@@ -22,7 +22,8 @@ respective data sources. Provide one or more of the names of
 the manifests as arguments to sync.
 
 Sync will only update manifests with changed identifiers, to
-force update use the -f flag.`,
+force update use the -f flag. Remote includes are fetched again
+the next time manifests are loaded.`,
 	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		os.Exit(task.Sync(force, keep, args))
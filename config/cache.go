@@ -0,0 +1,117 @@
+package config
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/pokanop/nostromo/log"
+	"github.com/pokanop/nostromo/model"
+	"github.com/pokanop/nostromo/pathutil"
+)
+
+// cacheFormat is bumped whenever the cached layout changes incompatibly
+const cacheFormat = 2
+
+// spaceportCache is a compiled snapshot of the spaceport and its manifests
+//
+// The cache is only valid as long as the files it was built from are
+// unchanged, which is tracked by their modification times.
+type spaceportCache struct {
+	Format         int
+	Version        string
+	ModTimes       map[string]int64
+	Theme          log.ThemeType
+	ConfirmSources []string
+	Manifests      []*model.Manifest
+}
+
+// writeSpaceportCache compiles the spaceport into the binary cache file
+func writeSpaceportCache(s *model.Spaceport) error {
+	manifests := s.Manifests()
+	paths := cachedPaths(manifests)
+	modTimes := map[string]int64{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		modTimes[path] = info.ModTime().UnixNano()
+	}
+
+	c := &spaceportCache{
+		Format:         cacheFormat,
+		Version:        cacheVersion(),
+		ModTimes:       modTimes,
+		Theme:          s.Theme,
+		ConfirmSources: s.ConfirmSources,
+		Manifests:      manifests,
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(c); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(spaceportCacheFile(), buf.Bytes(), 0644)
+}
+
+// readSpaceportCache loads the spaceport from the binary cache file
+//
+// Returns an error if the cache is missing or any of the files it was
+// compiled from have changed since.
+func readSpaceportCache() (*model.Spaceport, error) {
+	b, err := ioutil.ReadFile(spaceportCacheFile())
+	if err != nil {
+		return nil, err
+	}
+
+	c := &spaceportCache{}
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(c); err != nil {
+		return nil, err
+	}
+
+	if c.Format != cacheFormat || c.Version != cacheVersion() {
+		return nil, fmt.Errorf("spaceport cache is outdated")
+	}
+
+	// Every source file must be unchanged, the manifests folder changes
+	// when manifests are added or removed
+	for path, modTime := range c.ModTimes {
+		info, err := os.Stat(path)
+		if err != nil || info.ModTime().UnixNano() != modTime {
+			return nil, fmt.Errorf("spaceport cache is stale")
+		}
+	}
+
+	s := model.NewSpaceport(c.Manifests)
+	s.Theme = c.Theme
+	s.ConfirmSources = c.ConfirmSources
+	if err := s.Link(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// cachedPaths that invalidate the cache when modified
+func cachedPaths(manifests []*model.Manifest) []string {
+	paths := []string{spaceportFile(), manifestsPath()}
+	for _, m := range manifests {
+		if m == nil || len(m.Path) == 0 {
+			continue
+		}
+		paths = append(paths, pathutil.Abs(m.Path))
+		paths = append(paths, includedPaths(m)...)
+	}
+	return paths
+}
+
+func cacheVersion() string {
+	if ver == nil {
+		return ""
+	}
+	return ver.SemVer + "+" + ver.GitCommit
+}
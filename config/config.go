@@ -13,6 +13,7 @@ import (
 	"github.com/pokanop/nostromo/log"
 	"github.com/pokanop/nostromo/model"
 	"github.com/pokanop/nostromo/pathutil"
+	"github.com/pokanop/nostromo/secret"
 	"github.com/pokanop/nostromo/version"
 	"gopkg.in/yaml.v2"
 )
@@ -22,13 +23,22 @@ const (
 	SystemPrefixDir       = "/usr/local"
 	DefaultBaseDir        = "~/.nostromo"
 	DefaultConfigFile     = "%s.yaml"
+	DefaultCacheFile      = "%s.cache"
 	DefaultManifestsDir   = "ships"
 	DefaultBackupsDir     = "cargo"
 	DefaultDownloadsDir   = "downloads"
+	DefaultIncludesDir    = "includes"
 	DefaultCompletionsDir = "completions"
 	DefaultManDir         = "man"
+	DefaultProjectFile    = ".nostromo.yaml"
+	DefaultTrustFile      = "trusted.yaml"
+	DefaultHistoryFile    = "history.jsonl"
+	DefaultVaultFile      = "vault.json"
 )
 
+// Shells that profiles can be managed for
+var supportedShells = []string{"bash", "zsh", "fish", "powershell"}
+
 // URL scheme constants
 const (
 	FileURLScheme = "file://"
@@ -54,13 +64,51 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 
+	s, err := parseSpaceport(false)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := SaveSpaceport(s); err != nil {
+		return nil, err
+	}
+
+	return &Config{s}, nil
+}
+
+// LoadConfigReadOnly loads the config without migrating or writing the
+// spaceport or manifests
+//
+// This is meant for hot paths like `eval` and completions. The compiled
+// spaceport cache is used when it is up to date and otherwise manifests
+// are parsed from disk and the cache is rebuilt since it's derived data.
+func LoadConfigReadOnly() (*Config, error) {
+	s, err := readSpaceportCache()
+	if err != nil {
+		log.Debugf("spaceport cache not used: %s\n", err)
+		if s, err = parseSpaceport(true); err != nil {
+			return nil, err
+		}
+		if err := writeSpaceportCache(s); err != nil {
+			log.Debugf("unable to write spaceport cache: %s\n", err)
+		}
+	}
+
+	return &Config{s}, nil
+}
+
+// parseSpaceport loads the spaceport and all manifests from disk
+//
+// Nothing is logged when quiet since output on hot paths like `eval` is
+// run by the shell.
+func parseSpaceport(quiet bool) (*model.Spaceport, error) {
 	// Load core manifest
 	source, err := coreManifestURL()
 	if err != nil {
 		return nil, err
 	}
 	path := coreManifestPath()
-	m, err := Parse(coreManifestPath())
+	m, err := Parse(path)
 	if err != nil {
 		return nil, err
 	}
@@ -75,17 +123,18 @@ func LoadConfig() (*Config, error) {
 	// Load spaceport
 	s, err := loadSpaceport()
 	if err != nil {
-		log.Warning("spaceport not found, creating...")
+		if !quiet {
+			log.Warning("spaceport not found, creating...")
+		}
 		s = model.NewSpaceport(manifests)
 	}
 
 	s.Import(manifests)
-	s.Link()
-	if err := SaveSpaceport(s); err != nil {
+	if err := s.Link(); err != nil {
 		return nil, err
 	}
 
-	return &Config{s}, nil
+	return s, nil
 }
 
 // NewConfig returns a new nostromo config
@@ -101,7 +150,9 @@ func NewConfig() (*Config, error) {
 	manifests = append(manifests, loadManifests()...)
 
 	s := model.NewSpaceport(manifests)
-	s.Link()
+	if err := s.Link(); err != nil {
+		return nil, err
+	}
 
 	return &Config{s}, nil
 }
@@ -200,15 +251,38 @@ func Parse(path string) (*model.Manifest, error) {
 		return nil, err
 	}
 
+	m, err := unmarshalManifest(b, filepath.Ext(path))
+	if err != nil {
+		return nil, err
+	}
+
+	// Sanity check parsed manifest
+	if len(m.Name) == 0 {
+		return nil, fmt.Errorf("invalid file content")
+	}
+
+	// Manifest path should match
+	m.Path = path
+
+	// Load included manifests to be merged when linking
+	location := pathutil.Abs(path)
+	if err := includeManifests(m, location, []string{location}); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// unmarshalManifest from file content with the given extension
+func unmarshalManifest(b []byte, ext string) (*model.Manifest, error) {
 	// Initialize manifest with some defaults
 	m := &model.Manifest{
 		Config: &model.Config{
 			BackupCount: 10,
 		},
 	}
-	ext := filepath.Ext(path)
 	if ext == ".yaml" {
-		err = yaml.Unmarshal(b, &m)
+		err := yaml.Unmarshal(b, &m)
 		if err != nil {
 			return nil, err
 		}
@@ -216,14 +290,6 @@ func Parse(path string) (*model.Manifest, error) {
 		return nil, fmt.Errorf("invalid file format: %s", ext)
 	}
 
-	// Sanity check parsed manifest
-	if len(m.Name) == 0 {
-		return nil, fmt.Errorf("invalid file content")
-	}
-
-	// Manifest path should match
-	m.Path = path
-
 	return m, nil
 }
 
@@ -245,6 +311,11 @@ func SaveSpaceport(s *model.Spaceport) error {
 		return err
 	}
 
+	// Refresh the compiled cache, failing here only costs a slower read
+	if err := writeSpaceportCache(s); err != nil {
+		log.Debugf("unable to write spaceport cache: %s\n", err)
+	}
+
 	return nil
 }
 
@@ -288,14 +359,26 @@ func SaveManifest(manifest *model.Manifest, backup bool) error {
 	return nil
 }
 
-// WriteCompletion writes a file to the completions folder
-func WriteCompletion(sh, s string) error {
+// InitFile provides the path of the generated init script for a shell
+//
+// The script holds completions, shell functions and aliases for all
+// manifests and is sourced by shell profiles.
+func InitFile(sh string) string {
+	// PowerShell only sources scripts with its own extension
+	ext := sh
+	if sh == "powershell" {
+		ext = "ps1"
+	}
+	return filepath.Join(completionsPath(), fmt.Sprintf("nostromo.%s", ext))
+}
+
+// WriteInitFile writes the generated init script for a shell
+func WriteInitFile(sh, s string) error {
 	if len(sh) == 0 || len(s) == 0 {
 		return fmt.Errorf("attempt to write 0 length file")
 	}
 
-	path := filepath.Join(completionsPath(), fmt.Sprintf("nostromo.%s", sh))
-	return os.WriteFile(path, []byte(s), 0644)
+	return os.WriteFile(InitFile(sh), []byte(s), 0644)
 }
 
 // Spaceport associated with this config
@@ -308,13 +391,13 @@ func (c *Config) Save() error {
 	// Update version
 	c.spaceport.UpdateVersion(ver)
 
-	// Save spaceport
-	if err := SaveSpaceport(c.spaceport); err != nil {
+	// Save core manifest
+	if err := SaveManifest(c.spaceport.CoreManifest(), true); err != nil {
 		return err
 	}
 
-	// Save core manifest
-	if err := SaveManifest(c.spaceport.CoreManifest(), true); err != nil {
+	// Save spaceport last so the compiled cache sees the latest manifests
+	if err := SaveSpaceport(c.spaceport); err != nil {
 		return err
 	}
 
@@ -366,8 +449,24 @@ func (c *Config) Get(key string) string {
 		return m.Config.Mode.String()
 	case "backupCount":
 		return strconv.FormatInt(int64(m.Config.BackupCount), 10)
+	case "startupFiles":
+		return strings.Join(m.Config.StartupFiles, ",")
+	case "backupDir":
+		return m.Config.BackupDir
+	case "preferredShells":
+		return strings.Join(m.Config.PreferredShells, ",")
+	case "disableHistory":
+		return strconv.FormatBool(m.Config.DisableHistory)
+	case "historySize":
+		return strconv.Itoa(m.Config.HistorySize)
+	case "historyDays":
+		return strconv.Itoa(m.Config.HistoryDays)
+	case "secretBackend":
+		return m.Config.SecretBackend
 	case "theme":
 		return log.ThemeToString(c.spaceport.Theme)
+	case "confirmSources":
+		return strings.Join(c.spaceport.ConfirmSources, ",")
 	}
 	return "key not found"
 }
@@ -403,18 +502,104 @@ func (c *Config) Set(key, value string) error {
 		}
 		m.Config.BackupCount = int(count)
 		return nil
+	case "startupFiles":
+		m.Config.StartupFiles = splitList(value)
+		return nil
+	case "backupDir":
+		m.Config.BackupDir = strings.TrimSpace(value)
+		return nil
+	case "preferredShells":
+		shells := splitList(value)
+		for _, sh := range shells {
+			if !isSupportedShell(sh) {
+				return fmt.Errorf("invalid shell %s, supported shells: %s", sh, supportedShells)
+			}
+		}
+		m.Config.PreferredShells = shells
+		return nil
+	case "disableHistory":
+		disable, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		m.Config.DisableHistory = disable
+		return nil
+	case "historySize", "historyDays":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		if n < 0 {
+			return fmt.Errorf("%s cannot be negative", key)
+		}
+		if key == "historySize" {
+			m.Config.HistorySize = n
+		} else {
+			m.Config.HistoryDays = n
+		}
+		return nil
+	case "secretBackend":
+		value = strings.TrimSpace(value)
+		if len(value) > 0 && !secret.IsBackendSupported(value) {
+			return fmt.Errorf("invalid secret backend %s, supported backends: %s", value, secret.Backends())
+		}
+		m.Config.SecretBackend = value
+		return nil
 	case "theme":
 		c.spaceport.Theme = log.ThemeFromString(value)
 		return nil
+	case "confirmSources":
+		c.spaceport.ConfirmSources = splitList(value)
+		return nil
 	}
 	return fmt.Errorf("key not found")
 }
 
+// splitList of comma separated values with empty values removed
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func isSupportedShell(sh string) bool {
+	for _, s := range supportedShells {
+		if s == sh {
+			return true
+		}
+	}
+	return false
+}
+
 // spaceportFile provides the path for spaceports
 func spaceportFile() string {
 	return filepath.Join(pathutil.Abs(BaseDir()), fmt.Sprintf(DefaultConfigFile, model.DefaultSpaceportName))
 }
 
+// spaceportCacheFile provides the path for the compiled spaceport cache
+func spaceportCacheFile() string {
+	return filepath.Join(pathutil.Abs(BaseDir()), fmt.Sprintf(DefaultCacheFile, model.DefaultSpaceportName))
+}
+
+// trustFile provides the path for trusted project manifests
+func trustFile() string {
+	return filepath.Join(pathutil.Abs(BaseDir()), DefaultTrustFile)
+}
+
+// historyFile provides the path for command history
+func historyFile() string {
+	return filepath.Join(pathutil.Abs(BaseDir()), DefaultHistoryFile)
+}
+
+// vaultFile provides the path for the encrypted secrets vault
+func vaultFile() string {
+	return filepath.Join(pathutil.Abs(BaseDir()), DefaultVaultFile)
+}
+
 // manifestFile joins the manifests path with provided name
 func manifestFile(name string) string {
 	return filepath.Join(manifestsPath(), fmt.Sprintf(DefaultConfigFile, name))
@@ -425,6 +610,11 @@ func manifestsPath() string {
 	return filepath.Join(pathutil.Abs(BaseDir()), DefaultManifestsDir)
 }
 
+// BackupsDir returns the nostromo backups dir
+func BackupsDir() string {
+	return backupsPath()
+}
+
 // backupsPath joins the base directory and the backups directory
 func backupsPath() string {
 	return filepath.Join(pathutil.Abs(BaseDir()), DefaultBackupsDir)
@@ -447,6 +637,11 @@ func downloadsPath() string {
 	return filepath.Join(pathutil.Abs(BaseDir()), DefaultDownloadsDir)
 }
 
+// includesPath for remote includes fetched when parsing manifests
+func includesPath() string {
+	return filepath.Join(pathutil.Abs(BaseDir()), DefaultIncludesDir)
+}
+
 // coreManifestURL returns the core manifest URL
 func coreManifestURL() (*url.URL, error) {
 	rawURL := filepath.Join(FileURLScheme, coreManifestPath())
@@ -1,8 +1,11 @@
 package config
 
 import (
+	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"reflect"
 	"testing"
@@ -62,6 +65,81 @@ func TestLoadConfig(t *testing.T) {
 	}
 }
 
+func TestLoadConfigReadOnly(t *testing.T) {
+	os.Setenv("NOSTROMO_HOME", "/tmp/nostromo")
+	defer os.Unsetenv("NOSTROMO_HOME")
+	os.MkdirAll("/tmp/nostromo/ships", 0777)
+	defer os.RemoveAll("/tmp/nostromo")
+
+	m := fakeManifest("/tmp/nostromo/ships/manifest.yaml")
+	if err := SaveManifest(m, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// Missing spaceport is fine and nothing is written
+	c, err := LoadConfigReadOnly()
+	if err != nil {
+		t.Fatalf("want no error, got %s", err)
+	}
+	if c.spaceport.CoreManifest().Find("one.two.three") == nil {
+		t.Errorf("want command from parsed manifest")
+	}
+	if _, err := os.Stat(spaceportFile()); !os.IsNotExist(err) {
+		t.Errorf("want spaceport to not be written")
+	}
+	if _, err := readSpaceportCache(); err == nil {
+		t.Errorf("want no spaceport cache")
+	}
+
+	// Loading normally compiles the cache
+	if _, err := LoadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(spaceportFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readSpaceportCache(); err != nil {
+		t.Errorf("want valid spaceport cache, got %s", err)
+	}
+
+	c, err = LoadConfigReadOnly()
+	if err != nil {
+		t.Fatalf("want no error, got %s", err)
+	}
+	if c.spaceport.CoreManifest().Find("one.two.three") == nil {
+		t.Errorf("want command from cached manifest")
+	}
+	if after, _ := os.Stat(spaceportFile()); !after.ModTime().Equal(info.ModTime()) {
+		t.Errorf("want spaceport to be untouched")
+	}
+
+	// Changing a manifest invalidates the cache
+	time.Sleep(10 * time.Millisecond)
+	m.AddCommand("four", "command", "", &model.Code{}, false, "concatenate")
+	if err := SaveManifest(m, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readSpaceportCache(); err == nil {
+		t.Errorf("want stale spaceport cache")
+	}
+	c, err = LoadConfigReadOnly()
+	if err != nil {
+		t.Fatalf("want no error, got %s", err)
+	}
+	if c.spaceport.CoreManifest().Find("four") == nil {
+		t.Errorf("want command from updated manifest")
+	}
+
+	// Reading rebuilds the stale cache without touching the spaceport
+	if _, err := readSpaceportCache(); err != nil {
+		t.Errorf("want rebuilt spaceport cache, got %s", err)
+	}
+	if after, _ := os.Stat(spaceportFile()); !after.ModTime().Equal(info.ModTime()) {
+		t.Errorf("want spaceport to be untouched")
+	}
+}
+
 func TestNewConfig(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -138,6 +216,108 @@ func TestParse(t *testing.T) {
 	}
 }
 
+func TestParseIncludes(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  map[string]string
+		alias  string
+		expErr string
+	}{
+		{"relative include", map[string]string{
+			"manifest.yaml":      "name: main\nincludes:\n- shared/common.yaml\n",
+			"shared/common.yaml": "commands:\n  hello:\n    name: echo hello\n    alias: hello\n",
+		}, "hello", ""},
+		{"nested include", map[string]string{
+			"manifest.yaml":      "name: main\nincludes:\n- shared/common.yaml\n",
+			"shared/common.yaml": "name: common\nincludes:\n- base.yaml\n",
+			"shared/base.yaml":   "commands:\n  base:\n    name: echo base\n    alias: base\n",
+		}, "base", ""},
+		{"missing include", map[string]string{
+			"manifest.yaml": "name: main\nincludes:\n- missing.yaml\n",
+		}, "", "cannot include missing.yaml in main manifest: file not found"},
+		{"include cycle", map[string]string{
+			"manifest.yaml": "name: main\nincludes:\n- other.yaml\n",
+			"other.yaml":    "name: other\nincludes:\n- manifest.yaml\n",
+		}, "", "include cycle in other manifest: /tmp/nostromo/manifest.yaml -> /tmp/nostromo/other.yaml -> /tmp/nostromo/manifest.yaml"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			os.MkdirAll("/tmp/nostromo/shared", 0777)
+			defer os.RemoveAll("/tmp/nostromo")
+			for name, contents := range test.files {
+				if err := ioutil.WriteFile("/tmp/nostromo/"+name, []byte(contents), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			m, err := Parse("/tmp/nostromo/manifest.yaml")
+			if len(test.expErr) > 0 {
+				if err == nil || err.Error() != test.expErr {
+					t.Errorf("expected error '%s' but got '%v'", test.expErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+			if err := m.Link(); err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+			if m.Find(test.alias) == nil {
+				t.Errorf("expected included command %s", test.alias)
+			}
+		})
+	}
+}
+
+func TestParseRemoteIncludes(t *testing.T) {
+	os.Setenv("NOSTROMO_HOME", "/tmp/nostromo")
+	defer os.Unsetenv("NOSTROMO_HOME")
+	os.MkdirAll("/tmp/nostromo", 0777)
+	defer os.RemoveAll("/tmp/nostromo")
+
+	fetches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		fmt.Fprintf(w, "commands:\n  remote:\n    name: echo %d\n    alias: remote\n", fetches)
+	}))
+	defer server.Close()
+
+	url := server.URL + "/common.yaml"
+	contents := "name: main\nincludes:\n- " + url + "\n"
+	if err := ioutil.WriteFile("/tmp/nostromo/manifest.yaml", []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	parse := func() *model.Manifest {
+		m, err := Parse("/tmp/nostromo/manifest.yaml")
+		if err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+		if err := m.Link(); err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+		return m
+	}
+
+	m := parse()
+	parse()
+	if fetches != 1 {
+		t.Errorf("expected remote include to be fetched once, fetched %d times", fetches)
+	}
+	if paths := includedPaths(m); !reflect.DeepEqual(paths, []string{includeCacheFile(url)}) {
+		t.Errorf("expected fetched include in cached paths, got %v", paths)
+	}
+
+	if err := refreshIncludes(); err != nil {
+		t.Fatal(err)
+	}
+	if c := parse().Find("remote"); c == nil || c.Name != "echo 2" {
+		t.Errorf("expected refreshed remote include, got %v", c)
+	}
+}
+
 func TestSave(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -270,6 +450,21 @@ func TestSet(t *testing.T) {
 		{"backupCount empty", "backupCount", "", true, ""},
 		{"backupCount 5", "backupCount", "5", false, "5"},
 		{"backupCount 100", "backupCount", "100", false, "100"},
+		{"startupFiles empty", "startupFiles", "", false, ""},
+		{"startupFiles list", "startupFiles", "~/.config/bash/bashrc, ~/.zshrc", false, "~/.config/bash/bashrc,~/.zshrc"},
+		{"backupDir", "backupDir", "~/backups", false, "~/backups"},
+		{"preferredShells list", "preferredShells", "zsh,fish", false, "zsh,fish"},
+		{"preferredShells invalid", "preferredShells", "zsh,tcsh", true, ""},
+		{"disableHistory true", "disableHistory", "true", false, "true"},
+		{"disableHistory invalid", "disableHistory", "nope", true, ""},
+		{"historySize 50", "historySize", "50", false, "50"},
+		{"historySize negative", "historySize", "-1", true, ""},
+		{"historyDays 30", "historyDays", "30", false, "30"},
+		{"historyDays invalid", "historyDays", "month", true, ""},
+		{"secretBackend env", "secretBackend", "env", false, "env"},
+		{"secretBackend invalid", "secretBackend", "keyring", true, ""},
+		{"confirmSources list", "confirmSources", "https://github.com/acme/*, file:///ops.yaml", false, "https://github.com/acme/*,file:///ops.yaml"},
+		{"confirmSources empty", "confirmSources", "", false, ""},
 	}
 
 	for _, test := range tests {
@@ -295,7 +490,7 @@ func TestKeys(t *testing.T) {
 		config   *Config
 		expected []string
 	}{
-		{"keys", fakeConfig(""), []string{"verbose", "aliasesOnly", "mode", "backupCount"}},
+		{"keys", fakeConfig(""), []string{"verbose", "aliasesOnly", "mode", "backupCount", "startupFiles", "backupDir", "preferredShells", "disableHistory", "historySize", "historyDays", "secretBackend"}},
 	}
 
 	for _, test := range tests {
@@ -317,10 +512,17 @@ func TestFields(t *testing.T) {
 			"keys",
 			fakeConfig(""),
 			map[string]interface{}{
-				"verbose":     false,
-				"aliasesOnly": false,
-				"mode":        model.ConcatenateMode.String(),
-				"backupCount": 10,
+				"verbose":         false,
+				"aliasesOnly":     false,
+				"mode":            model.ConcatenateMode.String(),
+				"backupCount":     10,
+				"startupFiles":    "",
+				"backupDir":       "",
+				"preferredShells": "",
+				"disableHistory":  false,
+				"historySize":     0,
+				"historyDays":     0,
+				"secretBackend":   "",
 			},
 		},
 	}
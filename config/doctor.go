@@ -0,0 +1,94 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pokanop/nostromo/model"
+)
+
+// ManifestErrors for manifests in the ships folder that can't be parsed
+func ManifestErrors() []error {
+	_, errs := parseManifestFiles()
+	return errs
+}
+
+// MissingManifests referenced by the spaceport sequence without a manifest
+// that can be loaded
+//
+// Loading the config with `LoadConfig` rebuilds the sequence and drops any
+// missing manifests.
+func MissingManifests() ([]string, error) {
+	s, err := loadSpaceport()
+	if err != nil {
+		return nil, err
+	}
+
+	names, _ := parseManifestFiles()
+	missing := []string{}
+	for _, name := range s.Sequence {
+		if !names[name] {
+			missing = append(missing, name)
+		}
+	}
+	return missing, nil
+}
+
+// UnlinkedManPages in the nostromo man folder that are missing from the
+// system man folder
+func UnlinkedManPages() ([]string, error) {
+	manpages, err := ioutil.ReadDir(ManDir())
+	if err != nil {
+		return nil, err
+	}
+	if len(manpages) == 0 {
+		return nil, fmt.Errorf("no man pages found in %s", ManDir())
+	}
+
+	unlinked := []string{}
+	sysmandir := filepath.Join(SystemPrefixDir, "share", "man", "man1")
+	for _, manpage := range manpages {
+		name := manpage.Name()
+		if _, err := os.Stat(filepath.Join(sysmandir, name)); err != nil {
+			unlinked = append(unlinked, name)
+		}
+	}
+	return unlinked, nil
+}
+
+// parseManifestFiles in the ships folder returning the names of manifests
+// that were parsed and errors for the rest
+func parseManifestFiles() (map[string]bool, []error) {
+	names := map[string]bool{}
+	errs := []error{}
+
+	files, err := ioutil.ReadDir(manifestsPath())
+	if err != nil {
+		return names, []error{err}
+	}
+
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".yaml") {
+			continue
+		}
+
+		path := filepath.Join(manifestsPath(), file.Name())
+		m, err := Parse(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", path, err))
+			continue
+		}
+
+		// The core manifest is always named by its file
+		if file.Name() == coreManifestFile() {
+			names[model.CoreManifestName] = true
+			continue
+		}
+		names[m.Name] = true
+	}
+
+	return names, errs
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestManifestErrors(t *testing.T) {
+	os.Setenv("NOSTROMO_HOME", "/tmp/nostromo")
+	defer os.Unsetenv("NOSTROMO_HOME")
+	os.MkdirAll("/tmp/nostromo/ships", 0777)
+	defer os.RemoveAll("/tmp/nostromo")
+
+	if err := SaveManifest(fakeManifest("/tmp/nostromo/ships/manifest.yaml"), false); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ManifestErrors(); len(errs) != 0 {
+		t.Fatalf("expected no errors but got %v", errs)
+	}
+
+	ioutil.WriteFile("/tmp/nostromo/ships/broken.yaml", []byte("commands: ["), 0644)
+	errs := ManifestErrors()
+	if len(errs) != 1 || !strings.HasPrefix(errs[0].Error(), "/tmp/nostromo/ships/broken.yaml: ") {
+		t.Errorf("expected error for broken manifest but got %v", errs)
+	}
+}
+
+func TestMissingManifests(t *testing.T) {
+	os.Setenv("NOSTROMO_HOME", "/tmp/nostromo")
+	defer os.Unsetenv("NOSTROMO_HOME")
+	os.MkdirAll("/tmp/nostromo/ships", 0777)
+	defer os.RemoveAll("/tmp/nostromo")
+
+	if _, err := MissingManifests(); err == nil {
+		t.Errorf("expected error for missing spaceport")
+	}
+
+	if err := SaveManifest(fakeManifest("/tmp/nostromo/ships/manifest.yaml"), false); err != nil {
+		t.Fatal(err)
+	}
+	ioutil.WriteFile(spaceportFile(), []byte("sequence: [manifest, ghost]\n"), 0644)
+
+	missing, err := MissingManifests()
+	if err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+	if !reflect.DeepEqual(missing, []string{"ghost"}) {
+		t.Errorf("expected ghost to be missing but got %v", missing)
+	}
+
+	// Loading the config rebuilds the sequence
+	if _, err := LoadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if missing, _ = MissingManifests(); len(missing) != 0 {
+		t.Errorf("expected no missing manifests but got %v", missing)
+	}
+}
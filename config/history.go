@@ -0,0 +1,129 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+
+	"github.com/pokanop/nostromo/log"
+	"github.com/pokanop/nostromo/model"
+)
+
+// historyPruneMargin is how many times over the size limit the history file
+// can grow before recording prunes it
+const historyPruneMargin = 2
+
+// historyEntrySize estimates the bytes of an entry so the file size that
+// triggers pruning doesn't depend on the entry being recorded
+const historyEntrySize = 160
+
+// RecordHistory appends the entry to the history file unless history is
+// disabled
+//
+// Recording only appends so it stays cheap on hot paths like `eval`. The
+// file is pruned once it's well past the size limit, estimated with a
+// fixed entry size, and otherwise when history is loaded.
+func RecordHistory(cfg *model.Config, entry *model.HistoryEntry) error {
+	if cfg.DisableHistory {
+		return nil
+	}
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	f, err := os.OpenFile(historyFile(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	size, _ := cfg.HistoryRetention()
+	if info.Size() > int64(historyPruneMargin*size*historyEntrySize) {
+		_, err = LoadHistory(cfg)
+	}
+	return err
+}
+
+// LoadHistory entries oldest first with entries past retention pruned from
+// the history file
+func LoadHistory(cfg *model.Config) ([]*model.HistoryEntry, error) {
+	b, err := ioutil.ReadFile(historyFile())
+	if os.IsNotExist(err) {
+		return []*model.HistoryEntry{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	entries := []*model.HistoryEntry{}
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		entry := &model.HistoryEntry{}
+		if err := json.Unmarshal(line, entry); err != nil {
+			log.Debugf("skipping invalid history entry: %s\n", err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	size, age := cfg.HistoryRetention()
+	pruned := model.PruneHistory(entries, size, age)
+	if len(pruned) < len(entries) {
+		if err := writeHistory(pruned); err != nil {
+			return nil, err
+		}
+	}
+
+	return pruned, nil
+}
+
+// ClearHistory removes all history entries
+func ClearHistory() error {
+	err := os.Remove(historyFile())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// writeHistory replaces the history file with entries
+func writeHistory(entries []*model.HistoryEntry) error {
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		b, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		buf.Write(append(b, '\n'))
+	}
+
+	// Write to a temporary file first so a failure never loses history
+	tmp := historyFile() + ".tmp"
+	if err := ioutil.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, historyFile())
+}
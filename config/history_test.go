@@ -0,0 +1,106 @@
+package config
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pokanop/nostromo/model"
+)
+
+func TestRecordHistory(t *testing.T) {
+	os.Setenv("NOSTROMO_HOME", t.TempDir())
+	defer os.Unsetenv("NOSTROMO_HOME")
+
+	cfg := model.NewConfig()
+	cfg.HistorySize = 3
+	lines := func() int {
+		b, _ := ioutil.ReadFile(historyFile())
+		return strings.Count(string(b), "\n")
+	}
+	// Entries at a fixed time have the same size so pruning is predictable
+	entry := func(keyPath string) *model.HistoryEntry {
+		e := model.NewHistoryEntry(keyPath, "manifest", []string{"arg"})
+		e.Time = time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+		return e
+	}
+	b, _ := json.Marshal(entry("a"))
+	limit := historyPruneMargin * cfg.HistorySize * historyEntrySize / (len(b) + 1)
+
+	keyPaths := []string{}
+	for i := 0; i < limit; i++ {
+		keyPath := string(rune('a' + i))
+		keyPaths = append(keyPaths, keyPath)
+		if err := RecordHistory(cfg, entry(keyPath)); err != nil {
+			t.Fatalf("expected no error but got %s", err)
+		}
+	}
+	if n := lines(); n != limit {
+		t.Errorf("expected recording to only append %d entries but got %d", limit, n)
+	}
+
+	// Recording prunes once the file is past the limit
+	keyPaths = append(keyPaths, "z")
+	if err := RecordHistory(cfg, entry("z")); err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+	if n := lines(); n != 3 {
+		t.Errorf("expected recording to prune but got %d entries", n)
+	}
+
+	entries, err := LoadHistory(cfg)
+	if err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+	var actual []string
+	for _, entry := range entries {
+		actual = append(actual, entry.KeyPath)
+	}
+	if expected := strings.Join(keyPaths[len(keyPaths)-3:], ","); strings.Join(actual, ",") != expected {
+		t.Errorf("expected entries %s but got %v", expected, actual)
+	}
+
+	cfg.DisableHistory = true
+	RecordHistory(cfg, model.NewHistoryEntry("e", "manifest", nil))
+	if entries, _ = LoadHistory(cfg); len(entries) != 3 {
+		t.Errorf("expected disabled history to not record but got %d entries", len(entries))
+	}
+
+	if err := ClearHistory(); err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+	if entries, _ = LoadHistory(cfg); len(entries) != 0 {
+		t.Errorf("expected cleared history but got %d entries", len(entries))
+	}
+}
+
+func TestLoadHistory(t *testing.T) {
+	os.Setenv("NOSTROMO_HOME", t.TempDir())
+	defer os.Unsetenv("NOSTROMO_HOME")
+
+	old := time.Now().Add(-72 * time.Hour).Format(time.RFC3339)
+	recent := time.Now().Format(time.RFC3339)
+	ioutil.WriteFile(historyFile(), []byte(strings.Join([]string{
+		`{"time":"` + old + `","keyPath":"old","manifest":"manifest"}`,
+		`not json`,
+		``,
+		`{"time":"` + recent + `","keyPath":"new","manifest":"manifest","exitCode":1,"durationMs":20}`,
+	}, "\n")), 0600)
+
+	cfg := model.NewConfig()
+	entries, err := LoadHistory(cfg)
+	if err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+	if len(entries) != 2 || !entries[1].Failed() || entries[1].DurationMs != 20 {
+		t.Fatalf("expected valid entries to load but got %v", entries)
+	}
+
+	cfg.HistoryDays = 1
+	if entries, _ = LoadHistory(cfg); len(entries) != 1 || entries[0].KeyPath != "new" {
+		t.Errorf("expected old entries to be pruned but got %v", entries)
+	}
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pokanop/nostromo/model"
+	"github.com/pokanop/nostromo/pathutil"
+)
+
+// includeManifests parses manifests included by m and attaches them
+//
+// Relative includes are resolved against the location of the including
+// manifest and then its source. The chain of locations visited is used
+// to detect include cycles.
+func includeManifests(m *model.Manifest, location string, chain []string) error {
+	for _, include := range m.Includes {
+		target, err := includeLocation(include, location, m.Source)
+		if err != nil {
+			return fmt.Errorf("cannot include %s in %s manifest: %s", include, m.Name, err)
+		}
+
+		for _, visited := range chain {
+			if visited == target {
+				return fmt.Errorf("include cycle in %s manifest: %s", m.Name, strings.Join(append(chain, target), " -> "))
+			}
+		}
+
+		b, err := readInclude(target)
+		if err != nil {
+			return fmt.Errorf("cannot include %s in %s manifest: %s", include, m.Name, err)
+		}
+
+		inc, err := unmarshalManifest(b, path.Ext(target))
+		if err != nil {
+			return fmt.Errorf("cannot include %s in %s manifest: %s", include, m.Name, err)
+		}
+		if len(inc.Name) == 0 {
+			inc.Name = strings.TrimSuffix(path.Base(target), path.Ext(target))
+		}
+		inc.Source = target
+		if !isRemote(target) {
+			inc.Path = target
+		}
+
+		if err := includeManifests(inc, target, append(chain, target)); err != nil {
+			return err
+		}
+
+		m.Include(inc)
+	}
+
+	return nil
+}
+
+// includeLocation resolves an include to an absolute path or URL
+func includeLocation(include, location, source string) (string, error) {
+	if isRemote(include) {
+		return include, nil
+	}
+
+	candidates := []string{location}
+	if u, err := url.Parse(source); err == nil && (isRemote(source) || u.Scheme == "file") {
+		candidates = append(candidates, source)
+	}
+
+	for _, base := range candidates {
+		if isRemote(base) {
+			u, err := url.Parse(base)
+			if err != nil {
+				continue
+			}
+			ref, err := url.Parse(include)
+			if err != nil {
+				return "", err
+			}
+			return u.ResolveReference(ref).String(), nil
+		}
+
+		base = strings.TrimPrefix(base, FileURLScheme)
+		target := pathutil.Expand(include)
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(pathutil.Abs(base)), target)
+		}
+		if _, err := os.Stat(target); err == nil {
+			return target, nil
+		}
+	}
+
+	return "", fmt.Errorf("file not found")
+}
+
+// readInclude from a local path or remote URL
+//
+// Remote includes are fetched once and read from the includes folder
+// afterwards until they're refreshed by a sync.
+func readInclude(target string) ([]byte, error) {
+	if !isRemote(target) {
+		return ioutil.ReadFile(target)
+	}
+
+	cached := includeCacheFile(target)
+	if b, err := ioutil.ReadFile(cached); err == nil {
+		return b, nil
+	}
+
+	b, err := fetchInclude(target)
+	if err != nil {
+		return nil, err
+	}
+	if err := pathutil.EnsurePath(includesPath()); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(cached, b, 0644); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// fetchInclude from a remote URL
+func fetchInclude(target string) ([]byte, error) {
+	resp, err := http.Get(target)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("remote file not found")
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+// refreshIncludes removes fetched remote includes so they're fetched again
+// the next time they're parsed
+func refreshIncludes() error {
+	return os.RemoveAll(includesPath())
+}
+
+// includeCacheFile for a remote include named after a hash of its URL
+func includeCacheFile(target string) string {
+	sum := sha256.Sum256([]byte(target))
+	return filepath.Join(includesPath(), hex.EncodeToString(sum[:])+path.Ext(target))
+}
+
+// includedPaths of files included by manifests recursively with remote
+// includes at the file they were fetched to
+func includedPaths(m *model.Manifest) []string {
+	paths := []string{}
+	for _, inc := range m.IncludedManifests() {
+		if len(inc.Path) > 0 {
+			paths = append(paths, inc.Path)
+		} else if isRemote(inc.Source) {
+			paths = append(paths, includeCacheFile(inc.Source))
+		}
+		paths = append(paths, includedPaths(inc)...)
+	}
+	return paths
+}
+
+func isRemote(target string) bool {
+	return strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://")
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pokanop/nostromo/log"
+	"github.com/pokanop/nostromo/model"
+	"gopkg.in/yaml.v2"
+)
+
+// FindProjectManifest walks up from dir looking for a project manifest
+//
+// Returns the path to the closest `.nostromo.yaml` or an empty string if
+// none exists between dir and the filesystem root.
+func FindProjectManifest(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+
+	for {
+		path := filepath.Join(dir, DefaultProjectFile)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// ProjectManifestPrefix namespaces project manifest names so they never
+// collide with docked manifests
+const ProjectManifestPrefix = "project:"
+
+// ParseProjectManifest at path into a `Manifest` object
+//
+// Project manifests don't require a name and default to the name of the
+// folder they are found in. Names are prefixed with `project:`.
+func ParseProjectManifest(path string) (*model.Manifest, error) {
+	log.Debugf("parsing project manifest at %s\n", path)
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := unmarshalManifest(b, filepath.Ext(path))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(m.Name) == 0 {
+		m.Name = filepath.Base(filepath.Dir(path))
+	}
+	m.Name = ProjectManifestPrefix + m.Name
+	m.Source = FileURLScheme + path
+	m.Path = path
+
+	if err := includeManifests(m, path, []string{path}); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// IsTrusted returns true if the project manifest at path was trusted with
+// its current content and the content of the manifests it includes
+//
+// Includes are only resolved if the manifest itself is unchanged so those
+// of a changed manifest aren't fetched before it's trusted again.
+func IsTrusted(path string) bool {
+	trusted, err := loadTrusted()
+	if err != nil {
+		log.Debugf("unable to load trusted manifests: %s\n", err)
+		return false
+	}
+
+	hash, err := fileHash(path)
+	if err != nil || !strings.HasPrefix(trusted[path], hash+":") {
+		return false
+	}
+
+	hash, err = trustHash(path)
+	if err != nil {
+		log.Debugf("unable to hash project manifest: %s\n", err)
+		return false
+	}
+
+	return trusted[path] == hash
+}
+
+// Trust the project manifest at path with its current content
+//
+// Changing the manifest or any manifest it includes afterwards revokes
+// trust until trusted again.
+func Trust(path string) error {
+	hash, err := trustHash(path)
+	if err != nil {
+		return err
+	}
+
+	trusted, err := loadTrusted()
+	if err != nil {
+		return err
+	}
+	trusted[path] = hash
+
+	b, err := yaml.Marshal(trusted)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(trustFile(), b, 0644)
+}
+
+// trustHash of the project manifest at path followed by a hash of all the
+// files it includes like `manifest:includes`
+func trustHash(path string) (string, error) {
+	hash, err := fileHash(path)
+	if err != nil {
+		return "", err
+	}
+
+	m, err := ParseProjectManifest(path)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	for _, p := range includedPaths(m) {
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s %d\n", p, len(b))
+		h.Write(b)
+	}
+
+	return hash + ":" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// loadTrusted project manifest paths and their content hashes
+func loadTrusted() (map[string]string, error) {
+	trusted := map[string]string{}
+
+	b, err := ioutil.ReadFile(trustFile())
+	if os.IsNotExist(err) {
+		return trusted, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(b, &trusted); err != nil {
+		return nil, fmt.Errorf("invalid trust file: %s", err)
+	}
+
+	return trusted, nil
+}
+
+func fileHash(path string) (string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
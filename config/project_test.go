@@ -0,0 +1,113 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindProjectManifest(t *testing.T) {
+	os.MkdirAll("/tmp/nostromo/project/a/b", 0777)
+	defer os.RemoveAll("/tmp/nostromo")
+	ioutil.WriteFile("/tmp/nostromo/project/.nostromo.yaml", []byte("commands: {}\n"), 0644)
+
+	tests := []struct {
+		name     string
+		dir      string
+		expected string
+	}{
+		{"same dir", "/tmp/nostromo/project", "/tmp/nostromo/project/.nostromo.yaml"},
+		{"parent dir", "/tmp/nostromo/project/a/b", "/tmp/nostromo/project/.nostromo.yaml"},
+		{"not found", "/tmp/nostromo", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := FindProjectManifest(test.dir); actual != test.expected {
+				t.Errorf("expected: %s, actual: %s", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParseProjectManifest(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		expName  string
+		expErr   bool
+	}{
+		{"default name", "commands:\n  hello:\n    name: echo hello\n    alias: hello\n", "project:project", false},
+		{"named", "name: app\ncommands: {}\n", "project:app", false},
+		{"bad contents", "commands: [", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			os.MkdirAll("/tmp/nostromo/project", 0777)
+			defer os.RemoveAll("/tmp/nostromo")
+			path := "/tmp/nostromo/project/.nostromo.yaml"
+			ioutil.WriteFile(path, []byte(test.contents), 0644)
+
+			m, err := ParseProjectManifest(path)
+			if test.expErr {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+			if m.Name != test.expName {
+				t.Errorf("expected name %s but got %s", test.expName, m.Name)
+			}
+			if m.Path != path {
+				t.Errorf("expected path %s but got %s", path, m.Path)
+			}
+		})
+	}
+}
+
+func TestTrust(t *testing.T) {
+	os.Setenv("NOSTROMO_HOME", "/tmp/nostromo")
+	defer os.Unsetenv("NOSTROMO_HOME")
+	os.MkdirAll("/tmp/nostromo/project", 0777)
+	defer os.RemoveAll("/tmp/nostromo")
+
+	path := filepath.Join("/tmp/nostromo/project", DefaultProjectFile)
+	ioutil.WriteFile(path, []byte("commands: {}\n"), 0644)
+
+	if IsTrusted(path) {
+		t.Fatalf("expected project manifest to be untrusted")
+	}
+
+	if err := Trust(path); err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+	if !IsTrusted(path) {
+		t.Errorf("expected project manifest to be trusted")
+	}
+
+	ioutil.WriteFile(path, []byte("commands:\n  rm:\n    name: rm -rf /\n    alias: rm\n"), 0644)
+	if IsTrusted(path) {
+		t.Errorf("expected modified project manifest to be untrusted")
+	}
+
+	// Includes are covered by trust too
+	include := filepath.Join("/tmp/nostromo/project", "shared.yaml")
+	ioutil.WriteFile(include, []byte("commands: {}\n"), 0644)
+	ioutil.WriteFile(path, []byte("includes:\n- shared.yaml\n"), 0644)
+	if err := Trust(path); err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+	if !IsTrusted(path) {
+		t.Errorf("expected project manifest with includes to be trusted")
+	}
+
+	ioutil.WriteFile(include, []byte("commands:\n  rm:\n    name: rm -rf /\n    alias: rm\n"), 0644)
+	if IsTrusted(path) {
+		t.Errorf("expected project manifest with modified include to be untrusted")
+	}
+}
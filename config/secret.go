@@ -0,0 +1,16 @@
+package config
+
+import (
+	"github.com/pokanop/nostromo/model"
+	"github.com/pokanop/nostromo/secret"
+)
+
+// OpenSecrets backend set in config with the vault stored in the base dir
+//
+// The passphrase is only requested when the vault is unlocked.
+func OpenSecrets(cfg *model.Config, passphrase func(create bool) (string, error)) (secret.Backend, error) {
+	return secret.Open(cfg.SecretBackend, &secret.Options{
+		Path:       vaultFile(),
+		Passphrase: passphrase,
+	})
+}
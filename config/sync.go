@@ -116,6 +116,11 @@ func (c *Config) syncPrep(sources []string) ([]string, error) {
 		return nil, err
 	}
 
+	// Fetch remote includes again when manifests are parsed
+	if err := refreshIncludes(); err != nil {
+		return nil, err
+	}
+
 	// Sanitize github web urls
 	s := []string{}
 	for _, source := range sources {
@@ -0,0 +1,255 @@
+package dotenv
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Entry for a key with its value in the order it was defined
+type Entry struct {
+	Key   string
+	Value string
+	// Line the entry was defined on starting from 1
+	Line int
+}
+
+// parser state for dotenv content
+type parser struct {
+	s      string
+	i      int
+	vars   map[string]string
+	lookup func(name string) (string, bool)
+}
+
+// Parse dotenv content into entries
+//
+// Lines look like `KEY=value` with an optional `export` prefix and `#`
+// comments. Single quoted values are literal, double quoted values support
+// escapes like `\n` and can span lines. Unquoted and double quoted values
+// interpolate `${VAR}` and `$VAR` from keys defined earlier and then lookup
+// with missing variables replaced by an empty string.
+func Parse(content string, lookup func(name string) (string, bool)) ([]*Entry, error) {
+	p := &parser{
+		s:      strings.ReplaceAll(content, "\r\n", "\n"),
+		vars:   map[string]string{},
+		lookup: lookup,
+	}
+
+	var entries []*Entry
+	for {
+		p.skip(" \t\n")
+		if p.done() {
+			return entries, nil
+		}
+		if p.peek() == '#' {
+			p.skipLine()
+			continue
+		}
+
+		line := p.line()
+		entry, err := p.entry()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+		entry.Line = line
+		p.vars[entry.Key] = entry.Value
+		entries = append(entries, entry)
+	}
+}
+
+// entry at the current position through the end of its line
+func (p *parser) entry() (*Entry, error) {
+	if strings.HasPrefix(p.s[p.i:], "export ") || strings.HasPrefix(p.s[p.i:], "export\t") {
+		p.i += len("export")
+		p.skip(" \t")
+	}
+
+	start := p.i
+	for !p.done() && isKeyChar(p.peek()) {
+		p.i++
+	}
+	key := p.s[start:p.i]
+	if len(key) == 0 || (key[0] >= '0' && key[0] <= '9') {
+		return nil, fmt.Errorf("invalid key %s", key)
+	}
+
+	p.skip(" \t")
+	if p.done() || p.peek() != '=' {
+		return nil, fmt.Errorf("expected '=' after %s", key)
+	}
+	p.i++
+	p.skip(" \t")
+
+	var value string
+	var err error
+	switch {
+	case p.done():
+	case p.peek() == '\'':
+		value, err = p.singleQuoted()
+	case p.peek() == '"':
+		value, err = p.doubleQuoted()
+	default:
+		value = p.unquoted()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s for %s", err, key)
+	}
+
+	// Only a comment can follow a value on the same line
+	p.skip(" \t")
+	if !p.done() && p.peek() != '\n' && p.peek() != '#' {
+		return nil, fmt.Errorf("unexpected characters after value for %s", key)
+	}
+	p.skipLine()
+
+	return &Entry{Key: key, Value: value}, nil
+}
+
+func (p *parser) singleQuoted() (string, error) {
+	p.i++
+	end := strings.IndexByte(p.s[p.i:], '\'')
+	if end == -1 {
+		return "", fmt.Errorf("unterminated single quote")
+	}
+	value := p.s[p.i : p.i+end]
+	p.i += end + 1
+	return value, nil
+}
+
+func (p *parser) doubleQuoted() (string, error) {
+	p.i++
+	var b strings.Builder
+	for !p.done() {
+		c := p.s[p.i]
+		switch c {
+		case '"':
+			p.i++
+			return b.String(), nil
+		case '\\':
+			if p.i+1 < len(p.s) {
+				p.i++
+				b.WriteString(unescape(p.s[p.i]))
+				p.i++
+				continue
+			}
+		case '$':
+			b.WriteString(p.variable())
+			continue
+		}
+		b.WriteByte(c)
+		p.i++
+	}
+	return "", fmt.Errorf("unterminated double quote")
+}
+
+// unquoted value through the end of the line without an inline comment
+func (p *parser) unquoted() string {
+	end := strings.IndexByte(p.s[p.i:], '\n')
+	if end == -1 {
+		end = len(p.s) - p.i
+	}
+	raw := p.s[p.i : p.i+end]
+	if c := strings.Index(raw, " #"); c != -1 {
+		raw = raw[:c]
+	} else if c := strings.Index(raw, "\t#"); c != -1 {
+		raw = raw[:c]
+	}
+	raw = strings.TrimRight(raw, " \t")
+
+	sub := &parser{s: raw, vars: p.vars, lookup: p.lookup}
+	var b strings.Builder
+	for !sub.done() {
+		if sub.peek() == '$' {
+			b.WriteString(sub.variable())
+			continue
+		}
+		b.WriteByte(sub.peek())
+		sub.i++
+	}
+	p.i += len(raw)
+	return b.String()
+}
+
+// variable reference at `$` replaced with its value or `$` as is if it
+// isn't followed by a name
+func (p *parser) variable() string {
+	p.i++
+	braced := !p.done() && p.peek() == '{'
+	start := p.i
+	if braced {
+		start++
+	}
+	end := start
+	for end < len(p.s) && isNameChar(p.s[end], end == start) {
+		end++
+	}
+	if end == start || (braced && (end >= len(p.s) || p.s[end] != '}')) {
+		return "$"
+	}
+
+	name := p.s[start:end]
+	p.i = end
+	if braced {
+		p.i++
+	}
+	if value, ok := p.vars[name]; ok {
+		return value
+	}
+	if p.lookup != nil {
+		if value, ok := p.lookup(name); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+func (p *parser) done() bool {
+	return p.i >= len(p.s)
+}
+
+func (p *parser) peek() byte {
+	return p.s[p.i]
+}
+
+func (p *parser) line() int {
+	return strings.Count(p.s[:p.i], "\n") + 1
+}
+
+func (p *parser) skip(chars string) {
+	for !p.done() && strings.IndexByte(chars, p.peek()) != -1 {
+		p.i++
+	}
+}
+
+func (p *parser) skipLine() {
+	if end := strings.IndexByte(p.s[p.i:], '\n'); end != -1 {
+		p.i += end + 1
+	} else {
+		p.i = len(p.s)
+	}
+}
+
+func unescape(c byte) string {
+	switch c {
+	case 'n':
+		return "\n"
+	case 'r':
+		return "\r"
+	case 't':
+		return "\t"
+	case '"', '\\', '$':
+		return string(c)
+	}
+	return "\\" + string(c)
+}
+
+func isKeyChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func isNameChar(c byte, first bool) bool {
+	if c >= '0' && c <= '9' {
+		return !first
+	}
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
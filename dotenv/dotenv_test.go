@@ -0,0 +1,58 @@
+package dotenv
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	lookup := func(name string) (string, bool) {
+		if name == "HOME" {
+			return "/home/me", true
+		}
+		return "", false
+	}
+
+	tests := []struct {
+		name     string
+		content  string
+		expected []*Entry
+		wantErr  bool
+	}{
+		{"empty", "\n# comment\n\n", nil, false},
+		{"simple", "A=1\nexport B = two words \n", []*Entry{{"A", "1", 1}, {"B", "two words", 2}}, false},
+		{"inline comment", "A=1 # one\nB=a#b", []*Entry{{"A", "1", 1}, {"B", "a#b", 2}}, false},
+		{"empty value", "A=\nB=", []*Entry{{"A", "", 1}, {"B", "", 2}}, false},
+		{"single quoted", `A='$HOME \n # x'`, []*Entry{{"A", `$HOME \n # x`, 1}}, false},
+		{"double quoted", `A="line\nnext \"q\" \$HOME \d"`, []*Entry{{"A", "line\nnext \"q\" $HOME \\d", 1}}, false},
+		{"multiline", "A=\"one\ntwo\"\nB=3", []*Entry{{"A", "one\ntwo", 1}, {"B", "3", 3}}, false},
+		{"interpolation", "A=x\nB=${A}-$A-${HOME}/$MISSING.", []*Entry{{"A", "x", 1}, {"B", "x-x-/home/me/.", 2}}, false},
+		{"quoted interpolation", "A=x\nB=\"${A}y\"\nC='${A}'", []*Entry{{"A", "x", 1}, {"B", "xy", 2}, {"C", "${A}", 3}}, false},
+		{"not a variable", "A=$ ${ $1 ${secret:token}", []*Entry{{"A", "$ ${ $1 ${secret:token}", 1}}, false},
+		{"crlf", "A=1\r\nB=2\r\n", []*Entry{{"A", "1", 1}, {"B", "2", 2}}, false},
+		{"missing equals", "A=1\nB", nil, true},
+		{"invalid key", "A.B=1", nil, true},
+		{"digit key", "1A=1", nil, true},
+		{"unterminated", `A="open`, nil, true},
+		{"trailing text", `A="x" y`, nil, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := Parse(test.content, lookup)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("expected: %v, actual: %v", entries(test.expected), entries(actual))
+			}
+		})
+	}
+}
+
+func entries(e []*Entry) []Entry {
+	var values []Entry
+	for _, entry := range e {
+		values = append(values, *entry)
+	}
+	return values
+}
@@ -0,0 +1,352 @@
+package exporter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pokanop/nostromo/keypath"
+	"github.com/pokanop/nostromo/model"
+	"github.com/pokanop/nostromo/stringutil"
+)
+
+// Supported export formats
+const (
+	Bash = "bash"
+	Zsh  = "zsh"
+	Fish = "fish"
+	Make = "make"
+	Just = "just"
+)
+
+// Formats that manifests can be exported as
+var Formats = []string{Bash, Zsh, Fish, Make, Just}
+
+// Languages for code snippets and how they're run like in the shell package
+var languages = []struct {
+	name    string
+	command string
+}{
+	{"ruby", "ruby -e"},
+	{"python", "python -c"},
+	{"perl", "perl -e"},
+	{"js", "node -e"},
+}
+
+// runnerVar holding the runner function for make and just
+const runnerVar = "NOSTROMO_RUNNER"
+
+var invalidIdentifier = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// Export a manifest as a self contained script in the given format
+//
+// Scripts define a runner function that walks the command tree, applies
+// substitutions in scope and replaces positional parameters the same way
+// nostromo does so they can be used without installing nostromo.
+func Export(m *model.Manifest, format string) (string, error) {
+	cmds, err := m.ExportCommands()
+	if err != nil {
+		return "", err
+	}
+	if len(cmds) == 0 {
+		return "", fmt.Errorf("manifest %s has no commands to export", m.Name)
+	}
+
+	runner := "__nostromo_" + invalidIdentifier.ReplaceAllString(m.Name, "_")
+	header := fmt.Sprintf("# Generated by nostromo from manifest %s, do not edit\n", m.Name)
+	switch format {
+	case Bash, Zsh:
+		return header + "# Load with: source <file>\n\n" + shRunner(runner, cmds) + "\n" + shFunctions(runner, cmds), nil
+	case Fish:
+		return header + "# Load with: source <file>\n\n" + fishRunner(runner, cmds) + "\n" + fishFunctions(runner, cmds), nil
+	case Make:
+		return header + "# Run with: make <key.path> ARGS=\"<args>\"\n\n" + makefile(runner, cmds), nil
+	case Just:
+		return header + "# Run with: just <key-path> <args>\n\n" + justfile(runner, cmds), nil
+	}
+	return "", fmt.Errorf("invalid export format %s, expected one of %s", format, strings.Join(Formats, ", "))
+}
+
+// shRunner function for POSIX like shells
+func shRunner(runner string, cmds []*model.ExportCommand) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s() {\n", runner)
+	b.WriteString("  local _kp=\"$1\" _cmd= _lang= _arg= _var= _out= _rest= _tail= _i=1\n")
+	b.WriteString("  shift\n")
+
+	b.WriteString("  while [ $# -gt 0 ]; do\n")
+	b.WriteString("    case \"$_kp.$1\" in\n")
+	if children := childKeyPaths(cmds); len(children) > 0 {
+		fmt.Fprintf(&b, "      %s) _kp=\"$_kp.$1\"; shift ;;\n", shPattern(children))
+	}
+	b.WriteString("      *) break ;;\n")
+	b.WriteString("    esac\n")
+	b.WriteString("  done\n")
+
+	b.WriteString("  case \"$_kp\" in\n")
+	for _, c := range runnable(cmds) {
+		fmt.Fprintf(&b, "    %s) _cmd=%s", stringutil.SingleQuote(c.KeyPath), stringutil.SingleQuote(c.Command))
+		if len(c.Language) > 0 {
+			fmt.Fprintf(&b, "; _lang=%s", stringutil.SingleQuote(c.Language))
+		}
+		b.WriteString(" ;;\n")
+	}
+	b.WriteString("    *) echo \"$_kp: no command to run\" >&2; return 1 ;;\n")
+	b.WriteString("  esac\n")
+
+	b.WriteString("  for _arg in \"$@\"; do\n")
+	if subs := subPatterns(cmds); len(subs) > 0 {
+		b.WriteString("    case \"$_kp $_arg\" in\n")
+		for _, sub := range subs {
+			fmt.Fprintf(&b, "      %s) _arg=%s ;;\n", shPattern(sub.patterns), stringutil.SingleQuote(sub.value))
+		}
+		b.WriteString("    esac\n")
+	}
+	b.WriteString("    _var=\"\\$$_i\"\n")
+	b.WriteString("    if [ -z \"$_tail\" ] && case \"$_cmd\" in *\"$_var\"*) true ;; *) false ;; esac; then\n")
+	b.WriteString("      _out=\n")
+	b.WriteString("      while case \"$_cmd\" in *\"$_var\"*) true ;; *) false ;; esac; do\n")
+	b.WriteString("        _out=\"$_out${_cmd%%\"$_var\"*}$_arg\"\n")
+	b.WriteString("        _cmd=\"${_cmd#*\"$_var\"}\"\n")
+	b.WriteString("      done\n")
+	b.WriteString("      _cmd=\"$_out$_cmd\"\n")
+	b.WriteString("      _i=$((_i + 1))\n")
+	b.WriteString("    else\n")
+	b.WriteString("      _tail=1\n")
+	b.WriteString("      _rest=\"$_rest $_arg\"\n")
+	b.WriteString("    fi\n")
+	b.WriteString("  done\n")
+	b.WriteString("  _cmd=\"$_cmd$_rest\"\n")
+
+	b.WriteString("  case \"$_lang\" in\n")
+	for _, l := range languages {
+		fmt.Fprintf(&b, "    %s) _cmd=\"%s '$_cmd'\" ;;\n", l.name, l.command)
+	}
+	b.WriteString("  esac\n")
+	b.WriteString("  eval \"$_cmd\"\n")
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// shFunctions for each root command calling the runner
+func shFunctions(runner string, cmds []*model.ExportCommand) string {
+	var b strings.Builder
+	for _, c := range roots(cmds) {
+		if len(c.Description) > 0 {
+			fmt.Fprintf(&b, "# %s\n", comment(c.Description))
+		}
+		fmt.Fprintf(&b, "%s() { %s %s \"$@\"; }\n", c.KeyPath, runner, c.KeyPath)
+	}
+	return b.String()
+}
+
+// fishRunner function for fish which can't run POSIX functions
+func fishRunner(runner string, cmds []*model.ExportCommand) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "function %s\n", runner)
+	b.WriteString("    set -l kp $argv[1]\n")
+	b.WriteString("    set -e argv[1]\n")
+
+	b.WriteString("    while set -q argv[1]\n")
+	b.WriteString("        switch \"$kp.$argv[1]\"\n")
+	if children := childKeyPaths(cmds); len(children) > 0 {
+		fmt.Fprintf(&b, "            case %s\n", fishPattern(children))
+		b.WriteString("                set kp \"$kp.$argv[1]\"\n")
+		b.WriteString("                set -e argv[1]\n")
+	}
+	b.WriteString("            case '*'\n")
+	b.WriteString("                break\n")
+	b.WriteString("        end\n")
+	b.WriteString("    end\n")
+
+	b.WriteString("    set -l cmd\n")
+	b.WriteString("    set -l lang\n")
+	b.WriteString("    switch \"$kp\"\n")
+	for _, c := range runnable(cmds) {
+		fmt.Fprintf(&b, "        case %s\n", stringutil.FishQuote(c.KeyPath))
+		fmt.Fprintf(&b, "            set cmd %s\n", stringutil.FishQuote(c.Command))
+		if len(c.Language) > 0 {
+			fmt.Fprintf(&b, "            set lang %s\n", stringutil.FishQuote(c.Language))
+		}
+	}
+	b.WriteString("        case '*'\n")
+	b.WriteString("            echo \"$kp: no command to run\" >&2\n")
+	b.WriteString("            return 1\n")
+	b.WriteString("    end\n")
+
+	b.WriteString("    set -l i 1\n")
+	b.WriteString("    set -l tail\n")
+	b.WriteString("    set -l rest\n")
+	b.WriteString("    for arg in $argv\n")
+	if subs := subPatterns(cmds); len(subs) > 0 {
+		b.WriteString("        switch \"$kp $arg\"\n")
+		for _, sub := range subs {
+			fmt.Fprintf(&b, "            case %s\n", fishPattern(sub.patterns))
+			fmt.Fprintf(&b, "                set arg %s\n", stringutil.FishQuote(sub.value))
+		}
+		b.WriteString("        end\n")
+	}
+	b.WriteString("        if test -z \"$tail\"; and string match -q -- \"*\\$$i*\" \"$cmd\"\n")
+	b.WriteString("            set cmd (string replace -a -- \"\\$$i\" \"$arg\" \"$cmd\" | string collect)\n")
+	b.WriteString("            set i (math $i + 1)\n")
+	b.WriteString("        else\n")
+	b.WriteString("            set tail 1\n")
+	b.WriteString("            set rest \"$rest $arg\"\n")
+	b.WriteString("        end\n")
+	b.WriteString("    end\n")
+	b.WriteString("    set cmd \"$cmd$rest\"\n")
+
+	b.WriteString("    switch \"$lang\"\n")
+	for _, l := range languages {
+		fmt.Fprintf(&b, "        case %s\n", l.name)
+		fmt.Fprintf(&b, "            set cmd \"%s '$cmd'\"\n", l.command)
+	}
+	b.WriteString("    end\n")
+	b.WriteString("    eval $cmd\n")
+	b.WriteString("end\n")
+	return b.String()
+}
+
+// fishFunctions for each root command calling the runner
+func fishFunctions(runner string, cmds []*model.ExportCommand) string {
+	var b strings.Builder
+	for _, c := range roots(cmds) {
+		if len(c.Description) > 0 {
+			fmt.Fprintf(&b, "# %s\n", comment(c.Description))
+		}
+		fmt.Fprintf(&b, "function %s; %s %s $argv; end\n", c.KeyPath, runner, c.KeyPath)
+	}
+	return b.String()
+}
+
+// makefile with a target for each runnable command
+//
+// The runner is exported to recipes in a variable since make runs each
+// recipe line in a new shell.
+func makefile(runner string, cmds []*model.ExportCommand) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "define %s\n", runnerVar)
+	b.WriteString(strings.ReplaceAll(shRunner(runner, cmds), "$", "$$"))
+	b.WriteString("endef\n")
+	fmt.Fprintf(&b, "export %s\n", runnerVar)
+
+	var targets []string
+	for _, c := range runnable(cmds) {
+		targets = append(targets, c.KeyPath)
+	}
+	fmt.Fprintf(&b, "\n.PHONY: %s\n", strings.Join(targets, " "))
+
+	for _, c := range runnable(cmds) {
+		b.WriteString("\n")
+		if len(c.Description) > 0 {
+			fmt.Fprintf(&b, "# %s\n", comment(c.Description))
+		}
+		fmt.Fprintf(&b, "%s:\n", c.KeyPath)
+		fmt.Fprintf(&b, "\t@eval \"$$%s\"; %s %s $(ARGS)\n", runnerVar, runner, strings.Join(keypath.Keys(c.KeyPath), " "))
+	}
+	return b.String()
+}
+
+// justfile with a recipe for each runnable command
+//
+// Recipe names can't contain `.` so key paths are joined with `-`.
+func justfile(runner string, cmds []*model.ExportCommand) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "export %s := '''\n", runnerVar)
+	b.WriteString(shRunner(runner, cmds))
+	b.WriteString("'''\n")
+
+	for _, c := range runnable(cmds) {
+		b.WriteString("\n")
+		if len(c.Description) > 0 {
+			fmt.Fprintf(&b, "# %s\n", comment(c.Description))
+		}
+		keys := keypath.Keys(c.KeyPath)
+		fmt.Fprintf(&b, "%s *args:\n", strings.Join(keys, "-"))
+		fmt.Fprintf(&b, "    @eval \"$%s\"; %s %s {{args}}\n", runnerVar, runner, strings.Join(keys, " "))
+	}
+	return b.String()
+}
+
+// subPattern with key paths that substitute an argument with a value
+type subPattern struct {
+	value    string
+	patterns []string
+}
+
+// subPatterns like `key.path arg` grouped by the substituted value
+func subPatterns(cmds []*model.ExportCommand) []*subPattern {
+	var subs []*subPattern
+	indexes := map[string]int{}
+	for _, c := range runnable(cmds) {
+		for _, sub := range c.Subs {
+			i, ok := indexes[sub.Name]
+			if !ok {
+				i = len(subs)
+				indexes[sub.Name] = i
+				subs = append(subs, &subPattern{value: sub.Name})
+			}
+			subs[i].patterns = append(subs[i].patterns, c.KeyPath+" "+sub.Alias)
+		}
+	}
+	return subs
+}
+
+// childKeyPaths of all commands that aren't roots
+func childKeyPaths(cmds []*model.ExportCommand) []string {
+	var keyPaths []string
+	for _, c := range cmds {
+		for _, child := range c.Children {
+			keyPaths = append(keyPaths, keypath.KeyPath([]string{c.KeyPath, child}))
+		}
+	}
+	return keyPaths
+}
+
+// roots of the command trees
+func roots(cmds []*model.ExportCommand) []*model.ExportCommand {
+	children := map[string]bool{}
+	for _, keyPath := range childKeyPaths(cmds) {
+		children[keyPath] = true
+	}
+
+	var roots []*model.ExportCommand
+	for _, c := range cmds {
+		if !children[c.KeyPath] {
+			roots = append(roots, c)
+		}
+	}
+	return roots
+}
+
+// runnable commands that expand to something
+func runnable(cmds []*model.ExportCommand) []*model.ExportCommand {
+	var r []*model.ExportCommand
+	for _, c := range cmds {
+		if len(strings.TrimSpace(c.Command)) > 0 {
+			r = append(r, c)
+		}
+	}
+	return r
+}
+
+func shPattern(patterns []string) string {
+	var quoted []string
+	for _, p := range patterns {
+		quoted = append(quoted, stringutil.SingleQuote(p))
+	}
+	return strings.Join(quoted, "|")
+}
+
+func fishPattern(patterns []string) string {
+	var quoted []string
+	for _, p := range patterns {
+		quoted = append(quoted, stringutil.FishQuote(p))
+	}
+	return strings.Join(quoted, " ")
+}
+
+// comment text on a single line
+func comment(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
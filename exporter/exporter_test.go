@@ -0,0 +1,108 @@
+package exporter
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/pokanop/nostromo/model"
+)
+
+func fakeManifest() *model.Manifest {
+	m := model.NewManifest("my-manifest", "", "", nil)
+	m.AddCommand("br", "git checkout", "Switch branches", nil, false, "")
+	m.AddCommand("br.show", "echo branch $1 is", "", nil, false, "")
+	m.AddCommand("br.show.only", "echo only $1 $1", "", nil, false, model.ExclusiveMode.String())
+	m.AddCommand("e.ind", "echo 'it''s'", "", nil, false, model.IndependentMode.String())
+	m.AddCommand("e.ind.x", "more", "", nil, false, "")
+	m.AddCommand("py", "print(1)", "", &model.Code{Language: "python", Snippet: "print(1)"}, false, "")
+	m.AddSubstitution("br", "origin/main", "om")
+	m.AddSubstitution("br.show", "mine", "om")
+	m.Link()
+	return m
+}
+
+func TestExport(t *testing.T) {
+	m := fakeManifest()
+	tests := []struct {
+		name     string
+		format   string
+		contains []string
+		wantErr  bool
+	}{
+		{"bash", Bash, []string{"__nostromo_my_manifest() {", `br() { __nostromo_my_manifest br "$@"; }`, "# Switch branches", "'br om') _arg='origin/main' ;;"}, false},
+		{"zsh", Zsh, []string{"__nostromo_my_manifest() {", `e() { __nostromo_my_manifest e "$@"; }`}, false},
+		{"fish", Fish, []string{"function __nostromo_my_manifest", "function br; __nostromo_my_manifest br $argv; end", `set cmd 'echo \'it\'\'s\';'`}, false},
+		{"make", Make, []string{"define NOSTROMO_RUNNER", "export NOSTROMO_RUNNER", "br.show.only:\n\t@eval \"$$NOSTROMO_RUNNER\"; __nostromo_my_manifest br show only $(ARGS)", "$$_kp"}, false},
+		{"just", Just, []string{"export NOSTROMO_RUNNER := '''", "br-show-only *args:\n    @eval \"$NOSTROMO_RUNNER\"; __nostromo_my_manifest br show only {{args}}"}, false},
+		{"invalid", "ps1", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script, err := Export(m, tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Export() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(script, s) {
+					t.Errorf("expected %q in script:\n%s", s, script)
+				}
+			}
+		})
+	}
+}
+
+func TestExportEmpty(t *testing.T) {
+	if _, err := Export(model.NewManifest("empty", "", "", nil), Bash); err == nil {
+		t.Errorf("expected error exporting empty manifest")
+	}
+}
+
+func TestExportMatchesExecution(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not found")
+	}
+
+	m := fakeManifest()
+	s := model.NewSpaceport([]*model.Manifest{m})
+	script, err := Export(m, Bash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Print instead of running the command
+	script = strings.Replace(script, `eval "$_cmd"`, `printf '%s\n' "$_cmd"`, 1)
+
+	tests := [][]string{
+		{"br"},
+		{"br", "om", "x"},
+		{"br", "show", "om", "y", "z"},
+		{"br", "show", "only", "om", "q"},
+		{"br", "show"},
+		{"e", "ind", "x", "1"},
+		{"e", "ind"},
+		{"py", "2"},
+	}
+	for _, args := range tests {
+		t.Run(strings.Join(args, " "), func(t *testing.T) {
+			language, expected, _, err := s.ExecutionString(args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, l := range languages {
+				if l.name == language {
+					expected = l.command + " '" + expected + "'"
+				}
+			}
+
+			out, err := exec.Command(sh, append([]string{"-c", script + "\n\"$@\"", "sh"}, args...)...).CombinedOutput()
+			if err != nil {
+				t.Fatalf("%s: %s", err, out)
+			}
+			if actual := strings.TrimSuffix(string(out), "\n"); actual != expected {
+				t.Errorf("expected: %q, actual: %q", expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package importer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// functionLine like `name() { body; }` or `function name { body; }`
+	functionLine = regexp.MustCompile(`^(?:function\s+([\w.:-]+)\s*(?:\(\s*\))?|([\w.:-]+)\s*\(\s*\))\s*\{(.*)\}\s*;?$`)
+	// functionStart like `name() {` with the body on the following lines
+	functionStart = regexp.MustCompile(`^(?:function\s+([\w.:-]+)\s*(?:\(\s*\))?|([\w.:-]+)\s*\(\s*\))\s*\{\s*$`)
+	// bracedParam like `${1}`
+	bracedParam = regexp.MustCompile(`\$\{(\d)\}`)
+)
+
+// Keywords that make a function too complex to import
+var complexKeywords = []string{
+	"if", "then", "else", "elif", "fi", "for", "while", "until", "do", "done",
+	"case", "esac", "select", "local", "return", "shift", "exit", "function",
+	"set", "trap", "read", "declare", "typeset",
+}
+
+// Trailing parameters that nostromo provides by appending arguments
+var trailingParams = []string{`"$@"`, `$@`, `"$*"`, `$*`}
+
+// Alias defined in a shell with its command
+type Alias struct {
+	Name     string
+	Command  string
+	Function bool
+}
+
+// ParseAliases from shell content like startup files
+//
+// Reads `alias name='command'` statements including the fish form and
+// simple functions where `"$@"` at the end is dropped since nostromo
+// appends arguments. With output set, lines like `name='command'` printed
+// by the zsh `alias` builtin are read as well.
+func ParseAliases(content string, output bool) []*Alias {
+	var aliases []*Alias
+	lines := joinContinuations(content)
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if m := functionLine.FindStringSubmatch(line); m != nil {
+			if a := functionAlias(m[1]+m[2], []string{m[3]}); a != nil {
+				aliases = append(aliases, a)
+			}
+			continue
+		}
+
+		if m := functionStart.FindStringSubmatch(line); m != nil {
+			var body []string
+			for i++; i < len(lines) && strings.TrimSpace(lines[i]) != "}"; i++ {
+				body = append(body, lines[i])
+			}
+			if a := functionAlias(m[1]+m[2], body); a != nil {
+				aliases = append(aliases, a)
+			}
+			continue
+		}
+
+		for _, words := range statements(line) {
+			aliases = append(aliases, statementAliases(words, output)...)
+		}
+	}
+	return aliases
+}
+
+// AliasCommands for aliases with names split into key paths on `_` and `.`
+// unless flat is set, which only splits on `.`
+func AliasCommands(aliases []*Alias, source string, flat bool) []*Command {
+	separators := "._"
+	if flat {
+		separators = "."
+	}
+
+	var cmds []*Command
+	for _, a := range aliases {
+		if len(a.Name) == 0 || strings.HasPrefix(a.Name, "_") || a.Name == "nostromo" {
+			continue
+		}
+
+		keyPath := keyPathFor(a.Name, separators)
+		if len(keyPath) == 0 || len(a.Command) == 0 {
+			continue
+		}
+
+		kind := "alias"
+		if a.Function {
+			kind = "function"
+		}
+		cmds = append(cmds, &Command{
+			KeyPath:     keyPath,
+			Command:     a.Command,
+			Description: fmt.Sprintf("imported %s %s", kind, a.Name),
+			Source:      source,
+		})
+	}
+	return dedupe(cmds)
+}
+
+// statementAliases from the words of a statement
+func statementAliases(words []string, output bool) []*Alias {
+	if len(words) == 0 {
+		return nil
+	}
+
+	if words[0] != "alias" {
+		// Output of the zsh alias builtin omits the keyword
+		if output && len(words) == 1 && strings.Contains(words[0], "=") {
+			return statementAliases([]string{"alias", words[0]}, false)
+		}
+		return nil
+	}
+
+	var aliases []*Alias
+	args := words[1:]
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		args = args[1:]
+	}
+	for i, arg := range args {
+		if j := strings.Index(arg, "="); j > 0 {
+			aliases = append(aliases, &Alias{Name: arg[:j], Command: arg[j+1:]})
+		} else if i == 0 && len(args) == 2 {
+			// Fish aliases look like `alias name 'command'`
+			return []*Alias{{Name: arg, Command: args[1]}}
+		}
+	}
+	return aliases
+}
+
+// functionAlias for a function if its body is simple enough to import
+func functionAlias(name string, body []string) *Alias {
+	var lines []string
+	for _, line := range body {
+		line = strings.TrimSuffix(strings.TrimSpace(line), ";")
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	cmd := bracedParam.ReplaceAllString(strings.Join(lines, "; "), "$$$1")
+	for _, line := range strings.Split(cmd, "; ") {
+		fields := strings.Fields(line)
+		for _, keyword := range complexKeywords {
+			if fields[0] == keyword {
+				return nil
+			}
+		}
+	}
+	if strings.ContainsAny(cmd, "{}") || strings.Contains(cmd, "$#") || strings.Contains(cmd, "$0") {
+		return nil
+	}
+
+	for _, param := range trailingParams {
+		if strings.HasSuffix(cmd, param) {
+			cmd = strings.TrimSpace(strings.TrimSuffix(cmd, param))
+			break
+		}
+	}
+	for _, param := range trailingParams {
+		if strings.Contains(cmd, param) {
+			return nil
+		}
+	}
+
+	return &Alias{Name: name, Command: cmd, Function: true}
+}
+
+// joinContinuations of lines ending with a backslash
+func joinContinuations(content string) []string {
+	var lines []string
+	var current string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasSuffix(line, "\\") {
+			current += strings.TrimSuffix(line, "\\")
+			continue
+		}
+		lines = append(lines, current+line)
+		current = ""
+	}
+	if len(current) > 0 {
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+// statements in a line split into words with shell quoting removed
+//
+// Statements are separated by unquoted `;`, `&&` or `||` and parsing stops
+// at comments.
+func statements(line string) [][]string {
+	var stmts [][]string
+	var words []string
+	var word strings.Builder
+	inWord := false
+	endWord := func() {
+		if inWord {
+			words = append(words, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+	endStatement := func() {
+		endWord()
+		if len(words) > 0 {
+			stmts = append(stmts, words)
+			words = nil
+		}
+	}
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\'':
+			j := strings.IndexByte(line[i+1:], '\'')
+			if j == -1 {
+				j = len(line) - i - 1
+			}
+			word.WriteString(line[i+1 : i+1+j])
+			inWord = true
+			i += j + 1
+		case c == '"':
+			inWord = true
+			for i++; i < len(line) && line[i] != '"'; i++ {
+				if line[i] == '\\' && i+1 < len(line) && strings.IndexByte("\"\\$`", line[i+1]) != -1 {
+					i++
+				}
+				word.WriteByte(line[i])
+			}
+		case c == '\\' && i+1 < len(line):
+			i++
+			word.WriteByte(line[i])
+			inWord = true
+		case c == ' ' || c == '\t':
+			endWord()
+		case c == ';':
+			endStatement()
+		case (c == '&' || c == '|') && i+1 < len(line) && line[i+1] == c:
+			endStatement()
+			i++
+		case c == '#' && !inWord:
+			endStatement()
+			return stmts
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	endStatement()
+	return stmts
+}
@@ -0,0 +1,85 @@
+package importer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAliases(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		output  bool
+		want    []*Alias
+	}{
+		{"empty", "", false, nil},
+		{"comment", "# alias gs='git status'", false, nil},
+		{"single quotes", "alias gs='git status'", false, []*Alias{{"gs", "git status", false}}},
+		{"double quotes", `alias gl="git log \"--oneline\""`, false, []*Alias{{"gl", `git log "--oneline"`, false}}},
+		{"unquoted", `alias ll=ls\ -la`, false, []*Alias{{"ll", "ls -la", false}}},
+		{"multiple", "alias a='ls' b='pwd'", false, []*Alias{{"a", "ls", false}, {"b", "pwd", false}}},
+		{"flags", "alias -g G='| grep'", false, []*Alias{{"G", "| grep", false}}},
+		{"statements", "alias a='ls; pwd' && alias b=pwd # trailing", false, []*Alias{{"a", "ls; pwd", false}, {"b", "pwd", false}}},
+		{"fish", "alias gs 'git status'", false, []*Alias{{"gs", "git status", false}}},
+		{"continuation", "alias gs='git \\\nstatus'", false, []*Alias{{"gs", "git status", false}}},
+		{"other statements", "export FOO=bar\nsource ~/.nostromo", false, nil},
+		{"output ignored", "gs='git status'", false, nil},
+		{"output", "gs='git status'\nll=ls", true, []*Alias{{"gs", "git status", false}, {"ll", "ls", false}}},
+		{"function", "mkcd() { mkdir -p $1 && cd ${1}; }", false, []*Alias{{"mkcd", "mkdir -p $1 && cd $1", true}}},
+		{"function keyword", "function gp { git push \"$@\"; }", false, []*Alias{{"gp", "git push", true}}},
+		{"multiline function", "gco() {\n  git fetch\n  git checkout $1\n}", false, []*Alias{{"gco", "git fetch; git checkout $1", true}}},
+		{"complex function", "f() {\n  if [ -n \"$1\" ]; then\n    echo $1\n  fi\n}", false, nil},
+		{"function with args", "f() { echo $# \"$@\"; }", false, nil},
+		{"function with inner args", "f() { echo \"$@\" done; }", false, nil},
+		{"function with shift", "f() { shift; echo $1; }", false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseAliases(tt.content, tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseAliases() = %s, want %s", toString(got), toString(tt.want))
+			}
+		})
+	}
+}
+
+func TestAliasCommands(t *testing.T) {
+	aliases := []*Alias{
+		{"git_log", "git log", false},
+		{"k.pods", "kubectl get pods", false},
+		{"_private", "echo private", false},
+		{"nostromo", "nostromo", false},
+		{"mkcd", "mkdir -p $1 && cd $1", true},
+		{"git_log", "git log --oneline", false},
+	}
+	tests := []struct {
+		name string
+		flat bool
+		want []*Command
+	}{
+		{"key paths", false, []*Command{
+			{"git.log", "git log --oneline", "imported alias git_log", "test"},
+			{"k.pods", "kubectl get pods", "imported alias k.pods", "test"},
+			{"mkcd", "mkdir -p $1 && cd $1", "imported function mkcd", "test"},
+		}},
+		{"flat", true, []*Command{
+			{"git_log", "git log --oneline", "imported alias git_log", "test"},
+			{"k.pods", "kubectl get pods", "imported alias k.pods", "test"},
+			{"mkcd", "mkdir -p $1 && cd $1", "imported function mkcd", "test"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AliasCommands(aliases, "test", tt.flat); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AliasCommands() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func toString(aliases []*Alias) string {
+	s := "["
+	for _, a := range aliases {
+		s += a.Name + "=" + a.Command + " "
+	}
+	return s + "]"
+}
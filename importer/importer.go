@@ -0,0 +1,37 @@
+package importer
+
+import (
+	"strings"
+
+	"github.com/pokanop/nostromo/keypath"
+)
+
+// Command imported from another tool ready to add to a manifest
+type Command struct {
+	KeyPath     string
+	Command     string
+	Description string
+	Source      string
+}
+
+// dedupe commands by key path where later definitions win like in a shell
+func dedupe(cmds []*Command) []*Command {
+	indexes := map[string]int{}
+	var deduped []*Command
+	for _, cmd := range cmds {
+		if i, ok := indexes[cmd.KeyPath]; ok {
+			deduped[i] = cmd
+			continue
+		}
+		indexes[cmd.KeyPath] = len(deduped)
+		deduped = append(deduped, cmd)
+	}
+	return deduped
+}
+
+// keyPathFor a name splitting on separators into a key path tree
+func keyPathFor(name string, separators string) string {
+	return keypath.KeyPath(strings.FieldsFunc(name, func(r rune) bool {
+		return strings.ContainsRune(separators, r)
+	}))
+}
@@ -0,0 +1,53 @@
+package importer
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// justRecipe like `@build target='debug' *args: deps`
+	justRecipe = regexp.MustCompile(`^@?([A-Za-z_][A-Za-z0-9_-]*)(\s+[^:]*)?:([^=].*)?$`)
+	// justAttribute like `[private]` before a recipe
+	justAttribute = regexp.MustCompile(`^\[([^\]]*)\]$`)
+)
+
+// parseJustfile recipes with their doc comments
+//
+// Private recipes starting with `_` or marked `[private]` are skipped.
+func parseJustfile(content []byte) ([]*Task, error) {
+	var tasks []*Task
+	var comments commentBlock
+	private := false
+	for _, line := range joinContinuations(string(content)) {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case len(trimmed) == 0:
+			comments.reset()
+			private = false
+			continue
+		case strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t"):
+			continue
+		case strings.HasPrefix(trimmed, "#"):
+			// Just only uses the comment right before a recipe
+			comments.reset()
+			comments.add(trimmed)
+			continue
+		}
+
+		if m := justAttribute.FindStringSubmatch(trimmed); m != nil {
+			for _, attr := range strings.Split(m[1], ",") {
+				private = private || strings.TrimSpace(attr) == "private"
+			}
+			continue
+		}
+
+		m := justRecipe.FindStringSubmatch(trimmed)
+		if m != nil && m[1] != "alias" && m[1] != "set" && m[1] != "export" && m[1] != "import" && m[1] != "mod" && !private && !strings.HasPrefix(m[1], "_") {
+			tasks = append(tasks, &Task{Name: m[1], Description: comments.String()})
+		}
+		comments.reset()
+		private = false
+	}
+	return tasks, nil
+}
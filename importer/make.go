@@ -0,0 +1,70 @@
+package importer
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// makeTarget like `build test: deps ## help`
+	makeTarget = regexp.MustCompile(`^([A-Za-z0-9_][^:=#\s]*(?:\s+[A-Za-z0-9_][^:=#\s]*)*)\s*::?([^=].*)?$`)
+	// makeDirective lines that aren't rules
+	makeDirective = regexp.MustCompile(`^(export|unexport|override|include|-include|sinclude|ifeq|ifneq|ifdef|ifndef|else|endif|vpath)\b`)
+)
+
+// parseMakefile targets with help from `## help` after the target or the
+// comment lines right before it
+//
+// Pattern rules, special targets like `.PHONY` and targets using variables
+// are skipped.
+func parseMakefile(content []byte) ([]*Task, error) {
+	var tasks []*Task
+	seen := map[string]*Task{}
+	var comments commentBlock
+	inDefine := false
+	for _, line := range joinContinuations(string(content)) {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case inDefine:
+			inDefine = !strings.HasPrefix(trimmed, "endef")
+			continue
+		case strings.HasPrefix(trimmed, "define "):
+			inDefine = true
+			comments.reset()
+			continue
+		case strings.HasPrefix(line, "\t"):
+			continue
+		case strings.HasPrefix(trimmed, "#"):
+			comments.add(trimmed)
+			continue
+		case len(trimmed) == 0 || makeDirective.MatchString(trimmed):
+			comments.reset()
+			continue
+		}
+
+		m := makeTarget.FindStringSubmatch(line)
+		if m == nil || strings.ContainsAny(m[1], "%$") || strings.HasPrefix(m[2], "=") || strings.HasPrefix(m[2], ":=") {
+			comments.reset()
+			continue
+		}
+
+		description := comments.String()
+		if i := strings.Index(m[2], "##"); i != -1 {
+			description = strings.TrimSpace(m[2][i+2:])
+		}
+		comments.reset()
+
+		for _, name := range strings.Fields(m[1]) {
+			if t := seen[name]; t != nil {
+				if len(t.Description) == 0 {
+					t.Description = description
+				}
+				continue
+			}
+			t := &Task{Name: name, Description: description}
+			seen[name] = t
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks, nil
+}
@@ -0,0 +1,65 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pokanop/nostromo/stringutil"
+)
+
+// Lock files that identify the package manager of a project
+var lockFiles = []struct {
+	file    string
+	command string
+}{
+	{"pnpm-lock.yaml", "pnpm --dir %s run"},
+	{"yarn.lock", "yarn --cwd %s run"},
+	{"bun.lockb", "bun --cwd %s run"},
+}
+
+// parsePackageJSON scripts describing each with what it runs
+//
+// Lifecycle scripts like `prebuild` and `postbuild` are skipped since the
+// package manager runs them with `build`.
+func parsePackageJSON(content []byte) ([]*Task, error) {
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(content, &pkg); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for name := range pkg.Scripts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var tasks []*Task
+	for _, name := range names {
+		if _, ok := pkg.Scripts[strings.TrimPrefix(name, "pre")]; ok && strings.HasPrefix(name, "pre") {
+			continue
+		}
+		if _, ok := pkg.Scripts[strings.TrimPrefix(name, "post")]; ok && strings.HasPrefix(name, "post") {
+			continue
+		}
+		tasks = append(tasks, &Task{Name: name, Description: fmt.Sprintf("runs %s", pkg.Scripts[name])})
+	}
+	return tasks, nil
+}
+
+// packageManagerCommand to run scripts in the package at path using the
+// package manager that has a lock file next to it or npm
+func packageManagerCommand(path string) string {
+	dir := filepath.Dir(path)
+	for _, l := range lockFiles {
+		if _, err := os.Stat(filepath.Join(dir, l.file)); err == nil {
+			return fmt.Sprintf(l.command, stringutil.ShellQuote(dir))
+		}
+	}
+	return fmt.Sprintf("npm --prefix %s run", stringutil.ShellQuote(dir))
+}
@@ -0,0 +1,159 @@
+package importer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pokanop/nostromo/stringutil"
+)
+
+// Task runners that commands can be imported from
+const (
+	MakeRunner = "make"
+	JustRunner = "just"
+	NpmRunner  = "npm"
+	TaskRunner = "task"
+)
+
+// Runners that commands can be imported from
+var Runners = []string{MakeRunner, JustRunner, NpmRunner, TaskRunner}
+
+// Task defined by a task runner
+type Task struct {
+	Name        string
+	Description string
+}
+
+// runner parses tasks from a file and builds the command to run one
+type runner struct {
+	files   []string
+	parse   func(content []byte) ([]*Task, error)
+	command func(path string) string
+}
+
+var runners = map[string]*runner{
+	MakeRunner: {
+		files: []string{"GNUmakefile", "makefile", "Makefile"},
+		parse: parseMakefile,
+		command: func(path string) string {
+			return fmt.Sprintf("make -C %s -f %s", stringutil.ShellQuote(filepath.Dir(path)), stringutil.ShellQuote(filepath.Base(path)))
+		},
+	},
+	JustRunner: {
+		files: []string{"justfile", "Justfile", ".justfile"},
+		parse: parseJustfile,
+		command: func(path string) string {
+			return fmt.Sprintf("just --justfile %s", stringutil.ShellQuote(path))
+		},
+	},
+	NpmRunner: {
+		files:   []string{"package.json"},
+		parse:   parsePackageJSON,
+		command: packageManagerCommand,
+	},
+	TaskRunner: {
+		files: []string{"Taskfile.yml", "taskfile.yml", "Taskfile.yaml", "taskfile.yaml"},
+		parse: parseTaskfile,
+		command: func(path string) string {
+			return fmt.Sprintf("task --taskfile %s", stringutil.ShellQuote(path))
+		},
+	},
+}
+
+// RunnerFile for the runner at path which can be the file or a directory
+// containing one of its default files
+func RunnerFile(name, path string) (string, error) {
+	r, err := findRunner(name)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return path, nil
+	}
+
+	for _, f := range r.files {
+		p := filepath.Join(path, f)
+		if _, err := os.Stat(p); err == nil {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("no %s file found in %s, expected one of %s", name, path, strings.Join(r.files, ", "))
+}
+
+// RunnerCommands for each task in the runner file at path
+//
+// Commands run the task from the directory of the file so they work from
+// anywhere. Task names like `build:prod` become key paths like `build.prod`.
+func RunnerCommands(name, path string) ([]*Command, error) {
+	r, err := findRunner(name)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := r.parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s file %s: %s", name, path, err)
+	}
+
+	prefix := RunnerPrefix(name, path)
+	var cmds []*Command
+	for _, t := range tasks {
+		keyPath := keyPathFor(t.Name, ".:")
+		if len(keyPath) == 0 {
+			continue
+		}
+		cmds = append(cmds, &Command{
+			KeyPath:     keyPath,
+			Command:     prefix + stringutil.ShellQuote(t.Name),
+			Description: t.Description,
+			Source:      path,
+		})
+	}
+	return dedupe(cmds), nil
+}
+
+// RunnerPrefix of commands imported from the runner file at path used to
+// find commands from a previous import
+func RunnerPrefix(name, path string) string {
+	r, err := findRunner(name)
+	if err != nil {
+		return ""
+	}
+	return r.command(path) + " "
+}
+
+func findRunner(name string) (*runner, error) {
+	r := runners[name]
+	if r == nil {
+		return nil, fmt.Errorf("invalid runner %s, expected one of %s", name, strings.Join(Runners, ", "))
+	}
+	return r, nil
+}
+
+// commentBlock text from comment lines preceding a definition
+type commentBlock []string
+
+func (c *commentBlock) add(line string) {
+	*c = append(*c, strings.TrimSpace(strings.TrimLeft(line, "#")))
+}
+
+func (c *commentBlock) reset() {
+	*c = nil
+}
+
+func (c commentBlock) String() string {
+	return strings.TrimSpace(strings.Join(c, " "))
+}
@@ -0,0 +1,218 @@
+package importer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseMakefile(t *testing.T) {
+	content := `VERSION := 1.0
+CC ?= gcc
+FOO ::= bar
+.PHONY: build test
+
+## Build the app
+# with go
+build: deps
+	go build ./...
+
+test: ## Run tests
+	go test ./...
+
+deps docker-up:
+	echo $(VERSION)
+
+build: more
+
+define HELP
+help: not a target
+endef
+
+%.o: %.c
+	$(CC) -c $<
+
+out-$(VERSION):
+	touch $@
+`
+	expected := []*Task{
+		{"build", "Build the app with go"},
+		{"test", "Run tests"},
+		{"deps", ""},
+		{"docker-up", ""},
+	}
+	tasks, err := parseMakefile([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tasks, expected) {
+		t.Errorf("expected: %v, actual: %v", taskNames(expected), taskNames(tasks))
+	}
+}
+
+func TestParseJustfile(t *testing.T) {
+	content := `set shell := ["bash", "-c"]
+alias b := build
+version := "1.0"
+export FOO := "bar"
+
+# Build the app
+build target='debug' *args: deps
+    cargo build
+
+deps:
+    cargo fetch
+
+# Hidden helper
+_helper:
+    echo helper
+
+[private]
+secret:
+    echo secret
+
+[no-cd]
+# Run tests
+@test:
+    cargo test
+`
+	expected := []*Task{
+		{"build", "Build the app"},
+		{"deps", ""},
+		{"test", "Run tests"},
+	}
+	tasks, err := parseJustfile([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tasks, expected) {
+		t.Errorf("expected: %v, actual: %v", taskNames(expected), taskNames(tasks))
+	}
+}
+
+func TestParsePackageJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []*Task
+		wantErr  bool
+	}{
+		{"no scripts", `{"name": "app"}`, nil, false},
+		{"scripts", `{"scripts": {"test:unit": "vitest", "prebuild": "clean", "build": "vite build", "prepare": "husky"}}`, []*Task{
+			{"build", "runs vite build"},
+			{"prepare", "runs husky"},
+			{"test:unit", "runs vitest"},
+		}, false},
+		{"invalid", `{"scripts": [}`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks, err := parsePackageJSON([]byte(tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parsePackageJSON() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(tasks, tt.expected) {
+				t.Errorf("expected: %v, actual: %v", taskNames(tt.expected), taskNames(tasks))
+			}
+		})
+	}
+}
+
+func TestParseTaskfile(t *testing.T) {
+	content := `version: '3'
+tasks:
+  build:
+    desc: Build the app
+    cmds:
+      - go build ./...
+  lint: golangci-lint run
+  docs:serve:
+    summary: Serve docs
+    cmds: [mkdocs serve]
+  setup:
+    internal: true
+    cmds: [go mod download]
+`
+	expected := []*Task{
+		{"build", "Build the app"},
+		{"docs:serve", "Serve docs"},
+		{"lint", ""},
+	}
+	tasks, err := parseTaskfile([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tasks, expected) {
+		t.Errorf("expected: %v, actual: %v", taskNames(expected), taskNames(tasks))
+	}
+}
+
+func TestRunnerCommands(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nostromo-import")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	web := filepath.Join(dir, "my web")
+	os.MkdirAll(web, 0755)
+	ioutil.WriteFile(filepath.Join(web, "package.json"), []byte(`{"scripts": {"build": "vite build", "test:unit": "vitest"}}`), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "Makefile"), []byte("build:\n\tgo build\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "pnpm-lock.yaml"), []byte(""), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"scripts": {"dev": "vite"}}`), 0644)
+
+	tests := []struct {
+		name     string
+		runner   string
+		path     string
+		expected []*Command
+		wantErr  bool
+	}{
+		{"npm", NpmRunner, web, []*Command{
+			{"build", "npm --prefix '" + web + "' run build", "runs vite build", filepath.Join(web, "package.json")},
+			{"test.unit", "npm --prefix '" + web + "' run test:unit", "runs vitest", filepath.Join(web, "package.json")},
+		}, false},
+		{"pnpm", NpmRunner, filepath.Join(dir, "package.json"), []*Command{
+			{"dev", "pnpm --dir " + dir + " run dev", "runs vite", filepath.Join(dir, "package.json")},
+		}, false},
+		{"make", MakeRunner, dir, []*Command{
+			{"build", "make -C " + dir + " -f Makefile build", "", filepath.Join(dir, "Makefile")},
+		}, false},
+		{"missing file", TaskRunner, dir, nil, true},
+		{"invalid runner", "rake", dir, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := RunnerFile(tt.runner, tt.path)
+			if err == nil {
+				var cmds []*Command
+				cmds, err = RunnerCommands(tt.runner, file)
+				if !reflect.DeepEqual(cmds, tt.expected) {
+					t.Errorf("expected: %v, actual: %v", commandStrings(tt.expected), commandStrings(cmds))
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RunnerCommands() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func taskNames(tasks []*Task) []string {
+	var names []string
+	for _, t := range tasks {
+		names = append(names, t.Name+"="+t.Description)
+	}
+	return names
+}
+
+func commandStrings(cmds []*Command) []string {
+	var s []string
+	for _, c := range cmds {
+		s = append(s, c.KeyPath+"="+c.Command)
+	}
+	return s
+}
@@ -0,0 +1,58 @@
+package importer
+
+import (
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// taskfileTask definition which can also be a command or list of commands
+type taskfileTask struct {
+	Desc     string `yaml:"desc"`
+	Summary  string `yaml:"summary"`
+	Internal bool   `yaml:"internal"`
+}
+
+// UnmarshalYAML ignores tasks defined as commands without a description
+func (t *taskfileTask) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type task taskfileTask
+	var tt task
+	if err := unmarshal(&tt); err != nil {
+		return nil
+	}
+	*t = taskfileTask(tt)
+	return nil
+}
+
+// parseTaskfile tasks with their descriptions skipping internal tasks
+func parseTaskfile(content []byte) ([]*Task, error) {
+	var taskfile struct {
+		Tasks map[string]*taskfileTask `yaml:"tasks"`
+	}
+	if err := yaml.Unmarshal(content, &taskfile); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for name := range taskfile.Tasks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var tasks []*Task
+	for _, name := range names {
+		t := taskfile.Tasks[name]
+		if t == nil {
+			t = &taskfileTask{}
+		}
+		if t.Internal {
+			continue
+		}
+		description := t.Desc
+		if len(description) == 0 {
+			description = t.Summary
+		}
+		tasks = append(tasks, &Task{Name: name, Description: description})
+	}
+	return tasks, nil
+}
@@ -2,7 +2,6 @@ package log
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/olekukonko/tablewriter"
 )
@@ -43,17 +42,17 @@ func Fields(mapper FieldMapper) {
 			continue
 		}
 
-		svalue := fmt.Sprint(value)
+		svalue := redact(fmt.Sprint(value))
 		if len(svalue) == 0 {
 			continue
 		}
 
-		fmt.Print(opt.theme.formatStyle(keyFieldStyle, key))
-		fmt.Print(opt.theme.formatStyle(valueFieldStyle, ": "+svalue))
-		fmt.Print(" ")
+		fmt.Fprint(opt.out, opt.theme.formatStyle(keyFieldStyle, key))
+		fmt.Fprint(opt.out, opt.theme.formatStyle(valueFieldStyle, ": "+svalue))
+		fmt.Fprint(opt.out, " ")
 	}
 
-	fmt.Println()
+	fmt.Fprintln(opt.out)
 }
 
 // Table logs key value pairs as a table with keys for the header
@@ -69,7 +68,7 @@ func Table(mapper FieldMapper) {
 		return
 	}
 
-	table := tablewriter.NewWriter(os.Stdout)
+	table := tablewriter.NewWriter(opt.out)
 	table.SetColMinWidth(0, 12)
 	table.SetColWidth(68)
 
@@ -79,7 +78,7 @@ func Table(mapper FieldMapper) {
 			continue
 		}
 
-		svalue := fmt.Sprint(value)
+		svalue := redact(fmt.Sprint(value))
 		if len(svalue) == 0 {
 			continue
 		}
@@ -2,17 +2,24 @@ package log
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"strings"
 
 	"github.com/logrusorgru/aurora/v3"
 )
 
 type options struct {
-	theme   theme
-	verbose bool
-	echo    bool
+	theme    theme
+	verbose  bool
+	echo     bool
+	out      io.Writer
+	redacted []string
 }
 
+// redactedValue replaces redacted values in log output
+const redactedValue = "******"
+
 var opt *options
 
 type logLevel int
@@ -35,7 +42,7 @@ func Regular(a ...interface{}) {
 		echo(a...)
 		return
 	}
-	fmt.Println(opt.theme.formatRegular(joined(a...)))
+	fmt.Fprintln(opt.out, opt.theme.formatRegular(joined(a...)))
 }
 
 // Regularf log for body style text
@@ -44,7 +51,7 @@ func Regularf(format string, a ...interface{}) {
 		echof(format, a...)
 		return
 	}
-	fmt.Print(opt.theme.formatRegular(fmt.Sprintf(format, a...)))
+	fmt.Fprint(opt.out, opt.theme.formatRegular(sprintf(format, a...)))
 }
 
 // Highlight log as highlighted text
@@ -53,7 +60,7 @@ func Highlight(a ...interface{}) {
 		echo(a...)
 		return
 	}
-	fmt.Println(opt.theme.formatHighlight(joined(a...)))
+	fmt.Fprintln(opt.out, opt.theme.formatHighlight(joined(a...)))
 }
 
 // Highlightf log as highlighted text
@@ -62,7 +69,7 @@ func Highlightf(format string, a ...interface{}) {
 		echof(format, a...)
 		return
 	}
-	fmt.Print(opt.theme.formatHighlight(fmt.Sprintf(format, a...)))
+	fmt.Fprint(opt.out, opt.theme.formatHighlight(sprintf(format, a...)))
 }
 
 // Bold log text.
@@ -71,7 +78,7 @@ func Bold(a ...interface{}) {
 		echo(a...)
 		return
 	}
-	fmt.Println(aurora.Bold(joined(a...)))
+	fmt.Fprintln(opt.out, aurora.Bold(joined(a...)))
 }
 
 // Boldf log text with format.
@@ -80,7 +87,7 @@ func Boldf(format string, a ...interface{}) {
 		echof(format, a...)
 		return
 	}
-	fmt.Print(aurora.Bold(fmt.Sprintf(format, a...)))
+	fmt.Fprint(opt.out, aurora.Bold(sprintf(format, a...)))
 }
 
 // Debug logs a debug message
@@ -92,7 +99,7 @@ func Debug(a ...interface{}) {
 		echo(a...)
 		return
 	}
-	fmt.Println(opt.theme.formatLevel(debugLevel, "debug:"), joined(a...))
+	fmt.Fprintln(opt.out, opt.theme.formatLevel(debugLevel, "debug:"), joined(a...))
 }
 
 // Debugf logs a debug message
@@ -104,7 +111,7 @@ func Debugf(format string, a ...interface{}) {
 		echof(format, a...)
 		return
 	}
-	fmt.Print(opt.theme.formatLevel(debugLevel, "debug:"), " ", fmt.Sprintf(format, a...))
+	fmt.Fprint(opt.out, opt.theme.formatLevel(debugLevel, "debug:"), " ", sprintf(format, a...))
 }
 
 // Info logs an info message
@@ -113,7 +120,7 @@ func Info(a ...interface{}) {
 		echo(a...)
 		return
 	}
-	fmt.Println(opt.theme.formatLevel(infoLevel, "info:"), joined(a...))
+	fmt.Fprintln(opt.out, opt.theme.formatLevel(infoLevel, "info:"), joined(a...))
 }
 
 // Infof logs a debug message
@@ -122,7 +129,7 @@ func Infof(format string, a ...interface{}) {
 		echof(format, a...)
 		return
 	}
-	fmt.Print(opt.theme.formatLevel(infoLevel, "info:"), " ", fmt.Sprintf(format, a...))
+	fmt.Fprint(opt.out, opt.theme.formatLevel(infoLevel, "info:"), " ", sprintf(format, a...))
 }
 
 // Warning logs a warning message
@@ -131,7 +138,7 @@ func Warning(a ...interface{}) {
 		echo(a...)
 		return
 	}
-	fmt.Println(opt.theme.formatLevel(warningLevel, "warning:"), joined(a...))
+	fmt.Fprintln(opt.out, opt.theme.formatLevel(warningLevel, "warning:"), joined(a...))
 }
 
 // Warningf logs a debug message
@@ -140,7 +147,7 @@ func Warningf(format string, a ...interface{}) {
 		echof(format, a...)
 		return
 	}
-	fmt.Print(opt.theme.formatLevel(warningLevel, "warning:"), " ", fmt.Sprintf(format, a...))
+	fmt.Fprint(opt.out, opt.theme.formatLevel(warningLevel, "warning:"), " ", sprintf(format, a...))
 }
 
 // Error logs an error message
@@ -149,7 +156,7 @@ func Error(a ...interface{}) {
 		echo(a...)
 		return
 	}
-	fmt.Println(opt.theme.formatLevel(errorLevel, "error:"), joined(a...))
+	fmt.Fprintln(opt.out, opt.theme.formatLevel(errorLevel, "error:"), joined(a...))
 }
 
 // Errorf logs a debug message
@@ -158,7 +165,13 @@ func Errorf(format string, a ...interface{}) {
 		echof(format, a...)
 		return
 	}
-	fmt.Print(opt.theme.formatLevel(errorLevel, "error:"), " ", fmt.Sprintf(format, a...))
+	fmt.Fprint(opt.out, opt.theme.formatLevel(errorLevel, "error:"), " ", sprintf(format, a...))
+}
+
+// Promptf log bold text to stderr so prompts are visible even when stdout
+// is captured by the shell
+func Promptf(format string, a ...interface{}) {
+	fmt.Fprint(os.Stderr, aurora.Bold(sprintf(format, a...)))
 }
 
 // Print is effectively a pass-through to fmt.Print
@@ -167,11 +180,27 @@ func Print(a ...interface{}) {
 }
 
 func echo(a ...interface{}) {
-	fmt.Printf("echo \"%s\";", joined(a...))
+	fmt.Fprintf(opt.out, "echo \"%s\";", joined(a...))
 }
 
 func echof(format string, a ...interface{}) {
-	fmt.Printf("echo \"%s\";", fmt.Sprintf(format, a...))
+	fmt.Fprintf(opt.out, "echo \"%s\";", sprintf(format, a...))
+}
+
+// Matched text with the byte spans highlighted using the theme
+func Matched(text string, spans [][]int) string {
+	var b strings.Builder
+	last := 0
+	for _, span := range spans {
+		if len(span) != 2 || span[0] < last || span[1] > len(text) || span[0] >= span[1] {
+			continue
+		}
+		b.WriteString(text[last:span[0]])
+		b.WriteString(opt.theme.formatMatch(text[span[0]:span[1]]).String())
+		last = span[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
 }
 
 // SetVerbose for logger
@@ -184,6 +213,11 @@ func SetEcho(echo bool) {
 	opt.echo = echo
 }
 
+// SetOutput of logger which defaults to stdout
+func SetOutput(w io.Writer) {
+	opt.out = w
+}
+
 // SetTheme for logger
 func SetTheme(theme ThemeType) {
 	switch theme {
@@ -196,17 +230,40 @@ func SetTheme(theme ThemeType) {
 	}
 }
 
+// Redact values from all log output like secrets resolved for a command
+//
+// Output passed through with Print is never redacted.
+func Redact(values ...string) {
+	for _, v := range values {
+		if len(v) > 0 {
+			opt.redacted = append(opt.redacted, v)
+		}
+	}
+}
+
+func redact(s string) string {
+	for _, v := range opt.redacted {
+		s = strings.ReplaceAll(s, v, redactedValue)
+	}
+	return s
+}
+
+func sprintf(format string, a ...interface{}) string {
+	return redact(fmt.Sprintf(format, a...))
+}
+
 func joined(a ...interface{}) string {
 	sargs := []string{}
 	for _, arg := range a {
 		sargs = append(sargs, fmt.Sprint(arg))
 	}
-	return strings.Join(sargs, " ")
+	return redact(strings.Join(sargs, " "))
 }
 
 func init() {
 	opt = &options{
 		theme:   &emojiTheme{},
 		verbose: false,
+		out:     os.Stdout,
 	}
 }
@@ -1,11 +1,13 @@
 package model
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
 
 	"github.com/jinzhu/copier"
+	"github.com/pokanop/nostromo/secret"
 	"github.com/pokanop/nostromo/stringutil"
 	"github.com/spf13/cobra"
 
@@ -26,6 +28,21 @@ type Command struct {
 	Code        *Code                    `json:"code"`
 	Mode        Mode                     `json:"mode"`
 	Disabled    bool                     `json:"disabled"`
+	Order       int                      `json:"order,omitempty" yaml:"order,omitempty"`
+	Use         string                   `json:"use,omitempty" yaml:"use,omitempty"`
+	Values      map[string]string        `json:"values,omitempty" yaml:"values,omitempty"`
+	Complete    *Completion              `json:"complete,omitempty" yaml:"complete,omitempty"`
+	Flags       []*Flag                  `json:"flags,omitempty" yaml:"flags,omitempty"`
+	Steps       []*Step                  `json:"steps,omitempty" yaml:"steps,omitempty"`
+	Policy      *StepPolicy              `json:"policy,omitempty" yaml:"policy,omitempty"`
+	EnvFiles    []string                 `json:"envFiles,omitempty" yaml:"envFiles,omitempty"`
+	Confirm     *Confirm                 `json:"confirm,omitempty" yaml:"confirm,omitempty"`
+
+	// generated is set for commands created from templates or includes
+	// which are not saved with the manifest
+	generated bool
+	// manifest the command is indexed in for global substitutions
+	manifest *Manifest
 }
 
 // newCommand returns a newly initialized command
@@ -53,13 +70,29 @@ func newCommand(name, alias, description string, code *Code, aliasOnly bool, mod
 	}
 }
 
+// MarshalJSON omits generated commands
+func (c *Command) MarshalJSON() ([]byte, error) {
+	type command Command
+	cc := command(*c)
+	cc.Commands = authoredCommands(c.Commands)
+	return json.Marshal(&cc)
+}
+
+// MarshalYAML omits generated commands
+func (c *Command) MarshalYAML() (interface{}, error) {
+	type command Command
+	cc := command(*c)
+	cc.Commands = authoredCommands(c.Commands)
+	return &cc, nil
+}
+
 func (c *Command) String() string {
 	return fmt.Sprintf("[%s] %s -> %s", c.KeyPath, c.Name, c.Alias)
 }
 
 // Keys as ordered list of fields for logging
 func (c *Command) Keys() []string {
-	return []string{"keypath", "alias", "command", "description", "commands", "substitutions", "code", "mode", "aliasOnly", "disabled"}
+	return []string{"keypath", "alias", "command", "description", "commands", "substitutions", "flags", "steps", "envFiles", "confirm", "code", "mode", "aliasOnly", "disabled"}
 }
 
 // Fields interface for logging
@@ -71,6 +104,10 @@ func (c *Command) Fields() map[string]interface{} {
 		"description":   c.Description,
 		"commands":      joinedCommands(c.Commands),
 		"substitutions": joinedSubs(c.Subs),
+		"flags":         joinedFlags(c.Flags),
+		"steps":         joinedSteps(c.Steps),
+		"envFiles":      joinedEnvFiles(c.EnvFiles),
+		"confirm":       c.Confirm.String(),
 		"code":          c.Code.valid(),
 		"mode":          c.Mode.String(),
 		"aliasOnly":     c.AliasOnly,
@@ -86,12 +123,17 @@ func (c *Command) Data() interface{} {
 // Children method for Node interface to print tree
 func (c *Command) Children() []tree.Node {
 	nodes := make([]tree.Node, 0, len(c.Commands))
-	for _, v := range c.Commands {
+	for _, v := range c.SortedCommands() {
 		nodes = append(nodes, v)
 	}
 	return nodes
 }
 
+// SortedCommands at this scope ordered by `Order` and then alias
+func (c *Command) SortedCommands() []*Command {
+	return sortedCommands(c.Commands)
+}
+
 // Walk the command tree and run supplied func
 func (c *Command) Walk(fn func(*Command, *bool)) {
 	c.forwardWalk(fn)
@@ -100,19 +142,35 @@ func (c *Command) Walk(fn func(*Command, *bool)) {
 // CobraCommand returns a cobra.Command for this command
 func (c *Command) CobraCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:       c.Alias,
-		Short:     c.Description,
-		Long:      c.Description,
-		Hidden:    true,
-		ValidArgs: c.commandList(),
-		Run:       func(cmd *cobra.Command, args []string) {},
-	}
-	for _, childCmd := range c.Commands {
+		Use:    c.Alias,
+		Short:  c.Description,
+		Long:   c.Description,
+		Hidden: true,
+		Run:    func(cmd *cobra.Command, args []string) {},
+	}
+	for _, f := range c.Flags {
+		f.register(cmd)
+	}
+	// Cobra ignores the completion func if valid args are set
+	if c.Complete.valid() {
+		cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return c.completeArgs(args, toComplete), cobra.ShellCompDirectiveNoFileComp
+		}
+	} else {
+		cmd.ValidArgs = c.commandList()
+	}
+	for _, childCmd := range c.SortedCommands() {
 		cmd.AddCommand(childCmd.CobraCommand())
 	}
 	return cmd
 }
 
+// HasCompletions returns true if arguments can be completed with child
+// commands, flags or completion sources
+func (c *Command) HasCompletions() bool {
+	return len(c.Commands) > 0 || len(c.Flags) > 0 || c.Complete.valid()
+}
+
 func (c *Command) effectiveCommand() string {
 	if c.Code.valid() {
 		return c.Code.Snippet
@@ -153,7 +211,7 @@ func (c *Command) addSubstitution(sub *Substitution) {
 		return
 	}
 
-	c.Subs[sub.Alias] = sub
+	setSubstitution(c.Subs, sub)
 }
 
 // removeSubstitution at this scope
@@ -162,7 +220,7 @@ func (c *Command) removeSubstitution(sub *Substitution) {
 		return
 	}
 
-	delete(c.Subs, sub.Alias)
+	removeSubstitutions(c.Subs, sub.Alias)
 }
 
 // copy this command into a new instance
@@ -227,18 +285,44 @@ func (c *Command) shortestKeyPath(keyPath string) string {
 }
 
 // executionString to run the command with provided arguments
-func (c *Command) executionString(args []string) string {
+func (c *Command) executionString(args []string) (string, error) {
+	return c.trace(args, nil, nil, nil)
+}
+
+// trace building the execution string and record each step in e if not nil
+//
+// References to other commands are resolved with find and secrets with
+// lookup if not nil. Only references in commands are resolved, never ones
+// from flags or arguments.
+func (c *Command) trace(args []string, find commandFinder, lookup SecretLookup, e *Explanation) (string, error) {
 	var cmd string
 	if c.Mode == ExclusiveMode { // Only run this command
 		cmd = c.Name
 	} else {
 		cmd = c.expand()
 	}
-	var subs []string
-	for _, arg := range args {
-		subs = append(subs, c.substitute(arg))
+	e.addSteps(c)
+	if find != nil {
+		var err error
+		if cmd, err = resolveReferences(cmd, find, []string{c.KeyPath}, e); err != nil {
+			return "", err
+		}
+	}
+	values, args, err := c.parseFlags(args)
+	if err != nil {
+		return "", err
 	}
-	return stringutil.ReplaceShellVars(cmd, subs)
+	if lookup != nil {
+		cmd = secret.Mark(cmd)
+	}
+	cmd = c.applyFlags(cmd, values, e)
+	subs := c.substituteArgs(args, e)
+	e.addArguments(cmd, subs)
+	cmd = stringutil.ReplaceShellVars(cmd, subs)
+	if lookup != nil {
+		return secret.ExpandShell(cmd, lookup)
+	}
+	return cmd, nil
 }
 
 func (c *Command) expand() string {
@@ -252,16 +336,49 @@ func (c *Command) expand() string {
 	return strings.Join(stringutil.ReversedStrings(cmds), " ")
 }
 
-func (c *Command) substitute(arg string) string {
-	sub := arg
-	c.reverseWalk(func(cmd *Command, stop *bool) {
-		s := cmd.Subs[arg]
-		if s != nil {
-			sub = s.Name
-			*stop = true
+// substituteArgs in scope recording each substitution in e if not nil
+//
+// Arguments can be replaced with several values so the result may be longer
+// than args.
+func (c *Command) substituteArgs(args []string, e *Explanation) []string {
+	var subs []string
+	for i, arg := range args {
+		values, sub, scope := c.substituteScope(arg, i+1)
+		if sub != nil {
+			e.addSubstitution(arg, strings.Join(values, " "), sub, scope)
+		}
+		subs = append(subs, values...)
+	}
+	return subs
+}
+
+// substituteScope for arg at position returning the values it's replaced
+// with, the substitution and the scope it was found at or nil if not
+// substituted
+//
+// The closest scope wins followed by the manifest global scope.
+func (c *Command) substituteScope(arg string, position int) ([]string, *Substitution, string) {
+	for _, scope := range c.subScopes() {
+		for _, sub := range substitutionOrder(scope.subs) {
+			if values, ok := sub.apply(arg, position); ok {
+				return values, sub, scope.name
+			}
 		}
+	}
+	return []string{arg}, nil, ""
+}
+
+// subScopes from this command to the root followed by the manifest global
+// scope
+func (c *Command) subScopes() []*subScope {
+	var scopes []*subScope
+	c.reverseWalk(func(cmd *Command, stop *bool) {
+		scopes = append(scopes, &subScope{cmd.KeyPath, cmd.Subs})
 	})
-	return sub
+	if c.manifest != nil && len(c.manifest.Global) > 0 {
+		scopes = append(scopes, &subScope{GlobalScope, c.manifest.Global})
+	}
+	return scopes
 }
 
 func (c *Command) reverseWalk(fn func(*Command, *bool)) {
@@ -294,7 +411,7 @@ func (c *Command) forwardWalk(fn func(*Command, *bool)) bool {
 		return true
 	}
 
-	for _, cmd := range c.Commands {
+	for _, cmd := range c.SortedCommands() {
 		if stop := cmd.forwardWalk(fn); stop {
 			return true
 		}
@@ -305,9 +422,23 @@ func (c *Command) forwardWalk(fn func(*Command, *bool)) bool {
 
 func (c *Command) link(parent *Command) {
 	c.parent = parent
+	// Hand written manifests can omit key paths
+	if len(c.KeyPath) == 0 {
+		if parent == nil {
+			c.KeyPath = c.Alias
+		} else {
+			c.KeyPath = keypath.KeyPath([]string{parent.KeyPath, c.Alias})
+		}
+	}
 	if c.Code == nil {
 		c.Code = &Code{}
 	}
+	if c.Commands == nil {
+		c.Commands = map[string]*Command{}
+	}
+	if c.Subs == nil {
+		c.Subs = map[string]*Substitution{}
+	}
 	for _, cmd := range c.Commands {
 		cmd.link(c)
 	}
@@ -348,13 +479,52 @@ func (c *Command) build(keyPath, command, description string, code *Code, aliasO
 
 func (c *Command) commandList() []string {
 	var cmds []string
-	for _, cmd := range c.Commands {
+	for _, cmd := range c.SortedCommands() {
 		cmds = append(cmds, fmt.Sprintf("%s\t%s", cmd.Alias, cmd.Description))
 	}
-	sort.Strings(cmds)
 	return cmds
 }
 
+// subsInScope for this command where the closest scope wins
+func (c *Command) subsInScope() []*Substitution {
+	var subs []*Substitution
+	seen := map[string]bool{}
+	for _, scope := range c.subScopes() {
+		for _, sub := range sortedSubs(scope.subs) {
+			if !seen[sub.Alias] {
+				seen[sub.Alias] = true
+				subs = append(subs, sub)
+			}
+		}
+	}
+	return subs
+}
+
+// subList of aliases that can be completed which excludes patterns
+func (c *Command) subList() []string {
+	var subs []string
+	for _, sub := range c.subsInScope() {
+		if !sub.isPattern() {
+			subs = append(subs, fmt.Sprintf("%s\t%s", sub.Alias, sub.Name))
+		}
+	}
+	return subs
+}
+
+// completeArgs for this command from child commands and completion sources
+//
+// Child commands are only completed for the first argument.
+func (c *Command) completeArgs(args []string, toComplete string) []string {
+	var candidates []string
+	if len(args) == 0 {
+		candidates = append(candidates, c.commandList()...)
+	}
+	if c.Complete.valid() {
+		candidates = append(candidates, c.Complete.candidates(c, toComplete)...)
+	}
+	return filterCompletions(candidates, toComplete)
+}
+
 // checkDisabled returns true if this command or any parent node is disabled, and otherwise false
 //
 // Returns command if disabled, and otherwise nil
@@ -386,8 +556,38 @@ func (c *Command) updateRootKeyPath(newRoot string) {
 
 func joinedSubs(subMap map[string]*Substitution) string {
 	subs := []string{}
-	for sub := range subMap {
-		subs = append(subs, sub)
+	for _, sub := range sortedSubs(subMap) {
+		subs = append(subs, sub.Alias)
 	}
 	return strings.Join(subs, ", ")
 }
+
+// sortedCommands by explicit order and then alphabetically by alias
+func sortedCommands(cmdMap map[string]*Command) []*Command {
+	cmds := make([]*Command, 0, len(cmdMap))
+	for _, cmd := range cmdMap {
+		cmds = append(cmds, cmd)
+	}
+	sort.SliceStable(cmds, func(i, j int) bool {
+		if cmds[i].Order != cmds[j].Order {
+			return cmds[i].Order < cmds[j].Order
+		}
+		return cmds[i].Alias < cmds[j].Alias
+	})
+	return cmds
+}
+
+// sortedSubs alphabetically by alias
+func sortedSubs(subMap map[string]*Substitution) []*Substitution {
+	subs := make([]*Substitution, 0, len(subMap))
+	for _, sub := range subMap {
+		subs = append(subs, sub)
+	}
+	sort.SliceStable(subs, func(i, j int) bool {
+		if subs[i].Alias == subs[j].Alias {
+			return subs[i].Position < subs[j].Position
+		}
+		return subs[i].Alias < subs[j].Alias
+	})
+	return subs
+}
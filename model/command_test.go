@@ -22,9 +22,9 @@ func TestNewCommand(t *testing.T) {
 		code        *Code
 		expected    *Command
 	}{
-		{"empty alias", "cmd", "", false, "", nil, &Command{nil, "cmd", "cmd", "cmd", false, "", map[string]*Command{}, map[string]*Substitution{}, &Code{}, ConcatenateMode, false}},
-		{"empty name", "", "alias", false, "", nil, &Command{nil, "alias", "", "alias", false, "", map[string]*Command{}, map[string]*Substitution{}, &Code{}, ConcatenateMode, false}},
-		{"valid alias", "cmd", "cmd-alias", false, "description", nil, &Command{nil, "cmd-alias", "cmd", "cmd-alias", false, "description", map[string]*Command{}, map[string]*Substitution{}, &Code{}, ConcatenateMode, false}},
+		{"empty alias", "cmd", "", false, "", nil, &Command{KeyPath: "cmd", Name: "cmd", Alias: "cmd", AliasOnly: false, Description: "", Commands: map[string]*Command{}, Subs: map[string]*Substitution{}, Code: &Code{}, Mode: ConcatenateMode}},
+		{"empty name", "", "alias", false, "", nil, &Command{KeyPath: "alias", Name: "", Alias: "alias", AliasOnly: false, Description: "", Commands: map[string]*Command{}, Subs: map[string]*Substitution{}, Code: &Code{}, Mode: ConcatenateMode}},
+		{"valid alias", "cmd", "cmd-alias", false, "description", nil, &Command{KeyPath: "cmd-alias", Name: "cmd", Alias: "cmd-alias", AliasOnly: false, Description: "description", Commands: map[string]*Command{}, Subs: map[string]*Substitution{}, Code: &Code{}, Mode: ConcatenateMode}},
 	}
 
 	for _, test := range tests {
@@ -87,7 +87,7 @@ func TestAddSubstitution(t *testing.T) {
 	}{
 		{"nil sub", fakeCommand(1), nil},
 		{"invalid sub", fakeCommand(1), fakeCommand(2).Subs["one"]},
-		{"valid sub", fakeCommand(1), &Substitution{"two", ""}},
+		{"valid sub", fakeCommand(1), &Substitution{Name: "two"}},
 	}
 
 	for _, test := range tests {
@@ -108,7 +108,7 @@ func TestRemoveSubstitution(t *testing.T) {
 	}{
 		{"nil sub", fakeCommand(1), nil},
 		{"invalid sub", fakeCommand(1), fakeCommand(2).Subs["one"]},
-		{"valid sub", fakeCommand(1), &Substitution{"two", ""}},
+		{"valid sub", fakeCommand(1), &Substitution{Name: "two"}},
 	}
 
 	for _, test := range tests {
@@ -188,7 +188,7 @@ func TestExecutionString(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			if actual := test.command.executionString(test.args); test.expected != actual {
+			if actual, _ := test.command.executionString(test.args); test.expected != actual {
 				t.Errorf("expected: %s, actual: %s", test.expected, actual)
 			}
 		})
@@ -313,7 +313,7 @@ func TestKeys(t *testing.T) {
 		command  *Command
 		expected []string
 	}{
-		{"keys", fakeCommand(1), []string{"keypath", "alias", "command", "description", "commands", "substitutions", "code", "mode", "aliasOnly", "disabled"}},
+		{"keys", fakeCommand(1), []string{"keypath", "alias", "command", "description", "commands", "substitutions", "flags", "steps", "envFiles", "confirm", "code", "mode", "aliasOnly", "disabled"}},
 	}
 
 	for _, test := range tests {
@@ -340,6 +340,10 @@ func TestFields(t *testing.T) {
 				"description":   "",
 				"commands":      "",
 				"substitutions": "one-sub",
+				"flags":         "",
+				"steps":         "",
+				"envFiles":      "",
+				"confirm":       "",
 				"code":          false,
 				"keypath":       "one-alias",
 				"mode":          "concatenate",
@@ -500,7 +504,7 @@ func fakeCommandWithPrefix(depth int, prefix string) *Command {
 	for i := 0; i < depth; i++ {
 		name := depthKeys[i+1]
 		cmd = newCommand(prefix+name, prefix+name+"-alias", "", nil, false, ConcatenateMode.String())
-		cmd.addSubstitution(&Substitution{prefix + name, prefix + name + "-sub"})
+		cmd.addSubstitution(&Substitution{Name: prefix + name, Alias: prefix + name + "-sub"})
 		if lastCmd != nil {
 			lastCmd.addCommand(cmd)
 		} else {
@@ -527,3 +531,42 @@ func fakeBuiltCommand(startDepth, endDepth int, keyPath, command string) *Comman
 	}
 	return first
 }
+
+func TestSortedCommands(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands map[string]*Command
+		expected []string
+	}{
+		{"empty", map[string]*Command{}, []string{}},
+		{"alphabetical", map[string]*Command{"c": {Alias: "c"}, "a": {Alias: "a"}, "b": {Alias: "b"}}, []string{"a", "b", "c"}},
+		{"ordered", map[string]*Command{"c": {Alias: "c", Order: 1}, "a": {Alias: "a", Order: 2}, "b": {Alias: "b"}}, []string{"b", "c", "a"}},
+		{"negative order", map[string]*Command{"c": {Alias: "c", Order: -1}, "a": {Alias: "a"}, "b": {Alias: "b"}}, []string{"c", "a", "b"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &Command{Commands: test.commands}
+			actual := []string{}
+			for _, cmd := range c.SortedCommands() {
+				actual = append(actual, cmd.Alias)
+			}
+			if !reflect.DeepEqual(test.expected, actual) {
+				t.Errorf("expected: %s, actual: %s", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestJoinedSubs(t *testing.T) {
+	subs := map[string]*Substitution{
+		"c": {Name: "3", Alias: "c"},
+		"a": {Name: "1", Alias: "a"},
+		"b": {Name: "2", Alias: "b"},
+	}
+	for i := 0; i < 10; i++ {
+		if actual := joinedSubs(subs); actual != "a, b, c" {
+			t.Fatalf("expected: a, b, c, actual: %s", actual)
+		}
+	}
+}
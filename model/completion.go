@@ -0,0 +1,92 @@
+package model
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pokanop/nostromo/log"
+)
+
+// completionTimeout for shell commands providing completions
+const completionTimeout = 2 * time.Second
+
+// Completion sources for the arguments of a command
+//
+// All configured sources are combined. Files are matched relative to the
+// working directory and each line of the command output is a candidate.
+type Completion struct {
+	Values  []string `json:"values,omitempty" yaml:"values,omitempty"`
+	Subs    bool     `json:"subs,omitempty" yaml:"subs,omitempty"`
+	Files   string   `json:"files,omitempty" yaml:"files,omitempty"`
+	Command string   `json:"command,omitempty" yaml:"command,omitempty"`
+}
+
+func (c *Completion) valid() bool {
+	return c != nil && (len(c.Values) > 0 || c.Subs || len(c.Files) > 0 || len(c.Command) > 0)
+}
+
+// candidates for the argument being completed for cmd
+func (c *Completion) candidates(cmd *Command, toComplete string) []string {
+	var candidates []string
+	candidates = append(candidates, c.Values...)
+	if c.Subs {
+		candidates = append(candidates, cmd.subList()...)
+	}
+	if len(c.Files) > 0 {
+		candidates = append(candidates, completeFiles(c.Files, toComplete)...)
+	}
+	if len(c.Command) > 0 {
+		candidates = append(candidates, completeCommand(c.Command)...)
+	}
+	return candidates
+}
+
+// completeFiles matching the glob pattern in the folder being completed
+func completeFiles(pattern, toComplete string) []string {
+	dir := ""
+	if i := strings.LastIndex(toComplete, string(os.PathSeparator)); i != -1 {
+		dir = toComplete[:i+1]
+	}
+
+	matches, err := filepath.Glob(dir + pattern)
+	if err != nil {
+		log.Debugf("invalid completion pattern %s: %s\n", pattern, err)
+		return nil
+	}
+	return matches
+}
+
+// completeCommand from each non empty line the shell command outputs
+func completeCommand(command string) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "sh", "-c", command).Output()
+	if err != nil {
+		log.Debugf("completion command failed: %s\n", err)
+		return nil
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); len(line) > 0 {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// filterCompletions that start with the argument being completed
+func filterCompletions(candidates []string, toComplete string) []string {
+	var filtered []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, toComplete) {
+			filtered = append(filtered, candidate)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCompleteArgs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nostromo-complete")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "a.yaml"), []byte{}, 0644)
+	ioutil.WriteFile(filepath.Join(dir, "b.txt"), []byte{}, 0644)
+
+	tests := []struct {
+		name       string
+		keyPath    string
+		complete   *Completion
+		args       []string
+		toComplete string
+		expected   []string
+	}{
+		{"child commands", "one-alias", nil, nil, "", []string{"two-alias\t"}},
+		{"no sources", "one-alias.two-alias", nil, nil, "", nil},
+		{"values", "one-alias.two-alias", &Completion{Values: []string{"apple", "banana"}}, nil, "b", []string{"banana"}},
+		{"subs in scope", "one-alias.two-alias", &Completion{Subs: true}, nil, "", []string{"two-sub\ttwo", "one-sub\tone"}},
+		{"files", "one-alias.two-alias", &Completion{Files: "*.yaml"}, nil, dir + "/", []string{filepath.Join(dir, "a.yaml")}},
+		{"command", "one-alias.two-alias", &Completion{Command: "printf 'main\\ndev\\n'"}, []string{"checkout"}, "", []string{"main", "dev"}},
+		{"failed command", "one-alias.two-alias", &Completion{Command: "exit 1"}, nil, "", nil},
+		{"children and sources", "one-alias", &Completion{Values: []string{"three"}}, nil, "t", []string{"two-alias\t", "three"}},
+		{"children only first arg", "one-alias", &Completion{Values: []string{"three"}}, []string{"x"}, "t", []string{"three"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmd := fakeCommandWithModifier(2, test.keyPath, func(c *Command) {
+				c.Complete = test.complete
+			})
+			if actual := cmd.completeArgs(test.args, test.toComplete); !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("expected: %q, actual: %q", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCobraCommandCompletion(t *testing.T) {
+	cmd := fakeCommandWithModifier(2, "one-alias.two-alias", func(c *Command) {
+		c.Complete = &Completion{Values: []string{"apple", "banana"}}
+	})
+
+	root := &cobra.Command{Use: "nostromo"}
+	run := &cobra.Command{Use: "run"}
+	run.AddCommand(cmd.parent.CobraCommand())
+	root.AddCommand(run)
+
+	var buf bytes.Buffer
+	root.SetOut(&buf)
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs([]string{"__complete", "run", "one-alias", "two-alias", "a"})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	expected := []string{"apple", ":4"}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("expected: %q, actual: %q", expected, lines)
+	}
+}
@@ -1,5 +1,10 @@
 package model
 
+import (
+	"strings"
+	"time"
+)
+
 var verbose bool
 
 // Config model for holding nostromo settings
@@ -8,8 +13,25 @@ type Config struct {
 	AliasesOnly bool `json:"aliasesOnly"`
 	Mode        Mode `json:"mode"`
 	BackupCount int  `json:"backupCount"`
+
+	// Shell profile settings, empty values use the defaults
+	StartupFiles    []string `json:"startupFiles,omitempty" yaml:"startupFiles,omitempty"`
+	BackupDir       string   `json:"backupDir,omitempty" yaml:"backupDir,omitempty"`
+	PreferredShells []string `json:"preferredShells,omitempty" yaml:"preferredShells,omitempty"`
+
+	// History settings, a size of 0 keeps the default number of entries and
+	// 0 days keeps entries regardless of age
+	DisableHistory bool `json:"disableHistory,omitempty" yaml:"disableHistory,omitempty"`
+	HistorySize    int  `json:"historySize,omitempty" yaml:"historySize,omitempty"`
+	HistoryDays    int  `json:"historyDays,omitempty" yaml:"historyDays,omitempty"`
+
+	// Backend for secrets referenced by commands, empty uses the vault
+	SecretBackend string `json:"secretBackend,omitempty" yaml:"secretBackend,omitempty"`
 }
 
+// DefaultHistorySize is the number of history entries kept by default
+const DefaultHistorySize = 1000
+
 // Create a new config model with default values
 func NewConfig() *Config {
 	return &Config{
@@ -27,17 +49,34 @@ func (c *Config) IsVerbose() bool {
 	return verbose || c.Verbose
 }
 
+// HistoryRetention returns the max number of history entries and max age
+// to keep, an age of 0 keeps entries regardless of age
+func (c *Config) HistoryRetention() (int, time.Duration) {
+	size := c.HistorySize
+	if size <= 0 {
+		size = DefaultHistorySize
+	}
+	return size, time.Duration(c.HistoryDays) * 24 * time.Hour
+}
+
 // Keys as ordered list of fields for logging
 func (c *Config) Keys() []string {
-	return []string{"verbose", "aliasesOnly", "mode", "backupCount"}
+	return []string{"verbose", "aliasesOnly", "mode", "backupCount", "startupFiles", "backupDir", "preferredShells", "disableHistory", "historySize", "historyDays", "secretBackend"}
 }
 
 // Fields interface for logging
 func (c *Config) Fields() map[string]interface{} {
 	return map[string]interface{}{
-		"verbose":     c.Verbose,
-		"aliasesOnly": c.AliasesOnly,
-		"mode":        c.Mode.String(),
-		"backupCount": c.BackupCount,
+		"verbose":         c.Verbose,
+		"aliasesOnly":     c.AliasesOnly,
+		"mode":            c.Mode.String(),
+		"backupCount":     c.BackupCount,
+		"startupFiles":    strings.Join(c.StartupFiles, ","),
+		"backupDir":       c.BackupDir,
+		"preferredShells": strings.Join(c.PreferredShells, ","),
+		"disableHistory":  c.DisableHistory,
+		"historySize":     c.HistorySize,
+		"historyDays":     c.HistoryDays,
+		"secretBackend":   c.SecretBackend,
 	}
 }
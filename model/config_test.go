@@ -18,7 +18,7 @@ func TestConfigKeys(t *testing.T) {
 		manifest *Manifest
 		expected []string
 	}{
-		{"keys", fakeManifest(1, 1), []string{"verbose", "aliasesOnly", "mode", "backupCount"}},
+		{"keys", fakeManifest(1, 1), []string{"verbose", "aliasesOnly", "mode", "backupCount", "startupFiles", "backupDir", "preferredShells", "disableHistory", "historySize", "historyDays", "secretBackend"}},
 	}
 
 	for _, test := range tests {
@@ -40,10 +40,17 @@ func TestConfigFields(t *testing.T) {
 			"keys",
 			fakeManifest(1, 1),
 			map[string]interface{}{
-				"verbose":     true,
-				"aliasesOnly": false,
-				"mode":        "concatenate",
-				"backupCount": 10,
+				"verbose":         true,
+				"aliasesOnly":     false,
+				"mode":            "concatenate",
+				"backupCount":     10,
+				"startupFiles":    "",
+				"backupDir":       "",
+				"preferredShells": "",
+				"disableHistory":  false,
+				"historySize":     0,
+				"historyDays":     0,
+				"secretBackend":   "",
 			},
 		},
 	}
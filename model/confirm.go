@@ -0,0 +1,155 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Confirm before running a command that can't be undone like dropping a
+// database or force pushing
+//
+// The message is shown when asking and names the command by default. If a
+// phrase is set it has to be typed exactly to continue instead of yes. Skip
+// turns off confirmation inherited from a parent, which `confirm: false`
+// is a shorthand for.
+type Confirm struct {
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+	Phrase  string `json:"phrase,omitempty" yaml:"phrase,omitempty"`
+	Skip    bool   `json:"skip,omitempty" yaml:"skip,omitempty"`
+}
+
+// confirmAnswers accepted when no phrase is set, the same as the prompt
+// package
+var confirmAnswers = []string{"y", "Y", "yes", "Yes"}
+
+// UnmarshalYAML allows `confirm: true` or `confirm: <message>` shorthands
+func (c *Confirm) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var enabled bool
+	if err := unmarshal(&enabled); err == nil {
+		*c = Confirm{Skip: !enabled}
+		return nil
+	}
+	var message string
+	if err := unmarshal(&message); err == nil {
+		*c = Confirm{Message: message}
+		return nil
+	}
+	type confirm Confirm
+	return unmarshal((*confirm)(c))
+}
+
+// UnmarshalJSON allows the same shorthands as yaml
+func (c *Confirm) UnmarshalJSON(b []byte) error {
+	var enabled bool
+	if err := json.Unmarshal(b, &enabled); err == nil {
+		*c = Confirm{Skip: !enabled}
+		return nil
+	}
+	var message string
+	if err := json.Unmarshal(b, &message); err == nil {
+		*c = Confirm{Message: message}
+		return nil
+	}
+	type confirm Confirm
+	return json.Unmarshal(b, (*confirm)(c))
+}
+
+// Prompt shown when asking for confirmation
+func (c *Confirm) Prompt() string {
+	if len(c.Phrase) > 0 {
+		return fmt.Sprintf("%s type '%s' to continue", c.Message, c.Phrase)
+	}
+	return c.Message + " [y/N]"
+}
+
+// Accepts answer if it matches the phrase or is yes without one
+func (c *Confirm) Accepts(answer string) bool {
+	answer = strings.TrimSpace(answer)
+	if len(c.Phrase) > 0 {
+		return answer == c.Phrase
+	}
+	for _, a := range confirmAnswers {
+		if answer == a {
+			return true
+		}
+	}
+	return false
+}
+
+// String representation for logging
+func (c *Confirm) String() string {
+	switch {
+	case c == nil:
+		return ""
+	case c.Skip:
+		return "skip"
+	case len(c.Phrase) > 0:
+		return "phrase " + c.Phrase
+	}
+	return "yes"
+}
+
+// confirmation set on the command or its closest parent or nil if it
+// doesn't need one
+func (c *Command) confirmation() *Confirm {
+	var confirm *Confirm
+	c.reverseWalk(func(cmd *Command, stop *bool) {
+		if cmd.Confirm != nil {
+			confirm = cmd.Confirm
+			*stop = true
+		}
+	})
+	if confirm == nil || confirm.Skip {
+		return nil
+	}
+	return confirm
+}
+
+// Confirmation needed before running the command at args or nil if it can
+// run right away
+//
+// Commands from manifests docked from one of the confirm sources always
+// need confirmation even if they skip it themselves.
+func (s *Spaceport) Confirmation(args []string) (*Confirm, error) {
+	c, m, _, err := s.commandIndex().resolve(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.confirmation(c, m), nil
+}
+
+// confirmation for command c in manifest m with the default message set
+func (s *Spaceport) confirmation(c *Command, m *Manifest) *Confirm {
+	confirm := c.confirmation()
+	if confirm == nil {
+		if !s.RequiresConfirmation(m) {
+			return nil
+		}
+		confirm = &Confirm{}
+	}
+
+	result := *confirm
+	if len(result.Message) == 0 {
+		result.Message = fmt.Sprintf("run %s from %s?", c.KeyPath, m.Name)
+	}
+	return &result
+}
+
+// RequiresConfirmation for all commands in m if its source matches one of
+// the confirm sources
+//
+// Sources can use `*` as a wildcard like `https://github.com/acme/*`.
+func (s *Spaceport) RequiresConfirmation(m *Manifest) bool {
+	if m == nil || len(m.Source) == 0 {
+		return false
+	}
+	for _, source := range s.ConfirmSources {
+		re, err := regexp.Compile("^" + globExpr(source) + "$")
+		if err == nil && re.MatchString(m.Source) {
+			return true
+		}
+	}
+	return false
+}
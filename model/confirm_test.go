@@ -0,0 +1,105 @@
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func fakeConfirmManifest(source string) *Manifest {
+	m := NewManifest("db", source, "", nil)
+	m.AddCommand("db", "psql", "", nil, false, "")
+	m.AddCommand("db.drop", "dropdb app", "", nil, false, "")
+	m.AddCommand("db.drop.test", "dropdb test", "", nil, false, "")
+	m.AddCommand("db.list", "psql -l", "", nil, false, "")
+	m.Find("db.drop").Confirm = &Confirm{Message: "drop the app database?", Phrase: "app"}
+	m.Find("db.drop.test").Confirm = &Confirm{Skip: true}
+	m.Link()
+	return m
+}
+
+func TestSpaceportConfirmation(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		sources  []string
+		args     []string
+		expected *Confirm
+	}{
+		{"not needed", "", nil, []string{"db", "list"}, nil},
+		{"set", "", nil, []string{"db", "drop"}, &Confirm{Message: "drop the app database?", Phrase: "app"}},
+		{"inherited", "", nil, []string{"db", "drop", "--force"}, &Confirm{Message: "drop the app database?", Phrase: "app"}},
+		{"skipped", "", nil, []string{"db", "drop", "test"}, nil},
+		{"source", "https://github.com/acme/db.yaml", []string{"https://github.com/acme/*"}, []string{"db", "list"}, &Confirm{Message: "run db.list from db?"}},
+		{"source overrides skip", "https://github.com/acme/db.yaml", []string{"https://github.com/acme/*"}, []string{"db", "drop", "test"}, &Confirm{Message: "run db.drop.test from db?"}},
+		{"other source", "https://github.com/other/db.yaml", []string{"https://github.com/acme/*"}, []string{"db", "list"}, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := NewSpaceport([]*Manifest{fakeConfirmManifest(test.source)})
+			s.ConfirmSources = test.sources
+			actual, err := s.Confirmation(test.args)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("expected: %v, actual: %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestConfirmUnmarshal(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected *Confirm
+	}{
+		{"true", `true`, &Confirm{}},
+		{"false", `false`, &Confirm{Skip: true}},
+		{"message", `"really?"`, &Confirm{Message: "really?"}},
+		{"fields", `{"message": "really?", "phrase": "prod"}`, &Confirm{Message: "really?", Phrase: "prod"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var fromYAML, fromJSON Confirm
+			if err := yaml.Unmarshal([]byte(test.value), &fromYAML); err != nil {
+				t.Fatalf("unexpected yaml error: %s", err)
+			}
+			if err := json.Unmarshal([]byte(test.value), &fromJSON); err != nil {
+				t.Fatalf("unexpected json error: %s", err)
+			}
+			if !reflect.DeepEqual(&fromYAML, test.expected) {
+				t.Errorf("yaml expected: %v, actual: %v", test.expected, fromYAML)
+			}
+			if !reflect.DeepEqual(&fromJSON, test.expected) {
+				t.Errorf("json expected: %v, actual: %v", test.expected, fromJSON)
+			}
+		})
+	}
+}
+
+func TestConfirmAccepts(t *testing.T) {
+	tests := []struct {
+		name     string
+		confirm  *Confirm
+		answer   string
+		expected bool
+	}{
+		{"yes", &Confirm{}, "yes", true},
+		{"y", &Confirm{}, "y\n", true},
+		{"no", &Confirm{}, "n", false},
+		{"empty", &Confirm{}, "", false},
+		{"phrase", &Confirm{Phrase: "prod"}, "prod", true},
+		{"phrase yes", &Confirm{Phrase: "prod"}, "yes", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := test.confirm.Accepts(test.answer); actual != test.expected {
+				t.Errorf("expected: %t, actual: %t", test.expected, actual)
+			}
+		})
+	}
+}
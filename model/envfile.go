@@ -0,0 +1,141 @@
+package model
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pokanop/nostromo/dotenv"
+	"github.com/pokanop/nostromo/pathutil"
+)
+
+// maskedValue shown instead of env values
+const maskedValue = "******"
+
+// Environment loaded from env files of a command and its parents
+type Environment struct {
+	Files []*EnvFile
+	Vars  []*EnvVar
+}
+
+// EnvFile referenced by a command and the path it was resolved to
+type EnvFile struct {
+	KeyPath string `json:"keyPath"`
+	File    string `json:"file"`
+	Path    string `json:"path"`
+	Loaded  bool   `json:"loaded"`
+}
+
+// EnvVar loaded from an env file
+type EnvVar struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// Environment for the command at args loading env files from the root down
+// so files closer to the command take precedence
+//
+// Relative paths resolve from dir and then the directory of the manifest
+// with the command. Missing files are skipped. Values can interpolate
+// variables from earlier files and then lookup.
+func (s *Spaceport) Environment(args []string, dir string, lookup func(name string) (string, bool)) (*Environment, error) {
+	c, m, _, err := s.commandIndex().resolve(args)
+	if err != nil {
+		return nil, err
+	}
+	return c.environment(dir, filepath.Dir(m.Path), lookup)
+}
+
+func (c *Command) environment(dir, manifestDir string, lookup func(name string) (string, bool)) (*Environment, error) {
+	var scopes []*Command
+	c.reverseWalk(func(cmd *Command, stop *bool) {
+		scopes = append([]*Command{cmd}, scopes...)
+	})
+
+	env := &Environment{}
+	values := map[string]string{}
+	indexes := map[string]int{}
+	envLookup := func(name string) (string, bool) {
+		if value, ok := values[name]; ok {
+			return value, true
+		}
+		if lookup != nil {
+			return lookup(name)
+		}
+		return "", false
+	}
+
+	for _, cmd := range scopes {
+		for _, file := range cmd.EnvFiles {
+			f := &EnvFile{KeyPath: cmd.KeyPath, File: file, Path: resolveEnvFile(file, dir, manifestDir)}
+			env.Files = append(env.Files, f)
+
+			b, err := ioutil.ReadFile(f.Path)
+			if os.IsNotExist(err) {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			entries, err := dotenv.Parse(string(b), envLookup)
+			if err != nil {
+				return nil, fmt.Errorf("invalid env file %s for %s: %s", file, cmd.KeyPath, err)
+			}
+			f.Loaded = true
+
+			for _, entry := range entries {
+				values[entry.Key] = entry.Value
+				v := &EnvVar{entry.Key, entry.Value, file}
+				if i, ok := indexes[entry.Key]; ok {
+					env.Vars[i] = v
+					continue
+				}
+				indexes[entry.Key] = len(env.Vars)
+				env.Vars = append(env.Vars, v)
+			}
+		}
+	}
+	return env, nil
+}
+
+// resolveEnvFile path from dir if it exists there and otherwise the
+// manifest dir
+func resolveEnvFile(file, dir, manifestDir string) string {
+	file = pathutil.Expand(file)
+	if filepath.IsAbs(file) {
+		return file
+	}
+	path := filepath.Join(dir, file)
+	if _, err := os.Stat(path); err != nil && len(manifestDir) > 0 {
+		if alt := filepath.Join(manifestDir, file); alt != path {
+			if _, err := os.Stat(alt); err == nil {
+				return alt
+			}
+		}
+	}
+	return path
+}
+
+// List of variables like `KEY=value` for a process environment
+func (e *Environment) List() []string {
+	var list []string
+	for _, v := range e.Vars {
+		list = append(list, v.Key+"="+v.Value)
+	}
+	return list
+}
+
+// maskValue unless empty so it's clear whether a value is set
+func maskValue(value string) string {
+	if len(value) == 0 {
+		return value
+	}
+	return maskedValue
+}
+
+// joinedEnvFiles for logging
+func joinedEnvFiles(files []string) string {
+	return strings.Join(files, ", ")
+}
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSpaceportEnvironment(t *testing.T) {
+	dir, err := ioutil.TempDir("", "envfiles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	manifestDir := filepath.Join(dir, "manifest")
+	os.MkdirAll(manifestDir, 0755)
+
+	files := map[string]string{
+		filepath.Join(dir, ".env"):             "HOST=localhost\nPORT=80\nURL=http://${HOST}:$PORT",
+		filepath.Join(dir, "api.env"):          "PORT=8080\nURL=\"${URL}/api\"\nUSER_HOME=$HOME",
+		filepath.Join(manifestDir, "team.env"): "TEAM='core'",
+	}
+	for path, content := range files {
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := NewManifest("manifest", "", filepath.Join(manifestDir, "manifest.yaml"), nil)
+	m.AddCommand("svc", "svc", "", nil, false, "")
+	m.AddCommand("svc.api", "api", "", nil, false, "")
+	m.AddCommand("other", "other", "", nil, false, "")
+	m.Find("svc").EnvFiles = []string{".env", "team.env"}
+	m.Find("svc.api").EnvFiles = []string{"api.env", "missing.env"}
+	m.Link()
+	s := NewSpaceport([]*Manifest{m})
+
+	lookup := func(name string) (string, bool) {
+		if name == "HOME" {
+			return "/home/me", true
+		}
+		return "", false
+	}
+
+	env, err := s.Environment([]string{"svc", "api", "arg"}, dir, lookup)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []string{"HOST=localhost", "PORT=8080", "URL=http://localhost:80/api", "TEAM=core", "USER_HOME=/home/me"}
+	if actual := env.List(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+
+	var loaded []bool
+	for _, f := range env.Files {
+		loaded = append(loaded, f.Loaded)
+	}
+	if !reflect.DeepEqual(loaded, []bool{true, true, true, false}) {
+		t.Errorf("expected missing env file to be skipped but got %v", loaded)
+	}
+	if env.Files[1].Path != filepath.Join(manifestDir, "team.env") {
+		t.Errorf("expected env file relative to manifest but got %s", env.Files[1].Path)
+	}
+
+	if env, _ := s.Environment([]string{"other"}, dir, lookup); len(env.Vars) != 0 {
+		t.Errorf("expected no env for other command but got %v", env.List())
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "api.env"), []byte("INVALID"), 0644)
+	if _, err := s.Environment([]string{"svc", "api"}, dir, lookup); err == nil {
+		t.Errorf("expected error for invalid env file")
+	}
+}
+
+func TestExplanationSetEnvironment(t *testing.T) {
+	e := &Explanation{}
+	e.SetEnvironment(&Environment{Vars: []*EnvVar{{"TOKEN", "abc", ".env"}, {"EMPTY", "", ".env"}}})
+	expected := []*EnvVar{{"TOKEN", maskedValue, ".env"}, {"EMPTY", "", ".env"}}
+	if !reflect.DeepEqual(e.Env, expected) {
+		t.Errorf("expected masked values but got %v", e.Env)
+	}
+}
@@ -0,0 +1,193 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pokanop/nostromo/secret"
+)
+
+// Explanation of how an execution string was built from arguments
+type Explanation struct {
+	Input         []string               `json:"input"`
+	Manifest      string                 `json:"manifest"`
+	KeyPath       string                 `json:"keyPath"`
+	Language      string                 `json:"language,omitempty"`
+	Steps         []*ExplainStep         `json:"steps"`
+	Substitutions []*ExplainSubstitution `json:"substitutions,omitempty"`
+	Placeholders  []*ExplainPlaceholder  `json:"placeholders,omitempty"`
+	References    []*ExplainReference    `json:"references,omitempty"`
+	Secrets       []string               `json:"secrets,omitempty"`
+	EnvFiles      []*EnvFile             `json:"envFiles,omitempty"`
+	Env           []*EnvVar              `json:"env,omitempty"`
+	Arguments     []string               `json:"arguments,omitempty"`
+	Workflow      []*WorkflowStep        `json:"workflow,omitempty"`
+	Policy        string                 `json:"policy,omitempty"`
+	Confirm       *Confirm               `json:"confirm,omitempty"`
+	Result        string                 `json:"result"`
+	Eval          string                 `json:"eval,omitempty"`
+}
+
+// ExplainStep for each command from the root contributing to the result
+type ExplainStep struct {
+	KeyPath  string `json:"keyPath"`
+	Mode     string `json:"mode"`
+	Command  string `json:"command"`
+	Included bool   `json:"included"`
+}
+
+// ExplainSubstitution of an argument and the key path of its scope or
+// global for manifest wide substitutions
+type ExplainSubstitution struct {
+	Arg   string `json:"arg"`
+	Value string `json:"value"`
+	Scope string `json:"scope"`
+	// Pattern that matched the argument if not an exact substitution
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// ExplainPlaceholder replaced in the command and where its value came from
+type ExplainPlaceholder struct {
+	Placeholder string `json:"placeholder"`
+	Value       string `json:"value"`
+	Source      string `json:"source"`
+}
+
+// ExplainReference to another command inlined in the result
+type ExplainReference struct {
+	Reference string `json:"reference"`
+	Value     string `json:"value"`
+	KeyPath   string `json:"keyPath"`
+	Manifest  string `json:"manifest"`
+}
+
+// Explain how the command at args is resolved without running it
+func (s *Spaceport) Explain(args []string) (*Explanation, error) {
+	c, m, rest, err := s.commandIndex().resolve(args)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Explanation{
+		Input:    args,
+		Manifest: m.Name,
+		KeyPath:  c.KeyPath,
+		Language: c.Code.Language,
+	}
+	if e.Result, err = c.trace(rest, s.FindCommand, nil, e); err != nil {
+		return nil, err
+	}
+
+	if len(c.Steps) > 0 {
+		w, _, err := s.Workflow(args)
+		if err != nil {
+			return nil, err
+		}
+		e.Workflow = w.Steps
+		e.Policy = w.String()
+	}
+	e.addSecrets()
+	e.Confirm = s.confirmation(c, m)
+
+	return e, nil
+}
+
+// AsJSON returns the explanation as a JSON string
+func (e *Explanation) AsJSON() string {
+	b, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// addSteps for the command and its ancestors ordered from the root
+func (e *Explanation) addSteps(c *Command) {
+	if e == nil {
+		return
+	}
+
+	var steps []*ExplainStep
+	c.reverseWalk(func(cmd *Command, stop *bool) {
+		step := &ExplainStep{
+			KeyPath:  cmd.KeyPath,
+			Mode:     cmd.Mode.String(),
+			Command:  cmd.effectiveCommand(),
+			Included: c.Mode != ExclusiveMode || cmd == c,
+		}
+		if c.Mode == ExclusiveMode && cmd == c {
+			step.Command = c.Name
+		}
+		steps = append([]*ExplainStep{step}, steps...)
+	})
+	e.Steps = steps
+}
+
+// addSubstitution of arg by sub found in a scope
+func (e *Explanation) addSubstitution(arg, value string, sub *Substitution, scope string) {
+	if e == nil {
+		return
+	}
+
+	var pattern string
+	if sub.isPattern() {
+		pattern = fmt.Sprintf("%s %s", sub.Match, sub.Alias)
+	}
+	e.Substitutions = append(e.Substitutions, &ExplainSubstitution{arg, value, scope, pattern})
+}
+
+// addSecrets referenced by the result or workflow steps which are only
+// resolved when running so values are never shown
+func (e *Explanation) addSecrets() {
+	cmds := []string{e.Result}
+	for _, step := range e.Workflow {
+		cmds = append(cmds, step.Command)
+	}
+	e.Secrets = secret.References(strings.Join(cmds, "\n"))
+}
+
+// SetEnvironment loaded for the command with values masked
+func (e *Explanation) SetEnvironment(env *Environment) {
+	e.EnvFiles = env.Files
+	e.Env = nil
+	for _, v := range env.Vars {
+		e.Env = append(e.Env, &EnvVar{v.Key, maskValue(v.Value), v.Source})
+	}
+}
+
+// addReference inlined from a command in a manifest
+func (e *Explanation) addReference(ref, value string, c *Command, m *Manifest) {
+	if e == nil {
+		return
+	}
+
+	e.References = append(e.References, &ExplainReference{ref, value, c.KeyPath, m.Name})
+}
+
+func (e *Explanation) addPlaceholder(placeholder, value, source string) {
+	if e == nil {
+		return
+	}
+
+	e.Placeholders = append(e.Placeholders, &ExplainPlaceholder{placeholder, value, source})
+}
+
+// addArguments replacing positional parameters in cmd and the rest that
+// are appended
+func (e *Explanation) addArguments(cmd string, args []string) {
+	if e == nil {
+		return
+	}
+
+	count := 0
+	for _, arg := range args {
+		shellVar := fmt.Sprintf("$%d", count+1)
+		if !strings.Contains(cmd, shellVar) {
+			break
+		}
+		e.addPlaceholder(shellVar, arg, "argument")
+		count++
+	}
+	e.Arguments = args[count:]
+}
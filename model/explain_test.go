@@ -0,0 +1,93 @@
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSpaceportExplain(t *testing.T) {
+	m := fakeFlagManifest()
+	m.AddCommand("deploy.logs", "logs $1 --since", "", nil, false, "")
+	m.AddCommand("deploy.only", "only", "", nil, false, ExclusiveMode.String())
+	m.AddSubstitution("deploy", "production", "prod")
+	m.Link()
+	s := NewSpaceport([]*Manifest{m})
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected *Explanation
+	}{
+		{
+			"nested",
+			[]string{"deploy", "logs", "api", "prod", "--dry"},
+			&Explanation{
+				Input:    []string{"deploy", "logs", "api", "prod", "--dry"},
+				Manifest: "manifest",
+				KeyPath:  "deploy.logs",
+				Steps: []*ExplainStep{
+					{"deploy", "concatenate", "deploy ${flag:env}", true},
+					{"deploy.logs", "concatenate", "logs $1 --since", true},
+				},
+				Substitutions: []*ExplainSubstitution{{"prod", "production", "deploy", ""}},
+				Placeholders: []*ExplainPlaceholder{
+					{"${flag:env}", "staging", "default"},
+					{"--dry", "--dry", "option"},
+					{"$1", "api", "argument"},
+				},
+				Arguments: []string{"production"},
+				Result:    "deploy staging logs api --since --dry production",
+			},
+		},
+		{
+			"exclusive",
+			[]string{"deploy", "only"},
+			&Explanation{
+				Input:    []string{"deploy", "only"},
+				Manifest: "manifest",
+				KeyPath:  "deploy.only",
+				Steps: []*ExplainStep{
+					{"deploy", "concatenate", "deploy ${flag:env}", false},
+					{"deploy.only", "exclusive", "only", true},
+				},
+				Result: "only",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := s.Explain(test.args)
+			if err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+			if !reflect.DeepEqual(actual, test.expected) {
+				a, _ := json.Marshal(actual)
+				e, _ := json.Marshal(test.expected)
+				t.Errorf("expected: %s, actual: %s", e, a)
+			}
+			if _, cmd, _, _ := s.ExecutionString(test.args); cmd != actual.Result {
+				t.Errorf("expected explained result to match execution string %s", cmd)
+			}
+		})
+	}
+}
+
+func TestSpaceportExplainSecrets(t *testing.T) {
+	m := NewManifest("manifest", "", "", nil)
+	m.AddCommand("api", "curl -H 'token ${secret:gh_token}'", "", nil, false, "")
+	m.Link()
+	s := NewSpaceport([]*Manifest{m})
+
+	e, err := s.Explain([]string{"api", "url"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(e.Secrets, []string{"gh_token"}) {
+		t.Errorf("expected gh_token secret but got %v", e.Secrets)
+	}
+	if e.Result != "curl -H 'token ${secret:gh_token}' url" {
+		t.Errorf("expected secret reference in result but got %s", e.Result)
+	}
+}
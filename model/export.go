@@ -0,0 +1,83 @@
+package model
+
+import (
+	"sort"
+)
+
+// ExportCommand with everything needed to run it without nostromo
+type ExportCommand struct {
+	KeyPath     string
+	Description string
+	// Command expanded from the root with modes and flag defaults applied
+	Command  string
+	Language string
+	// Subs in scope where the closest scope wins including global ones
+	Subs []*Substitution
+	// Children aliases that can follow this command
+	Children []string
+}
+
+// ExportCommands for all enabled commands in the manifest ordered by key
+// path
+//
+// Commands are expanded the same way as when executing so scripts built
+// from them behave the same. Flags can't be parsed without nostromo so
+// their placeholders use default values. References can only be inlined
+// from commands in the same manifest. Only exact substitutions for any
+// argument position can be exported.
+func (m *Manifest) ExportCommands() ([]*ExportCommand, error) {
+	var cmds []*ExportCommand
+	var err error
+	for _, cmd := range m.SortedCommands() {
+		cmd.forwardWalk(func(c *Command, stop *bool) {
+			if disabled, _ := c.checkDisabled(); disabled {
+				return
+			}
+			var e *ExportCommand
+			if e, err = m.exportCommand(c); err != nil {
+				*stop = true
+				return
+			}
+			cmds = append(cmds, e)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return cmds, nil
+}
+
+func (m *Manifest) exportCommand(c *Command) (*ExportCommand, error) {
+	cmd := c.Name
+	if c.Mode != ExclusiveMode {
+		cmd = c.expand()
+	}
+	cmd, err := m.resolveReferences(c.applyFlags(cmd, map[string]string{}, nil), c)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &ExportCommand{
+		KeyPath:     c.KeyPath,
+		Description: c.Description,
+		Command:     cmd,
+		Language:    c.Code.Language,
+	}
+
+	for _, sub := range c.subsInScope() {
+		if sub.isPattern() || sub.Position > 0 || sub.Split {
+			continue
+		}
+		e.Subs = append(e.Subs, sub)
+	}
+	sort.Slice(e.Subs, func(i, j int) bool {
+		return e.Subs[i].Alias < e.Subs[j].Alias
+	})
+
+	for _, child := range c.SortedCommands() {
+		if !child.Disabled {
+			e.Children = append(e.Children, child.Alias)
+		}
+	}
+	return e, nil
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestManifestExportCommands(t *testing.T) {
+	m := fakeFlagManifest()
+	m.AddCommand("deploy.only", "only $1", "", nil, false, ExclusiveMode.String())
+	m.AddCommand("deploy.off", "off", "", nil, false, "")
+	m.AddSubstitution("deploy", "production", "prod")
+	m.AddSubstitution("deploy.only", "primary", "prod")
+	m.Find("deploy.off").Disabled = true
+	m.Link()
+
+	expected := []*ExportCommand{
+		{
+			KeyPath:  "deploy",
+			Command:  "deploy staging",
+			Subs:     []*Substitution{{Name: "production", Alias: "prod"}},
+			Children: []string{"only", "status"},
+		},
+		{
+			KeyPath: "deploy.only",
+			Command: "only $1",
+			Subs:    []*Substitution{{Name: "primary", Alias: "prod"}},
+		},
+		{
+			KeyPath: "deploy.status",
+			Command: "deploy staging status",
+			Subs:    []*Substitution{{Name: "production", Alias: "prod"}},
+		},
+	}
+	actual, err := m.ExportCommands()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected: %s, actual: %s", toJSON(expected), toJSON(actual))
+	}
+}
@@ -0,0 +1,215 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pokanop/nostromo/stringutil"
+	"github.com/spf13/cobra"
+)
+
+// Supported flag types
+const (
+	StringFlagType = "string"
+	BoolFlagType   = "bool"
+	IntFlagType    = "int"
+)
+
+// flagPlaceholder in commands replaced with a flag's value like `${flag:name}`
+var flagPlaceholder = regexp.MustCompile(`\$\{flag:([A-Za-z0-9_-]+)\}`)
+
+// Flag for a command parsed from arguments when executed
+//
+// Values replace `${flag:name}` placeholders in the command and flags
+// without a placeholder are appended as options when set. Values from
+// arguments are quoted for the shell while defaults are used as authored.
+type Flag struct {
+	Name        string `json:"name"`
+	Shorthand   string `json:"shorthand,omitempty" yaml:"shorthand,omitempty"`
+	Type        string `json:"type,omitempty" yaml:"type,omitempty"`
+	Default     string `json:"default,omitempty" yaml:"default,omitempty"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+func (f *Flag) flagType() string {
+	if len(f.Type) == 0 {
+		return StringFlagType
+	}
+	return f.Type
+}
+
+// validate the flag value for its type
+func (f *Flag) validate(value string) error {
+	var err error
+	switch f.flagType() {
+	case StringFlagType:
+	case BoolFlagType:
+		_, err = strconv.ParseBool(value)
+	case IntFlagType:
+		_, err = strconv.Atoi(value)
+	default:
+		return fmt.Errorf("invalid type %s for flag %s", f.Type, f.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid value '%s' for %s flag %s", value, f.flagType(), f.Name)
+	}
+	return nil
+}
+
+// option rendered for a value when the command has no placeholder
+func (f *Flag) option(value string) string {
+	if f.flagType() == BoolFlagType {
+		if b, _ := strconv.ParseBool(value); b {
+			return "--" + f.Name
+		}
+		return ""
+	}
+	return fmt.Sprintf("--%s %s", f.Name, stringutil.ShellQuote(value))
+}
+
+// register the flag with a cobra command for help and completion, flags
+// are inherited by child commands
+func (f *Flag) register(cmd *cobra.Command) {
+	switch f.flagType() {
+	case BoolFlagType:
+		b, _ := strconv.ParseBool(f.Default)
+		cmd.PersistentFlags().BoolP(f.Name, f.Shorthand, b, f.Description)
+	case IntFlagType:
+		i, _ := strconv.Atoi(f.Default)
+		cmd.PersistentFlags().IntP(f.Name, f.Shorthand, i, f.Description)
+	default:
+		cmd.PersistentFlags().StringP(f.Name, f.Shorthand, f.Default, f.Description)
+	}
+}
+
+// flagsInScope for this command where the closest scope wins
+func (c *Command) flagsInScope() []*Flag {
+	var flags []*Flag
+	seen := map[string]bool{}
+	c.reverseWalk(func(cmd *Command, stop *bool) {
+		for _, f := range cmd.Flags {
+			if !seen[f.Name] {
+				seen[f.Name] = true
+				flags = append(flags, f)
+			}
+		}
+	})
+	return flags
+}
+
+// parseFlags in args for this command
+//
+// Returns the values of flags that were set and the remaining arguments.
+// Unknown flags are kept as arguments for the underlying command and
+// parsing stops at `--`.
+func (c *Command) parseFlags(args []string) (map[string]string, []string, error) {
+	flags := c.flagsInScope()
+	values := map[string]string{}
+	if len(flags) == 0 {
+		return values, args, nil
+	}
+
+	lookup := func(name string, short bool) *Flag {
+		for _, f := range flags {
+			if (!short && f.Name == name) || (short && len(f.Shorthand) > 0 && f.Shorthand == name) {
+				return f
+			}
+		}
+		return nil
+	}
+
+	var remaining []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			remaining = append(remaining, args[i+1:]...)
+			break
+		}
+
+		var name string
+		var short bool
+		if strings.HasPrefix(arg, "--") {
+			name = arg[2:]
+		} else if strings.HasPrefix(arg, "-") && len(arg) > 1 {
+			name, short = arg[1:], true
+		} else {
+			remaining = append(remaining, arg)
+			continue
+		}
+
+		value, hasValue := "", false
+		if i := strings.Index(name, "="); i != -1 {
+			name, value, hasValue = name[:i], name[i+1:], true
+		}
+
+		f := lookup(name, short)
+		if f == nil {
+			remaining = append(remaining, arg)
+			continue
+		}
+
+		if !hasValue {
+			if f.flagType() == BoolFlagType {
+				value = "true"
+			} else if i+1 < len(args) {
+				i++
+				value = args[i]
+			} else {
+				return nil, nil, fmt.Errorf("flag needs an argument: %s", arg)
+			}
+		}
+
+		if err := f.validate(value); err != nil {
+			return nil, nil, err
+		}
+		values[f.Name] = value
+	}
+
+	return values, remaining, nil
+}
+
+// applyFlags to the command replacing placeholders with values or defaults
+// and appending options for flags that were set without a placeholder
+func (c *Command) applyFlags(cmd string, values map[string]string, e *Explanation) string {
+	placed := map[string]bool{}
+	cmd = flagPlaceholder.ReplaceAllStringFunc(cmd, func(s string) string {
+		name := flagPlaceholder.FindStringSubmatch(s)[1]
+		if value, ok := values[name]; ok {
+			placed[name] = true
+			value = stringutil.ShellQuote(value)
+			e.addPlaceholder(s, value, "flag")
+			return value
+		}
+		for _, f := range c.flagsInScope() {
+			if f.Name == name {
+				e.addPlaceholder(s, f.Default, "default")
+				return f.Default
+			}
+		}
+		// Leave placeholders for undeclared flags as is
+		return s
+	})
+
+	for _, f := range c.flagsInScope() {
+		value, ok := values[f.Name]
+		if !ok || placed[f.Name] {
+			continue
+		}
+		if option := f.option(value); len(option) > 0 {
+			e.addPlaceholder("--"+f.Name, option, "option")
+			cmd = fmt.Sprintf("%s %s", cmd, option)
+		}
+	}
+
+	return cmd
+}
+
+func joinedFlags(flags []*Flag) string {
+	names := []string{}
+	for _, f := range flags {
+		names = append(names, f.Name)
+	}
+	return strings.Join(names, ", ")
+}
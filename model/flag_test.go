@@ -0,0 +1,89 @@
+package model
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestFlagExecutionString(t *testing.T) {
+	tests := []struct {
+		name     string
+		keyPath  string
+		args     []string
+		expErr   string
+		expected string
+	}{
+		{"default placeholder", "deploy", nil, "", "deploy staging"},
+		{"long flag", "deploy", []string{"--env", "prod"}, "", "deploy prod"},
+		{"shorthand with value", "deploy", []string{"-e=prod"}, "", "deploy prod"},
+		{"bool appended", "deploy", []string{"--dry"}, "", "deploy staging --dry"},
+		{"bool false", "deploy", []string{"--dry=false"}, "", "deploy staging"},
+		{"int appended before args", "deploy", []string{"app", "--replicas", "3"}, "", "deploy staging --replicas 3 app"},
+		{"unknown flags kept", "deploy", []string{"-la", "--env", "prod"}, "", "deploy prod -la"},
+		{"stop at double dash", "deploy", []string{"--", "--env", "prod"}, "", "deploy staging --env prod"},
+		{"inherited flag", "deploy.status", []string{"--env", "prod"}, "", "deploy prod status"},
+		{"quoted placeholder", "deploy", []string{"--env", "prod; rm -rf /"}, "", "deploy 'prod; rm -rf /'"},
+		{"quoted option", "deploy", []string{"--note", "it's a test; id"}, "", `deploy staging --note 'it'\''s a test; id'`},
+		{"invalid int", "deploy", []string{"--replicas", "many"}, "invalid value 'many' for int flag replicas", ""},
+		{"missing value", "deploy", []string{"--env"}, "flag needs an argument: --env", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := fakeFlagManifest()
+			_, actual, err := m.ExecutionString(append(strings.Split(test.keyPath, "."), test.args...))
+			if len(test.expErr) > 0 {
+				if err == nil || err.Error() != test.expErr {
+					t.Errorf("expected error '%s' but got '%v'", test.expErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+			if actual != test.expected {
+				t.Errorf("expected: %s, actual: %s", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCobraCommandFlags(t *testing.T) {
+	m := fakeFlagManifest()
+
+	root := &cobra.Command{Use: "nostromo"}
+	run := &cobra.Command{Use: "run"}
+	run.AddCommand(m.Find("deploy").CobraCommand())
+	root.AddCommand(run)
+
+	var buf bytes.Buffer
+	root.SetOut(&buf)
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs([]string{"__complete", "run", "deploy", "status", "--"})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{"--env\tTarget environment\n", "--dry\n", "--replicas\n"} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("expected completion %q in %s", expected, buf.String())
+		}
+	}
+}
+
+func fakeFlagManifest() *Manifest {
+	m := NewManifest("manifest", "", "", nil)
+	m.AddCommand("deploy", "deploy ${flag:env}", "", nil, false, "")
+	m.AddCommand("deploy.status", "status", "", nil, false, "")
+	m.Find("deploy").Flags = []*Flag{
+		{Name: "env", Shorthand: "e", Default: "staging", Description: "Target environment"},
+		{Name: "dry", Type: BoolFlagType},
+		{Name: "replicas", Type: IntFlagType},
+		{Name: "note"},
+	}
+	m.Link()
+	return m
+}
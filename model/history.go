@@ -0,0 +1,196 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pokanop/nostromo/keypath"
+)
+
+// HistoryEntry for a command resolved by nostromo
+//
+// Exit codes and durations are only known for commands run directly,
+// commands evaluated by the shell only record their arguments.
+type HistoryEntry struct {
+	Time       time.Time `json:"time"`
+	KeyPath    string    `json:"keyPath"`
+	Manifest   string    `json:"manifest"`
+	Args       []string  `json:"args,omitempty"`
+	ExitCode   *int      `json:"exitCode,omitempty"`
+	DurationMs int64     `json:"durationMs,omitempty"`
+}
+
+// NewHistoryEntry for the command at key path in manifest
+func NewHistoryEntry(keyPath, manifest string, args []string) *HistoryEntry {
+	return &HistoryEntry{
+		Time:     time.Now(),
+		KeyPath:  keyPath,
+		Manifest: manifest,
+		Args:     args,
+	}
+}
+
+// Finish the entry with the exit code and duration since it started
+func (h *HistoryEntry) Finish(exitCode int) {
+	h.ExitCode = &exitCode
+	h.DurationMs = time.Since(h.Time).Milliseconds()
+}
+
+// Failed returns true if the command was run and exited with an error
+func (h *HistoryEntry) Failed() bool {
+	return h.ExitCode != nil && *h.ExitCode != 0
+}
+
+// CommandLine for the entry as it would be typed
+func (h *HistoryEntry) CommandLine() string {
+	return strings.TrimSpace(strings.Join(append(keypath.Keys(h.KeyPath), h.Args...), " "))
+}
+
+// FindHistoryEntry referenced like `!n` counting from 1, `!-n` counting back
+// from the most recent entry or `!!` for the most recent entry
+func FindHistoryEntry(entries []*HistoryEntry, ref string) (*HistoryEntry, error) {
+	if !strings.HasPrefix(ref, "!") {
+		return nil, fmt.Errorf("invalid history reference %s, expected !n, !-n or !!", ref)
+	}
+
+	index := len(entries) - 1
+	if ref != "!!" {
+		n, err := strconv.Atoi(ref[1:])
+		if err != nil || n == 0 {
+			return nil, fmt.Errorf("invalid history reference %s, expected !n, !-n or !!", ref)
+		}
+		if n > 0 {
+			index = n - 1
+		} else {
+			index = len(entries) + n
+		}
+	}
+
+	if index < 0 || index >= len(entries) {
+		return nil, fmt.Errorf("history entry %s not found", ref)
+	}
+	return entries[index], nil
+}
+
+// PruneHistory entries past the max count or older than max age, an age of
+// 0 keeps entries regardless of age
+func PruneHistory(entries []*HistoryEntry, maxCount int, maxAge time.Duration) []*HistoryEntry {
+	if maxAge > 0 {
+		cutoff := time.Now().Add(-maxAge)
+		i := 0
+		for i < len(entries) && entries[i].Time.Before(cutoff) {
+			i++
+		}
+		entries = entries[i:]
+	}
+	if maxCount >= 0 && len(entries) > maxCount {
+		entries = entries[len(entries)-maxCount:]
+	}
+	return entries
+}
+
+// CommandUsage from history for a command
+type CommandUsage struct {
+	Manifest string     `json:"manifest"`
+	KeyPath  string     `json:"keyPath"`
+	Count    int        `json:"count"`
+	Runs     int        `json:"runs,omitempty"`
+	Failures int        `json:"failures,omitempty"`
+	LastUsed *time.Time `json:"lastUsed,omitempty"`
+}
+
+// HistoryStats for commands in the spaceport
+type HistoryStats struct {
+	MostUsed  []*CommandUsage `json:"mostUsed"`
+	NeverUsed []*CommandUsage `json:"neverUsed"`
+	Failing   []*CommandUsage `json:"failing"`
+}
+
+// HistoryStats for commands in all manifests or the one named
+//
+// Most used and failing commands are limited to the top results when limit
+// is positive. Commands are never used if neither they nor any of their
+// children show up in history.
+func (s *Spaceport) HistoryStats(entries []*HistoryEntry, manifest string, limit int) *HistoryStats {
+	usages := map[string]*CommandUsage{}
+	var ordered []*CommandUsage
+	for _, entry := range entries {
+		if len(manifest) > 0 && entry.Manifest != manifest {
+			continue
+		}
+
+		key := entry.Manifest + ":" + entry.KeyPath
+		u := usages[key]
+		if u == nil {
+			u = &CommandUsage{Manifest: entry.Manifest, KeyPath: entry.KeyPath}
+			usages[key] = u
+			ordered = append(ordered, u)
+		}
+		u.Count++
+		if entry.ExitCode != nil {
+			u.Runs++
+		}
+		if entry.Failed() {
+			u.Failures++
+		}
+		t := entry.Time
+		u.LastUsed = &t
+	}
+
+	stats := &HistoryStats{
+		MostUsed:  []*CommandUsage{},
+		NeverUsed: []*CommandUsage{},
+		Failing:   []*CommandUsage{},
+	}
+
+	stats.MostUsed = append(stats.MostUsed, ordered...)
+	sort.SliceStable(stats.MostUsed, func(i, j int) bool {
+		return stats.MostUsed[i].Count > stats.MostUsed[j].Count
+	})
+
+	for _, u := range ordered {
+		if u.Failures > 0 {
+			stats.Failing = append(stats.Failing, u)
+		}
+	}
+	sort.SliceStable(stats.Failing, func(i, j int) bool {
+		return stats.Failing[i].Failures > stats.Failing[j].Failures
+	})
+
+	if limit > 0 {
+		if len(stats.MostUsed) > limit {
+			stats.MostUsed = stats.MostUsed[:limit]
+		}
+		if len(stats.Failing) > limit {
+			stats.Failing = stats.Failing[:limit]
+		}
+	}
+
+	for _, m := range s.Manifests() {
+		if len(manifest) > 0 && m.Name != manifest {
+			continue
+		}
+		for _, cmd := range m.SortedCommands() {
+			cmd.Walk(func(c *Command, stop *bool) {
+				if !usedInHistory(usages, m.Name, c.KeyPath) {
+					stats.NeverUsed = append(stats.NeverUsed, &CommandUsage{Manifest: m.Name, KeyPath: c.KeyPath})
+				}
+			})
+		}
+	}
+
+	return stats
+}
+
+// usedInHistory checks if the key path or any of its children were used
+func usedInHistory(usages map[string]*CommandUsage, manifest, keyPath string) bool {
+	for _, u := range usages {
+		if u.Manifest == manifest && (u.KeyPath == keyPath || strings.HasPrefix(u.KeyPath, keyPath+".")) {
+			return true
+		}
+	}
+	return false
+}
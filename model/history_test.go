@@ -0,0 +1,149 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pokanop/nostromo/version"
+)
+
+func fakeHistory() []*HistoryEntry {
+	now := time.Now()
+	entry := func(keyPath string, age time.Duration, exitCode *int, args ...string) *HistoryEntry {
+		return &HistoryEntry{Time: now.Add(-age), KeyPath: keyPath, Manifest: CoreManifestName, Args: args, ExitCode: exitCode}
+	}
+	ok, failed := 0, 2
+	return []*HistoryEntry{
+		entry("git.checkout", 72*time.Hour, nil, "main"),
+		entry("git.checkout", 48*time.Hour, &failed, "oops"),
+		entry("build", 24*time.Hour, &ok),
+		entry("git.checkout", time.Hour, &ok),
+		entry("build", time.Minute, &failed),
+	}
+}
+
+func fakeHistorySpaceport() *Spaceport {
+	m := NewManifest(CoreManifestName, "", "", &version.Info{})
+	m.AddCommand("git.checkout", "git checkout", "", nil, false, "")
+	m.AddCommand("git.stash", "git stash", "", nil, false, "")
+	m.AddCommand("build", "make", "", nil, false, "")
+	m.AddCommand("lint", "golint", "", nil, false, "")
+	return NewSpaceport([]*Manifest{m})
+}
+
+func TestHistoryEntry(t *testing.T) {
+	h := NewHistoryEntry("git.checkout", CoreManifestName, []string{"-b", "feature"})
+	if line := h.CommandLine(); line != "git checkout -b feature" {
+		t.Errorf("expected command line but got %s", line)
+	}
+	if h.Failed() {
+		t.Errorf("expected eval entry to not fail")
+	}
+	h.Finish(1)
+	if !h.Failed() || *h.ExitCode != 1 {
+		t.Errorf("expected finished entry to fail")
+	}
+}
+
+func TestFindHistoryEntry(t *testing.T) {
+	tests := []struct {
+		name     string
+		ref      string
+		expected int
+		err      bool
+	}{
+		{"last", "!!", 4, false},
+		{"first", "!1", 0, false},
+		{"nth", "!3", 2, false},
+		{"relative", "!-2", 3, false},
+		{"past end", "!6", 0, true},
+		{"before start", "!-6", 0, true},
+		{"zero", "!0", 0, true},
+		{"not a number", "!foo", 0, true},
+		{"missing bang", "3", 0, true},
+	}
+
+	entries := fakeHistory()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := FindHistoryEntry(entries, test.ref)
+			if test.err {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+			if actual != entries[test.expected] {
+				t.Errorf("expected entry %d but got %v", test.expected, actual)
+			}
+		})
+	}
+
+	if _, err := FindHistoryEntry(nil, "!!"); err == nil {
+		t.Errorf("expected error for empty history")
+	}
+}
+
+func TestPruneHistory(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxCount int
+		maxAge   time.Duration
+		expected int
+	}{
+		{"keep all", 10, 0, 5},
+		{"max count", 2, 0, 2},
+		{"max age", 10, 36 * time.Hour, 3},
+		{"max count and age", 1, 36 * time.Hour, 1},
+		{"none", 0, 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entries := fakeHistory()
+			actual := PruneHistory(entries, test.maxCount, test.maxAge)
+			if len(actual) != test.expected {
+				t.Fatalf("expected %d entries but got %d", test.expected, len(actual))
+			}
+			if len(actual) > 0 && actual[len(actual)-1] != entries[len(entries)-1] {
+				t.Errorf("expected most recent entries to be kept")
+			}
+		})
+	}
+}
+
+func TestHistoryStats(t *testing.T) {
+	s := fakeHistorySpaceport()
+	stats := s.HistoryStats(fakeHistory(), "", 0)
+
+	if len(stats.MostUsed) != 2 || stats.MostUsed[0].KeyPath != "git.checkout" || stats.MostUsed[0].Count != 3 {
+		t.Errorf("expected git.checkout to be most used but got %s", toJSON(stats.MostUsed))
+	}
+	if u := stats.MostUsed[1]; u.KeyPath != "build" || u.Runs != 2 || u.Failures != 1 {
+		t.Errorf("expected build runs and failures but got %s", toJSON(u))
+	}
+	if len(stats.Failing) != 2 {
+		t.Errorf("expected 2 failing commands but got %s", toJSON(stats.Failing))
+	}
+
+	var neverUsed []string
+	for _, u := range stats.NeverUsed {
+		neverUsed = append(neverUsed, u.KeyPath)
+	}
+	if toJSON(neverUsed) != `["git.stash","lint"]` {
+		t.Errorf("expected unused commands but got %v", neverUsed)
+	}
+
+	limited := s.HistoryStats(fakeHistory(), "", 1)
+	if len(limited.MostUsed) != 1 || len(limited.Failing) != 1 {
+		t.Errorf("expected limited stats but got %s", toJSON(limited))
+	}
+
+	other := s.HistoryStats(fakeHistory(), "other", 0)
+	if len(other.MostUsed) != 0 || len(other.NeverUsed) != 0 {
+		t.Errorf("expected no stats for other manifest but got %s", toJSON(other))
+	}
+}
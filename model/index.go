@@ -0,0 +1,138 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pokanop/nostromo/keypath"
+	"github.com/pokanop/nostromo/log"
+)
+
+// indexNode is a single key in the command index
+type indexNode struct {
+	command  *Command
+	manifest *Manifest
+	children map[string]*indexNode
+}
+
+func newIndexNode(cmd *Command, m *Manifest) *indexNode {
+	return &indexNode{
+		command:  cmd,
+		manifest: m,
+		children: map[string]*indexNode{},
+	}
+}
+
+// insert command and its subtree as a child of this node
+func (n *indexNode) insert(cmd *Command, m *Manifest) {
+	// Commands resolve global substitutions from the manifest they're
+	// indexed in
+	cmd.manifest = m
+	child := newIndexNode(cmd, m)
+	n.children[cmd.Alias] = child
+	for _, c := range cmd.Commands {
+		child.insert(c, m)
+	}
+}
+
+// commandIndex is a trie of keys to commands and their manifests
+//
+// The index is built once when linking and makes key path resolution
+// proportional to the key path length instead of the number of commands.
+type commandIndex struct {
+	root      *indexNode
+	revisions map[*Manifest]int
+}
+
+// newCommandIndex for manifests where earlier manifests take precedence
+// for root commands with the same alias
+func newCommandIndex(manifests ...*Manifest) *commandIndex {
+	i := &commandIndex{
+		root:      newIndexNode(nil, nil),
+		revisions: map[*Manifest]int{},
+	}
+	for _, m := range manifests {
+		if m == nil {
+			continue
+		}
+		i.revisions[m] = m.revision
+		for _, cmd := range m.SortedCommands() {
+			if _, exists := i.root.children[cmd.Alias]; exists {
+				continue
+			}
+			i.root.insert(cmd, m)
+		}
+	}
+	return i
+}
+
+// stale returns true if any indexed manifest changed after indexing
+func (i *commandIndex) stale() bool {
+	for m, revision := range i.revisions {
+		if m.revision != revision {
+			return true
+		}
+	}
+	return false
+}
+
+// find the command and manifest at key path or nil if missing
+func (i *commandIndex) find(keyPath string) (*Command, *Manifest) {
+	if len(keyPath) == 0 {
+		return nil, nil
+	}
+
+	// Alias only commands can use the full key path as the alias
+	if n := i.root.children[keyPath]; n != nil {
+		return n.command, n.manifest
+	}
+
+	n := i.root
+	for _, key := range keypath.Keys(keyPath) {
+		if n = n.children[key]; n == nil {
+			return nil, nil
+		}
+	}
+	return n.command, n.manifest
+}
+
+// match the longest key path for keys returning the command, manifest and
+// number of keys matched
+func (i *commandIndex) match(keys []string) (*Command, *Manifest, int) {
+	n := i.root
+	count := 0
+	for _, key := range keys {
+		next := n.children[key]
+		if next == nil {
+			break
+		}
+		n = next
+		count++
+	}
+	return n.command, n.manifest, count
+}
+
+// resolve input to the matching command, its manifest and the remaining
+// arguments or an error if not found
+func (i *commandIndex) resolve(args []string) (*Command, *Manifest, []string, error) {
+	keys := keypath.Keys(keypath.KeyPath(args))
+	c, m, count := i.match(keys)
+	if c == nil || count == 0 {
+		log.Debug("arguments:", args)
+		return nil, nil, nil, fmt.Errorf("unable to execute command '%s'", strings.Join(args, " "))
+	}
+
+	log.Debug("key path:", keypath.KeyPath(keys[:count]))
+	if count > len(args) {
+		count = len(args)
+	}
+	if len(args[count:]) > 0 {
+		log.Debug("arguments:", args[count:])
+	}
+
+	if disabled, n := c.checkDisabled(); disabled {
+		return nil, nil, nil, fmt.Errorf("command is disabled at %s", n.KeyPath)
+	}
+
+	return c, m, args[count:], nil
+}
@@ -0,0 +1,273 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/pokanop/nostromo/keypath"
+	"github.com/pokanop/nostromo/version"
+)
+
+func TestCommandIndexFind(t *testing.T) {
+	m := fakeManifest(2, 3)
+	m.AddCommand("alias.only", "command", "", nil, true, "")
+	i := newCommandIndex(m)
+
+	tests := []struct {
+		name    string
+		keyPath string
+		want    *Command
+	}{
+		{"empty key path", "", nil},
+		{"missing key path", "missing", nil},
+		{"missing nested key path", "0-one-alias.missing", nil},
+		{"root key path", "1-one-alias", m.Commands["1-one-alias"]},
+		{"nested key path", "0-one-alias.0-two-alias.0-three-alias", m.Commands["0-one-alias"].Commands["0-two-alias"].Commands["0-three-alias"]},
+		{"alias only key path", "alias.only", m.Commands["alias.only"]},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotManifest := i.find(test.keyPath)
+			if got != test.want {
+				t.Errorf("expected %v but got %v", test.want, got)
+			}
+			if got != nil && gotManifest != m {
+				t.Errorf("expected manifest %s but got %v", m.Name, gotManifest)
+			}
+		})
+	}
+}
+
+func TestCommandIndexMatch(t *testing.T) {
+	m := fakeManifest(1, 3)
+	i := newCommandIndex(m)
+
+	tests := []struct {
+		name      string
+		args      []string
+		wantAlias string
+		wantCount int
+	}{
+		{"no args", []string{}, "", 0},
+		{"missing", []string{"missing"}, "", 0},
+		{"root", []string{"0-one-alias"}, "0-one-alias", 1},
+		{"root with args", []string{"0-one-alias", "arg"}, "0-one-alias", 1},
+		{"nested with args", []string{"0-one-alias", "0-two-alias", "arg"}, "0-two-alias", 2},
+		{"leaf", []string{"0-one-alias", "0-two-alias", "0-three-alias", "0-four-alias"}, "0-three-alias", 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmd, _, count := i.match(test.args)
+			if count != test.wantCount {
+				t.Errorf("expected count %d but got %d", test.wantCount, count)
+			}
+			if count > 0 && cmd.Alias != test.wantAlias {
+				t.Errorf("expected alias %s but got %s", test.wantAlias, cmd.Alias)
+			}
+		})
+	}
+}
+
+func TestCommandIndexStale(t *testing.T) {
+	m := fakeManifest(1, 1)
+	m.Link()
+	if m.Find("new") != nil {
+		t.Fatalf("expected command to not exist")
+	}
+
+	if _, err := m.AddCommand("new", "command", "", nil, false, ""); err != nil {
+		t.Fatal(err)
+	}
+	if m.Find("new") == nil {
+		t.Errorf("expected index to include new command")
+	}
+
+	if _, err := m.RemoveCommand("new"); err != nil {
+		t.Fatal(err)
+	}
+	if m.Find("new") != nil {
+		t.Errorf("expected index to drop removed command")
+	}
+}
+
+func TestSpaceportFindCommand(t *testing.T) {
+	first := fakeManifest(1, 2)
+	first.Name = "first"
+	second := fakeManifest(2, 3)
+	second.Name = "second"
+	s := NewSpaceport([]*Manifest{first, second})
+	s.Link()
+
+	tests := []struct {
+		name         string
+		keyPath      string
+		wantManifest *Manifest
+		wantNil      bool
+	}{
+		{"missing", "missing", nil, true},
+		{"first manifest takes precedence", "0-one-alias", first, false},
+		{"shadowed subtree", "0-one-alias.0-two-alias.0-three-alias", nil, true},
+		{"second manifest", "1-one-alias.1-two-alias", second, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmd, m := s.FindCommand(test.keyPath)
+			if test.wantNil {
+				if cmd != nil {
+					t.Errorf("expected no command but got %s", cmd)
+				}
+				return
+			}
+			if cmd == nil || m != test.wantManifest {
+				t.Errorf("expected command in %s manifest but got %v", test.wantManifest.Name, m)
+			}
+		})
+	}
+}
+
+func TestSpaceportExecutionString(t *testing.T) {
+	first := fakeManifest(1, 2)
+	first.Name = "first"
+	second := fakeSimilarManifest(3, 3)
+	second.Name = "second"
+	s := NewSpaceport([]*Manifest{first, second})
+	s.Link()
+
+	tests := []struct {
+		name         string
+		args         []string
+		wantErr      bool
+		want         string
+		wantManifest *Manifest
+	}{
+		{"empty args", []string{}, true, "", nil},
+		{"missing", []string{"missing"}, true, "", nil},
+		{"first manifest", []string{"0-one-alias", "0-two-alias", "arg"}, false, "0-one 0-two arg", first},
+		{"second manifest", keypath.Keys("2-one-alias.two-alias.three-alias"), false, "2-one two three", second},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, got, m, err := s.ExecutionString(test.args)
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+			if got != test.want {
+				t.Errorf("expected: %s, actual: %s", test.want, got)
+			}
+			if m != test.wantManifest {
+				t.Errorf("expected manifest %s but got %s", test.wantManifest.Name, m.Name)
+			}
+		})
+	}
+}
+
+func BenchmarkSpaceportFindCommand(b *testing.B) {
+	s := fakeLargeSpaceport()
+	keyPath := "9-0.9.9.9"
+
+	b.Run("scan", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			if cmd, _ := scanFindCommand(s, keyPath); cmd == nil {
+				b.Fatal("command not found")
+			}
+		}
+	})
+
+	b.Run("index", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			if cmd, _ := s.FindCommand(keyPath); cmd == nil {
+				b.Fatal("command not found")
+			}
+		}
+	})
+}
+
+func BenchmarkSpaceportExecutionString(b *testing.B) {
+	s := fakeLargeSpaceport()
+	args := []string{"9-0", "9", "9", "9", "arg"}
+
+	b.Run("scan", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			if _, _, err := scanExecutionString(s, args); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("index", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			if _, _, _, err := s.ExecutionString(args); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// fakeLargeSpaceport with 10 manifests of 10 root commands each having
+// 3 levels of 10 children for a total of 11,110 commands
+func fakeLargeSpaceport() *Spaceport {
+	manifests := []*Manifest{}
+	for i := 0; i < 10; i++ {
+		m := NewManifest(fmt.Sprintf("manifest-%d", i), "", "", &version.Info{})
+		for j := 0; j < 10; j++ {
+			root := newCommand(fmt.Sprintf("root %d", j), fmt.Sprintf("%d-%d", i, j), "", nil, false, "")
+			fakeChildren(root, 10, 3)
+			m.Commands[root.Alias] = root
+		}
+		manifests = append(manifests, m)
+	}
+	s := NewSpaceport(manifests)
+	s.Link()
+	return s
+}
+
+func fakeChildren(cmd *Command, width, depth int) {
+	if depth == 0 {
+		return
+	}
+	for i := 0; i < width; i++ {
+		alias := strconv.Itoa(i)
+		child := newCommand("child "+alias, alias, "", nil, false, "")
+		cmd.addCommand(child)
+		fakeChildren(child, width, depth-1)
+	}
+}
+
+// scanFindCommand is the linear lookup used prior to indexing
+func scanFindCommand(s *Spaceport, keyPath string) (*Command, *Manifest) {
+	for _, m := range s.Manifests() {
+		for _, cmd := range m.Commands {
+			if c := cmd.find(keyPath); c != nil {
+				return c, m
+			}
+		}
+	}
+	return nil, nil
+}
+
+// scanExecutionString is the linear resolution used prior to indexing
+func scanExecutionString(s *Spaceport, args []string) (string, string, error) {
+	for _, m := range s.Manifests() {
+		for _, cmd := range m.Commands {
+			keyPath := cmd.shortestKeyPath(keypath.KeyPath(args))
+			if len(keyPath) > 0 {
+				count := len(keypath.Keys(keyPath))
+				c := cmd.find(keyPath)
+				cmdStr, err := c.executionString(args[count:])
+				return c.Code.Language, cmdStr, err
+			}
+		}
+	}
+	return "", "", fmt.Errorf("unable to execute command")
+}
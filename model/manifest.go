@@ -6,7 +6,6 @@ import (
 	"strings"
 
 	"github.com/pokanop/nostromo/keypath"
-	"github.com/pokanop/nostromo/log"
 	"github.com/pokanop/nostromo/version"
 	"github.com/shivamMg/ppds/tree"
 	"gopkg.in/yaml.v2"
@@ -37,6 +36,19 @@ type Manifest struct {
 	Version  *version.Info       `json:"version"`
 	Config   *Config             `json:"config"`
 	Commands map[string]*Command `json:"commands"`
+	// Templates of reusable command subtrees
+	Templates map[string]*Template `json:"templates,omitempty" yaml:"templates,omitempty"`
+	// Includes of other manifests by relative path or URL
+	Includes []string `json:"includes,omitempty" yaml:"includes,omitempty"`
+	// Global substitutions for all commands tried after command scopes
+	Global map[string]*Substitution `json:"global,omitempty" yaml:"global,omitempty"`
+
+	// included manifests that are merged when linking
+	included []*Manifest
+	// index of the command tree for lookups
+	index *commandIndex
+	// revision is incremented every time the command tree changes
+	revision int
 }
 
 // NewManifest returns a newly initialized manifest
@@ -59,11 +71,81 @@ func (m *Manifest) IsCore() bool {
 // Link a newly loaded manifest
 //
 // This must be run after parsing a manifest to walk the command
-// tree and build links.
-func (m *Manifest) Link() {
-	for _, cmd := range m.Commands {
+// tree and build links. Included manifests and templates are resolved
+// into a concrete command tree.
+func (m *Manifest) Link() error {
+	if m.Commands == nil {
+		m.Commands = map[string]*Command{}
+	}
+
+	// Commands defined in this manifest take precedence over included ones
+	for _, inc := range m.included {
+		if err := inc.Link(); err != nil {
+			return err
+		}
+		for _, cmd := range inc.SortedCommands() {
+			if _, exists := m.Commands[cmd.Alias]; exists {
+				continue
+			}
+			copy := cmd.generate(nil)
+			if copy == nil {
+				return fmt.Errorf("failed to include %s from %s", cmd.KeyPath, inc.Name)
+			}
+			m.Commands[copy.Alias] = copy
+		}
+	}
+
+	templates := m.allTemplates()
+	for _, cmd := range m.SortedCommands() {
+		cmd.link(nil)
+		if err := cmd.resolveTemplates(templates, nil); err != nil {
+			return err
+		}
 		cmd.link(nil)
 	}
+	m.index = newCommandIndex(m)
+
+	return nil
+}
+
+// Include another manifest that is merged when linking
+func (m *Manifest) Include(inc *Manifest) {
+	m.included = append(m.included, inc)
+}
+
+// IncludedManifests that are merged into this manifest
+func (m *Manifest) IncludedManifests() []*Manifest {
+	return m.included
+}
+
+// MarshalJSON omits generated commands
+func (m *Manifest) MarshalJSON() ([]byte, error) {
+	type manifest Manifest
+	mm := manifest(*m)
+	mm.Commands = authoredCommands(m.Commands)
+	return json.Marshal(&mm)
+}
+
+// MarshalYAML omits generated commands
+func (m *Manifest) MarshalYAML() (interface{}, error) {
+	type manifest Manifest
+	mm := manifest(*m)
+	mm.Commands = authoredCommands(m.Commands)
+	return &mm, nil
+}
+
+// allTemplates available to this manifest including from included manifests
+func (m *Manifest) allTemplates() map[string]*Template {
+	templates := map[string]*Template{}
+	for _, inc := range m.included {
+		for name, t := range inc.allTemplates() {
+			templates[name] = t
+		}
+	}
+	for name, t := range m.Templates {
+		templates[name] = t
+	}
+	return templates
 }
 
 // AddCommand tree up to key path
@@ -76,6 +158,7 @@ func (m *Manifest) AddCommand(keyPath, command, description string, code *Code,
 	if aliasOnly {
 		cmd := newCommand(command, keyPath, description, code, true, mode)
 		m.Commands[cmd.Alias] = cmd
+		m.invalidate()
 		return true, nil
 	}
 
@@ -92,6 +175,7 @@ func (m *Manifest) AddCommand(keyPath, command, description string, code *Code,
 
 	// Modify or build the rest of the key path of commands
 	cmd.build(keyPath, command, description, code, aliasOnly, mode)
+	m.invalidate()
 
 	return isRoot, nil
 }
@@ -105,6 +189,7 @@ func (m *Manifest) RemoveCommand(keyPath string) (bool, error) {
 
 	// Track if root command
 	_, isRoot := m.Commands[keyPath]
+	defer m.invalidate()
 
 	parent := cmd.parent
 	if parent == nil {
@@ -134,46 +219,74 @@ func (m *Manifest) RenameCommand(keyPath, name, description string) error {
 		root = p.KeyPath
 	}
 	cmd.updateRootKeyPath(root)
+	m.invalidate()
 
 	return nil
 }
 
 // AddSubstitution with name and alias at key path
 func (m *Manifest) AddSubstitution(keyPath, name, alias string) error {
+	return m.SetSubstitution(keyPath, &Substitution{Name: name, Alias: alias})
+}
+
+// SetSubstitution at key path replacing any with the same alias and
+// position
+func (m *Manifest) SetSubstitution(keyPath string, s *Substitution) error {
 	cmd := m.Find(keyPath)
 	if cmd == nil {
 		return fmt.Errorf("command not found")
 	}
 
-	s := &Substitution{name, alias}
+	if err := s.validate(); err != nil {
+		return err
+	}
 	cmd.addSubstitution(s)
 
 	return nil
 }
 
-// RemoveSubstitution at key path for given alias
+// SetGlobalSubstitution for all commands replacing any with the same alias
+// and position
+func (m *Manifest) SetGlobalSubstitution(s *Substitution) error {
+	if err := s.validate(); err != nil {
+		return err
+	}
+	if m.Global == nil {
+		m.Global = map[string]*Substitution{}
+	}
+	setSubstitution(m.Global, s)
+
+	return nil
+}
+
+// RemoveSubstitution at key path for given alias at any position
 func (m *Manifest) RemoveSubstitution(keyPath, alias string) error {
 	cmd := m.Find(keyPath)
 	if cmd == nil {
 		return fmt.Errorf("command not found")
 	}
 
-	s := &Substitution{"", alias}
+	s := &Substitution{Alias: alias}
 	cmd.removeSubstitution(s)
 
 	return nil
 }
 
-// Find command at key path or nil if missing
-func (m *Manifest) Find(keyPath string) *Command {
-	for _, cmd := range m.Commands {
-		if c := cmd.find(keyPath); c != nil {
-			return c
-		}
+// RemoveGlobalSubstitution for given alias at any position
+func (m *Manifest) RemoveGlobalSubstitution(alias string) error {
+	if !removeSubstitutions(m.Global, alias) {
+		return fmt.Errorf("global substitution not found")
 	}
+
 	return nil
 }
 
+// Find command at key path or nil if missing
+func (m *Manifest) Find(keyPath string) *Command {
+	cmd, _ := m.commandIndex().find(keyPath)
+	return cmd
+}
+
 // AsJSON returns string representation of manifest
 func (m *Manifest) AsJSON() string {
 	b, err := json.MarshalIndent(m, "", "  ")
@@ -194,27 +307,15 @@ func (m *Manifest) AsYAML() string {
 
 // ExecutionString from input if possible or return error
 func (m *Manifest) ExecutionString(args []string) (string, string, error) {
-	for _, cmd := range m.Commands {
-		keyPath := cmd.shortestKeyPath(keypath.KeyPath(args))
-		if len(keyPath) > 0 {
-			count := len(keypath.Keys(keyPath))
-
-			log.Debug("key path:", keyPath)
-			if len(args[count:]) > 0 {
-				log.Debug("arguments:", args[count:])
-			}
-
-			c := cmd.find(keyPath)
-			if disabled, n := c.checkDisabled(); disabled {
-				return "", "", fmt.Errorf("command is disabled at %s", n.KeyPath)
-			}
-			return c.Code.Language, c.executionString(args[count:]), nil
-		}
+	c, _, args, err := m.commandIndex().resolve(args)
+	if err != nil {
+		return "", "", err
 	}
-
-	log.Debug("arguments:", args)
-
-	return "", "", fmt.Errorf("unable to execute command '%s'", strings.Join(args, " "))
+	cmd, err := c.executionString(args)
+	if err != nil {
+		return "", "", err
+	}
+	return c.Code.Language, cmd, nil
 }
 
 // Keys as ordered list of fields for logging
@@ -240,13 +341,20 @@ func (m *Manifest) Data() interface{} {
 // Children method for Node interface to print tree
 func (m *Manifest) Children() []tree.Node {
 	nodes := make([]tree.Node, 0, len(m.Commands))
-	for _, v := range m.Commands {
+	for _, v := range m.SortedCommands() {
 		nodes = append(nodes, v)
 	}
 	return nodes
 }
 
+// SortedCommands at the root ordered by `Order` and then alias
+func (m *Manifest) SortedCommands() []*Command {
+	return sortedCommands(m.Commands)
+}
+
 func (m *Manifest) ImportCommands(cmds []*Command, kp, description string, create bool) error {
+	defer m.invalidate()
+
 	// Check if keypath exists
 	root := m.Find(kp)
 	if root == nil && create && len(kp) > 0 && kp != "." {
@@ -292,6 +400,19 @@ func (m *Manifest) ImportCommands(cmds []*Command, kp, description string, creat
 	return nil
 }
 
+// commandIndex for this manifest which is rebuilt if the tree changed
+func (m *Manifest) commandIndex() *commandIndex {
+	if m.index == nil || m.index.stale() {
+		m.index = newCommandIndex(m)
+	}
+	return m.index
+}
+
+// invalidate indexes after modifying the command tree
+func (m *Manifest) invalidate() {
+	m.revision++
+}
+
 // count of the total number of commands in this manifest
 func (m *Manifest) count() int {
 	count := 0
@@ -303,8 +424,8 @@ func (m *Manifest) count() int {
 
 func joinedCommands(cmdMap map[string]*Command) string {
 	commands := []string{}
-	for cmd := range cmdMap {
-		commands = append(commands, cmd)
+	for _, cmd := range sortedCommands(cmdMap) {
+		commands = append(commands, cmd.Alias)
 	}
 	return strings.Join(commands, ", ")
 }
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// referencePattern to another command like `@{docker.build}` or an escaped
+// `@@{...}` that is kept as a literal `@{...}`
+var referencePattern = regexp.MustCompile(`@?@\{([^{}\s]+)\}`)
+
+// commandFinder looks up a command and its manifest by key path
+type commandFinder func(keyPath string) (*Command, *Manifest)
+
+// resolveReferences in cmd to the execution strings of the commands they
+// reference
+//
+// Referenced commands are resolved without arguments and can reference
+// other commands. Stack holds the key paths being resolved so cycles are
+// reported instead of recursing forever.
+//
+// References to unknown key paths are left as is since shells use the same
+// syntax like `HEAD@{1}` in git.
+func resolveReferences(cmd string, find commandFinder, stack []string, e *Explanation) (string, error) {
+	var err error
+	cmd = referencePattern.ReplaceAllStringFunc(cmd, func(ref string) string {
+		if err != nil {
+			return ref
+		}
+		if strings.HasPrefix(ref, "@@") {
+			return ref[1:]
+		}
+
+		keyPath := referencePattern.FindStringSubmatch(ref)[1]
+		for i, kp := range stack {
+			if kp == keyPath {
+				err = fmt.Errorf("reference cycle %s", strings.Join(append(stack[i:], keyPath), " -> "))
+				return ref
+			}
+		}
+
+		c, m := find(keyPath)
+		if c == nil {
+			return ref
+		}
+		if disabled, n := c.checkDisabled(); disabled {
+			err = fmt.Errorf("referenced command %s is disabled at %s", keyPath, n.KeyPath)
+			return ref
+		}
+		if len(c.Steps) > 0 {
+			err = fmt.Errorf("referenced command %s has steps which cannot be inlined", keyPath)
+			return ref
+		}
+		if l := c.Code.Language; len(l) > 0 && l != "sh" {
+			err = fmt.Errorf("referenced command %s runs %s which cannot be inlined", keyPath, l)
+			return ref
+		}
+
+		var value string
+		if value, err = c.executionString(nil); err != nil {
+			return ref
+		}
+		if value, err = resolveReferences(value, find, append(stack, keyPath), e); err != nil {
+			return ref
+		}
+
+		e.addReference(ref, value, c, m)
+		return value
+	})
+	return cmd, err
+}
+
+// resolveReferences in cmd for the command across all manifests
+func (s *Spaceport) resolveReferences(cmd string, c *Command, e *Explanation) (string, error) {
+	return resolveReferences(cmd, s.FindCommand, []string{c.KeyPath}, e)
+}
+
+// resolveReferences in cmd for the command within this manifest
+func (m *Manifest) resolveReferences(cmd string, c *Command) (string, error) {
+	find := func(keyPath string) (*Command, *Manifest) {
+		return m.Find(keyPath), m
+	}
+	return resolveReferences(cmd, find, []string{c.KeyPath}, nil)
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSpaceportReferences(t *testing.T) {
+	docker := NewManifest("docker", "", "", nil)
+	docker.AddCommand("docker", "docker", "", nil, false, "")
+	docker.AddCommand("docker.build", "build -t ${flag:tag} .", "", nil, false, "")
+	docker.Find("docker.build").Flags = []*Flag{{Name: "tag", Default: "app"}}
+	docker.AddCommand("docker.py", "", "", &Code{Language: "python", Snippet: "print(1)"}, false, "")
+	docker.Link()
+
+	m := NewManifest("manifest", "", "", nil)
+	m.AddCommand("release", "@{docker.build} && push $1", "", nil, false, "")
+	m.AddCommand("nested", "@{release} done", "", nil, false, "")
+	m.AddCommand("code", "", "", &Code{Language: "sh", Snippet: "echo @{docker}"}, false, "")
+	m.AddCommand("cycle", "@{cycle.inner}", "", nil, false, "")
+	m.AddCommand("cycle.inner", "inner", "", nil, false, "")
+	m.AddCommand("missing", "@{nope}", "", nil, false, "")
+	m.AddCommand("gl", "git log", "", nil, false, "")
+	m.AddCommand("upstream", "git log @{u}..", "", nil, false, "")
+	m.AddCommand("escaped", "echo @@{docker}", "", nil, false, "")
+	m.AddCommand("python", "@{docker.py}", "", nil, false, "")
+	m.AddCommand("off", "@{disabled}", "", nil, false, "")
+	m.AddCommand("disabled", "echo off", "", nil, false, "")
+	m.Find("disabled").Disabled = true
+	m.Link()
+	s := NewSpaceport([]*Manifest{m, docker})
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+		wantErr  bool
+	}{
+		{"across manifests", []string{"release", "app"}, "docker build -t app . && push app", false},
+		{"nested", []string{"nested"}, "docker build -t app . && push $1 done", false},
+		{"code", []string{"code"}, "echo docker", false},
+		{"cycle", []string{"cycle"}, "", true},
+		{"missing", []string{"missing"}, "@{nope}", false},
+		{"git reflog argument", []string{"gl", "HEAD@{1}"}, "git log HEAD@{1}", false},
+		{"reference argument", []string{"gl", "@{docker}"}, "git log @{docker}", false},
+		{"git upstream", []string{"upstream"}, "git log @{u}..", false},
+		{"escaped", []string{"escaped"}, "echo @{docker}", false},
+		{"other language", []string{"python"}, "", true},
+		{"disabled", []string{"off"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, actual, _, err := s.ExecutionString(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ExecutionString() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if actual != tt.expected {
+				t.Errorf("expected: %s, actual: %s", tt.expected, actual)
+			}
+		})
+	}
+
+	e, err := s.Explain([]string{"nested"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []*ExplainReference{
+		{"@{docker.build}", "docker build -t app .", "docker.build", "docker"},
+		{"@{release}", "docker build -t app . && push $1", "release", "manifest"},
+	}
+	if !reflect.DeepEqual(e.References, expected) {
+		t.Errorf("expected: %s, actual: %s", toJSON(expected), toJSON(e.References))
+	}
+}
@@ -0,0 +1,238 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pokanop/nostromo/stringutil"
+)
+
+// Searchable fields of commands
+const (
+	KeyPathSearchField     = "keypath"
+	CommandSearchField     = "command"
+	AliasSearchField       = "alias"
+	DescriptionSearchField = "description"
+	CodeSearchField        = "code"
+	SubsSearchField        = "subs"
+)
+
+// SearchFields in the order matches are reported
+var SearchFields = []string{
+	KeyPathSearchField,
+	CommandSearchField,
+	AliasSearchField,
+	DescriptionSearchField,
+	CodeSearchField,
+	SubsSearchField,
+}
+
+// SearchOptions to find commands
+type SearchOptions struct {
+	Query    string
+	Regex    bool
+	Fuzzy    bool
+	Fields   []string
+	Manifest string
+}
+
+// SearchResult for a command with the fields that matched
+type SearchResult struct {
+	Manifest string         `json:"manifest"`
+	KeyPath  string         `json:"keyPath"`
+	Score    int            `json:"score,omitempty"`
+	Matches  []*SearchMatch `json:"matches"`
+}
+
+// SearchMatch in a field with the byte spans of the value that matched
+type SearchMatch struct {
+	Field string  `json:"field"`
+	Value string  `json:"value"`
+	Spans [][]int `json:"spans"`
+}
+
+// matcher returns the spans in s that match and a score
+type matcher func(s string) ([][]int, int, bool)
+
+// Search commands in all manifests or the one named in options
+//
+// Queries match case insensitive substrings unless regex or fuzzy is set.
+// Fuzzy results are ranked by their best matching field.
+func (s *Spaceport) Search(opts SearchOptions) ([]*SearchResult, error) {
+	if opts.Regex && opts.Fuzzy {
+		return nil, fmt.Errorf("cannot search with both regex and fuzzy matching")
+	}
+
+	fields, err := searchFields(opts.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	match, err := newMatcher(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	manifests := s.Manifests()
+	if len(opts.Manifest) > 0 {
+		m := s.FindManifest(opts.Manifest)
+		if m == nil {
+			return nil, fmt.Errorf("manifest %s not found", opts.Manifest)
+		}
+		manifests = []*Manifest{m}
+	}
+
+	results := []*SearchResult{}
+	for _, m := range manifests {
+		for _, cmd := range m.SortedCommands() {
+			cmd.Walk(func(c *Command, stop *bool) {
+				if r := c.search(fields, match); r != nil {
+					r.Manifest = m.Name
+					results = append(results, r)
+				}
+			})
+		}
+	}
+
+	if opts.Fuzzy {
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].Score > results[j].Score
+		})
+	}
+
+	return results, nil
+}
+
+// search fields of the command returning nil if nothing matched
+func (c *Command) search(fields []string, match matcher) *SearchResult {
+	var r *SearchResult
+	addMatch := func(field, value string, spans [][]int, score int) {
+		if r == nil {
+			r = &SearchResult{KeyPath: c.KeyPath, Score: score}
+		} else if score > r.Score {
+			r.Score = score
+		}
+		r.Matches = append(r.Matches, &SearchMatch{field, value, spans})
+	}
+	add := func(field, value string) {
+		if spans, score, ok := match(value); ok {
+			addMatch(field, value, spans, score)
+		}
+	}
+
+	for _, field := range fields {
+		switch field {
+		case KeyPathSearchField:
+			add(field, c.KeyPath)
+		case CommandSearchField:
+			add(field, c.Name)
+		case AliasSearchField:
+			add(field, c.Alias)
+		case DescriptionSearchField:
+			add(field, c.Description)
+		case CodeSearchField:
+			if c.Code != nil {
+				add(field, c.Code.Snippet)
+			}
+		case SubsSearchField:
+			// Aliases and names are matched on their own and reported
+			// together like `alias=name`
+			for _, sub := range sortedSubs(c.Subs) {
+				aliasSpans, aliasScore, aliasOk := match(sub.Alias)
+				nameSpans, nameScore, nameOk := match(sub.Name)
+				if !aliasOk && !nameOk {
+					continue
+				}
+				for _, span := range nameSpans {
+					aliasSpans = append(aliasSpans, []int{span[0] + len(sub.Alias) + 1, span[1] + len(sub.Alias) + 1})
+				}
+				if nameScore > aliasScore {
+					aliasScore = nameScore
+				}
+				addMatch(field, fmt.Sprintf("%s=%s", sub.Alias, sub.Name), aliasSpans, aliasScore)
+			}
+		}
+	}
+	return r
+}
+
+// searchFields validated against known fields, all fields by default
+func searchFields(fields []string) ([]string, error) {
+	if len(fields) == 0 {
+		return SearchFields, nil
+	}
+
+	requested := map[string]bool{}
+	for _, f := range fields {
+		f = strings.ToLower(strings.TrimSpace(f))
+		found := false
+		for _, field := range SearchFields {
+			found = found || field == f
+		}
+		if !found {
+			return nil, fmt.Errorf("invalid search field %s, expected one of %s", f, strings.Join(SearchFields, ", "))
+		}
+		requested[f] = true
+	}
+
+	var valid []string
+	for _, field := range SearchFields {
+		if requested[field] {
+			valid = append(valid, field)
+		}
+	}
+	return valid, nil
+}
+
+func newMatcher(opts SearchOptions) (matcher, error) {
+	if opts.Fuzzy {
+		return func(s string) ([][]int, int, bool) {
+			if len(s) == 0 {
+				return nil, 0, false
+			}
+			score, indexes, ok := stringutil.FuzzyMatch(s, opts.Query)
+			if !ok {
+				return nil, 0, false
+			}
+			return runeSpans(s, indexes), score, true
+		}, nil
+	}
+
+	expr := opts.Query
+	if !opts.Regex {
+		expr = "(?i)" + regexp.QuoteMeta(expr)
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex: %s", err)
+	}
+	return func(s string) ([][]int, int, bool) {
+		if len(s) == 0 {
+			return nil, 0, false
+		}
+		spans := re.FindAllStringIndex(s, -1)
+		return spans, 0, spans != nil
+	}, nil
+}
+
+// runeSpans converts rune indexes in s to byte spans merging adjacent runes
+func runeSpans(s string, indexes []int) [][]int {
+	var spans [][]int
+	offset, next := 0, 0
+	for i, r := range []rune(s) {
+		size := utf8.RuneLen(r)
+		if next < len(indexes) && indexes[next] == i {
+			if n := len(spans); n > 0 && spans[n-1][1] == offset {
+				spans[n-1][1] += size
+			} else {
+				spans = append(spans, []int{offset, offset + size})
+			}
+			next++
+		}
+		offset += size
+	}
+	return spans
+}
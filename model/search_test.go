@@ -0,0 +1,194 @@
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/pokanop/nostromo/version"
+)
+
+func fakeSearchSpaceport() *Spaceport {
+	core := NewManifest(CoreManifestName, "", "", &version.Info{})
+	core.AddCommand("git", "git", "version control", nil, false, "")
+	core.AddCommand("git.checkout", "checkout", "switch branches", nil, false, "")
+	core.AddSubstitution("git.checkout", "origin/main", "om")
+	core.AddCommand("hello", "", "say hi", &Code{Language: "ruby", Snippet: "puts 'hi'"}, false, "")
+
+	other := NewManifest("other", "", "", &version.Info{})
+	other.AddCommand("deploy", "kubectl apply", "ship to Git hosted clusters", nil, false, "")
+
+	return NewSpaceport([]*Manifest{core, other})
+}
+
+func TestSpaceportSearch(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     SearchOptions
+		expected []*SearchResult
+		err      bool
+	}{
+		{
+			"substring",
+			SearchOptions{Query: "GIT"},
+			[]*SearchResult{
+				{CoreManifestName, "git", 0, []*SearchMatch{
+					{KeyPathSearchField, "git", [][]int{{0, 3}}},
+					{CommandSearchField, "git", [][]int{{0, 3}}},
+					{AliasSearchField, "git", [][]int{{0, 3}}},
+				}},
+				{CoreManifestName, "git.checkout", 0, []*SearchMatch{
+					{KeyPathSearchField, "git.checkout", [][]int{{0, 3}}},
+				}},
+				{"other", "deploy", 0, []*SearchMatch{
+					{DescriptionSearchField, "ship to Git hosted clusters", [][]int{{8, 11}}},
+				}},
+			},
+			false,
+		},
+		{
+			"in fields",
+			SearchOptions{Query: "git", Fields: []string{"description", " Alias"}},
+			[]*SearchResult{
+				{CoreManifestName, "git", 0, []*SearchMatch{
+					{AliasSearchField, "git", [][]int{{0, 3}}},
+				}},
+				{"other", "deploy", 0, []*SearchMatch{
+					{DescriptionSearchField, "ship to Git hosted clusters", [][]int{{8, 11}}},
+				}},
+			},
+			false,
+		},
+		{
+			"manifest",
+			SearchOptions{Query: "git", Manifest: "other"},
+			[]*SearchResult{
+				{"other", "deploy", 0, []*SearchMatch{
+					{DescriptionSearchField, "ship to Git hosted clusters", [][]int{{8, 11}}},
+				}},
+			},
+			false,
+		},
+		{
+			"regex",
+			SearchOptions{Query: `^s\w+`, Regex: true, Fields: []string{"description"}},
+			[]*SearchResult{
+				{CoreManifestName, "git.checkout", 0, []*SearchMatch{
+					{DescriptionSearchField, "switch branches", [][]int{{0, 6}}},
+				}},
+				{CoreManifestName, "hello", 0, []*SearchMatch{
+					{DescriptionSearchField, "say hi", [][]int{{0, 3}}},
+				}},
+				{"other", "deploy", 0, []*SearchMatch{
+					{DescriptionSearchField, "ship to Git hosted clusters", [][]int{{0, 4}}},
+				}},
+			},
+			false,
+		},
+		{
+			"code",
+			SearchOptions{Query: "puts", Fields: []string{"code"}},
+			[]*SearchResult{
+				{CoreManifestName, "hello", 0, []*SearchMatch{
+					{CodeSearchField, "puts 'hi'", [][]int{{0, 4}}},
+				}},
+			},
+			false,
+		},
+		{
+			"subs",
+			SearchOptions{Query: "main", Fields: []string{"subs"}},
+			[]*SearchResult{
+				{CoreManifestName, "git.checkout", 0, []*SearchMatch{
+					{SubsSearchField, "om=origin/main", [][]int{{10, 14}}},
+				}},
+			},
+			false,
+		},
+		{
+			"subs anchored",
+			SearchOptions{Query: "^(om|origin)", Regex: true, Fields: []string{"subs"}},
+			[]*SearchResult{
+				{CoreManifestName, "git.checkout", 0, []*SearchMatch{
+					{SubsSearchField, "om=origin/main", [][]int{{0, 2}, {3, 9}}},
+				}},
+			},
+			false,
+		},
+		{"no matches", SearchOptions{Query: "zzz"}, []*SearchResult{}, false},
+		{"invalid field", SearchOptions{Query: "git", Fields: []string{"body"}}, nil, true},
+		{"invalid regex", SearchOptions{Query: "(", Regex: true}, nil, true},
+		{"regex and fuzzy", SearchOptions{Query: "git", Regex: true, Fuzzy: true}, nil, true},
+		{"missing manifest", SearchOptions{Query: "git", Manifest: "missing"}, nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := fakeSearchSpaceport().Search(test.opts)
+			if test.err {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("expected: %s, actual: %s", toJSON(test.expected), toJSON(actual))
+			}
+		})
+	}
+}
+
+func TestSpaceportSearchFuzzy(t *testing.T) {
+	results, err := fakeSearchSpaceport().Search(SearchOptions{Query: "gco", Fuzzy: true, Fields: []string{"keypath"}})
+	if err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+	if len(results) != 1 || results[0].KeyPath != "git.checkout" {
+		t.Fatalf("expected git.checkout to match but got %s", toJSON(results))
+	}
+	if spans := results[0].Matches[0].Spans; !reflect.DeepEqual(spans, [][]int{{0, 1}, {4, 5}, {9, 10}}) {
+		t.Errorf("expected spans for matched runes but got %v", spans)
+	}
+
+	results, err = fakeSearchSpaceport().Search(SearchOptions{Query: "h", Fuzzy: true})
+	if err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+	if len(results) < 2 || results[0].KeyPath != "hello" {
+		t.Errorf("expected hello to rank first but got %s", toJSON(results))
+	}
+	for i := 1; i < len(results); i++ {
+		if results[i].Score > results[i-1].Score {
+			t.Errorf("expected results ranked by score but got %s", toJSON(results))
+		}
+	}
+}
+
+func TestRuneSpans(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		indexes  []int
+		expected [][]int
+	}{
+		{"empty", "abc", nil, nil},
+		{"adjacent", "abcd", []int{1, 2}, [][]int{{1, 3}}},
+		{"separate", "abcd", []int{0, 3}, [][]int{{0, 1}, {3, 4}}},
+		{"multibyte", "éa", []int{0, 1}, [][]int{{0, 3}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := runeSpans(test.s, test.indexes); !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("expected %v but got %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func toJSON(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"fmt"
+
 	"github.com/pokanop/nostromo/log"
 	"github.com/pokanop/nostromo/version"
 )
@@ -8,12 +10,20 @@ import (
 // Spaceport type that manages and docks multiple ships' manifests
 type Spaceport struct {
 	manifests map[string]*Manifest
+	index     *commandIndex
 	Sequence  []string      `json:"sequence"`
 	Theme     log.ThemeType `json:"themeType"`
+	// ConfirmSources of docked manifests whose commands always need
+	// confirmation before running
+	ConfirmSources []string `json:"confirmSources,omitempty" yaml:"confirmSources,omitempty"`
 }
 
 func NewSpaceport(manifests []*Manifest) *Spaceport {
-	s := &Spaceport{map[string]*Manifest{}, []string{}, log.EmojiTheme}
+	s := &Spaceport{
+		manifests: map[string]*Manifest{},
+		Sequence:  []string{},
+		Theme:     log.EmojiTheme,
+	}
 	s.Import(manifests)
 	return s
 }
@@ -41,36 +51,68 @@ func (s *Spaceport) Manifests() []*Manifest {
 func (s *Spaceport) Commands() []*Command {
 	cmds := []*Command{}
 	for _, m := range s.Manifests() {
-		for _, c := range m.Commands {
-			cmds = append(cmds, c)
-		}
+		cmds = append(cmds, m.SortedCommands()...)
 	}
 	return cmds
 }
 
 func (s *Spaceport) Import(manifests []*Manifest) {
+	s.index = nil
+	s.manifests = map[string]*Manifest{}
 	s.Sequence = []string{}
 	for _, m := range manifests {
 		s.AddManifest(m)
-		s.Sequence = append(s.Sequence, m.Name)
 	}
 }
 
-func (s *Spaceport) Link() {
-	for _, m := range s.manifests {
-		m.Link()
+// Link all manifests and build the command index
+func (s *Spaceport) Link() error {
+	for _, m := range s.Manifests() {
+		if m == nil {
+			continue
+		}
+		if err := m.Link(); err != nil {
+			return fmt.Errorf("%s manifest: %s", m.Name, err)
+		}
 	}
+	s.index = newCommandIndex(s.Manifests()...)
+	return nil
+}
+
+// Layer a manifest on top of all others for this session
+//
+// The manifest takes precedence over docked manifests when resolving
+// commands. Layered manifests are meant to be discarded and never saved.
+func (s *Spaceport) Layer(m *Manifest) error {
+	if !s.IsUnique(m.Name) {
+		return fmt.Errorf("manifest named %s already exists", m.Name)
+	}
+	if err := m.Link(); err != nil {
+		return fmt.Errorf("%s manifest: %s", m.Name, err)
+	}
+
+	s.index = nil
+	s.manifests[m.Name] = m
+	s.Sequence = append([]string{m.Name}, s.Sequence...)
+	return nil
 }
 
 func (s *Spaceport) CoreManifest() *Manifest {
 	return s.manifests[CoreManifestName]
 }
 
+// AddManifest or replace an existing one with the same name, new manifests
+// are sequenced last
 func (s *Spaceport) AddManifest(m *Manifest) {
+	s.index = nil
+	if s.manifests[m.Name] == nil {
+		s.Sequence = append(s.Sequence, m.Name)
+	}
 	s.manifests[m.Name] = m
 }
 
 func (s *Spaceport) RemoveManifest(name string) bool {
+	s.index = nil
 	s.manifests[name] = nil
 	index := -1
 	for i, n := range s.Sequence {
@@ -100,11 +142,46 @@ func (s *Spaceport) UpdateVersion(ver *version.Info) {
 	}
 }
 
+// FindCommand at key path and the manifest it belongs to or nil if missing
 func (s *Spaceport) FindCommand(name string) (*Command, *Manifest) {
-	for _, m := range s.manifests {
-		if cmd := m.Find(name); cmd != nil {
-			return cmd, m
-		}
+	return s.commandIndex().find(name)
+}
+
+// SecretLookup for the value of a secret by name
+type SecretLookup func(name string) (string, error)
+
+// ExecutionString from input if possible or return error
+//
+// Returns the code language, command string and the manifest the command
+// was resolved from. Manifests earlier in the sequence take precedence.
+func (s *Spaceport) ExecutionString(args []string) (string, string, *Manifest, error) {
+	return s.ResolvedExecutionString(args, nil)
+}
+
+// ResolvedExecutionString like ExecutionString with secrets referenced by
+// commands resolved with lookup if not nil
+func (s *Spaceport) ResolvedExecutionString(args []string, lookup SecretLookup) (string, string, *Manifest, error) {
+	c, m, args, err := s.commandIndex().resolve(args)
+	if err != nil {
+		return "", "", nil, err
+	}
+	cmd, err := c.trace(args, s.FindCommand, lookup, nil)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return c.Code.Language, cmd, m, nil
+}
+
+// Resolve the command at args returning its manifest and the remaining
+// arguments
+func (s *Spaceport) Resolve(args []string) (*Command, *Manifest, []string, error) {
+	return s.commandIndex().resolve(args)
+}
+
+// commandIndex for all manifests which is rebuilt if any tree changed
+func (s *Spaceport) commandIndex() *commandIndex {
+	if s.index == nil || s.index.stale() {
+		s.index = newCommandIndex(s.Manifests()...)
 	}
-	return nil, nil
+	return s.index
 }
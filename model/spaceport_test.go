@@ -0,0 +1,73 @@
+package model
+
+import "testing"
+
+func TestSpaceportLayer(t *testing.T) {
+	docked := fakeManifest(1, 2)
+	docked.Name = "docked"
+	s := NewSpaceport([]*Manifest{docked})
+	s.Link()
+
+	project := fakeSimilarManifest(1, 1)
+	project.Name = "project"
+	if err := s.Layer(project); err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+
+	if s.Manifests()[0] != project {
+		t.Errorf("expected layered manifest first but got %s", s.Manifests()[0].Name)
+	}
+
+	cmd, m := s.FindCommand("0-one-alias")
+	if cmd == nil || m != project {
+		t.Errorf("expected layered manifest to take precedence but got %v", m)
+	}
+
+	duplicate := fakeManifest(1, 1)
+	duplicate.Name = "docked"
+	if err := s.Layer(duplicate); err == nil {
+		t.Errorf("expected error for duplicate manifest name but got none")
+	}
+	if s.Manifests()[0] != project || len(s.Manifests()) != 2 {
+		t.Errorf("expected failed layer to leave manifests unchanged")
+	}
+}
+
+func TestSpaceportResolvedSecrets(t *testing.T) {
+	m := NewManifest("manifest", "", "", nil)
+	m.AddCommand("api", "curl -H 'token ${secret:token}'", "", nil, false, "")
+	m.AddCommand("ci", "", "", nil, false, "")
+	m.Find("ci").Steps = []*Step{{Run: "login ${secret:token} $1"}, {KeyPath: "api", Args: []string{"$1"}}}
+	m.Link()
+	s := NewSpaceport([]*Manifest{m})
+
+	secrets := map[string]string{"token": "it's $1", "other": "leaked"}
+	lookup := func(name string) (string, error) {
+		return secrets[name], nil
+	}
+
+	// References in arguments are never resolved and values are never
+	// substituted with arguments
+	_, actual, _, err := s.ResolvedExecutionString([]string{"api", "${secret:other}"}, lookup)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if expected := `curl -H 'token it'\''s $1' ${secret:other}`; actual != expected {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+
+	w, _, err := s.ResolvedWorkflow([]string{"ci", "${secret:other}"}, lookup)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := []string{`login 'it'\''s $1' ${secret:other}`, `curl -H 'token it'\''s $1' ${secret:other}`}
+	for i, step := range w.Steps {
+		if step.Command != expected[i] {
+			t.Errorf("expected: %s, actual: %s", expected[i], step.Command)
+		}
+	}
+
+	if _, actual, _, _ = s.ExecutionString([]string{"api"}); actual != "curl -H 'token ${secret:token}'" {
+		t.Errorf("expected secret reference without lookup, actual: %s", actual)
+	}
+}
@@ -0,0 +1,276 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pokanop/nostromo/secret"
+)
+
+// Failure policies for steps
+const (
+	// StopStepPolicy stops running steps after one fails like `&&`
+	StopStepPolicy = "stop"
+	// ContinueStepPolicy runs all steps and fails if any step failed
+	ContinueStepPolicy = "continue"
+	// RetryStepPolicy retries failed steps with backoff before stopping
+	RetryStepPolicy = "retry"
+)
+
+// StepPolicies that can be used for steps
+var StepPolicies = []string{StopStepPolicy, ContinueStepPolicy, RetryStepPolicy}
+
+// Defaults for retrying failed steps
+const (
+	DefaultStepRetries = 3
+	DefaultStepBackoff = time.Second
+)
+
+// Step in a workflow that runs another command by key path or a raw command
+//
+// Positional parameters like `$1` in args and raw commands are replaced with
+// arguments passed to the command with the steps.
+type Step struct {
+	KeyPath string   `json:"keyPath,omitempty" yaml:"keyPath,omitempty"`
+	Args    []string `json:"args,omitempty" yaml:"args,omitempty"`
+	Run     string   `json:"run,omitempty" yaml:"run,omitempty"`
+}
+
+// StepPolicy for how steps run and what happens when one fails
+//
+// Backoff is the delay before the first retry which doubles after each
+// retry. Parallel steps run in batches of concurrency steps or all at once
+// if concurrency isn't set.
+type StepPolicy struct {
+	OnFailure   string `json:"onFailure,omitempty" yaml:"onFailure,omitempty"`
+	Retries     int    `json:"retries,omitempty" yaml:"retries,omitempty"`
+	Backoff     string `json:"backoff,omitempty" yaml:"backoff,omitempty"`
+	Parallel    bool   `json:"parallel,omitempty" yaml:"parallel,omitempty"`
+	Concurrency int    `json:"concurrency,omitempty" yaml:"concurrency,omitempty"`
+}
+
+// Workflow with steps resolved to commands and their policy
+type Workflow struct {
+	KeyPath     string
+	Steps       []*WorkflowStep
+	OnFailure   string
+	Retries     int
+	Backoff     time.Duration
+	Parallel    bool
+	Concurrency int
+}
+
+// WorkflowStep resolved to a command and the language it runs in
+type WorkflowStep struct {
+	Name     string `json:"name"`
+	Language string `json:"language,omitempty"`
+	Command  string `json:"command"`
+}
+
+// String describing how steps run like `retry 3 times, parallel`
+func (w *Workflow) String() string {
+	s := w.OnFailure
+	if w.OnFailure == RetryStepPolicy {
+		s = fmt.Sprintf("retry %d times with %s backoff", w.Retries, w.Backoff)
+	}
+	if w.Parallel {
+		s += ", parallel"
+		if w.Concurrency > 0 {
+			s += fmt.Sprintf(" %d at a time", w.Concurrency)
+		}
+	} else {
+		s += ", sequential"
+	}
+	return s
+}
+
+// Delays before each retry doubling the backoff each time
+func (w *Workflow) Delays() []time.Duration {
+	if w.OnFailure != RetryStepPolicy {
+		return nil
+	}
+
+	var delays []time.Duration
+	d := w.Backoff
+	for i := 0; i < w.Retries; i++ {
+		delays = append(delays, d)
+		d *= 2
+	}
+	return delays
+}
+
+// Batches of step indexes that run together, one step per batch unless
+// steps are parallel
+func (w *Workflow) Batches() [][]int {
+	size := 1
+	if w.Parallel {
+		size = w.Concurrency
+		if size <= 0 {
+			size = len(w.Steps)
+		}
+	}
+
+	var batches [][]int
+	for i := range w.Steps {
+		if i%size == 0 {
+			batches = append(batches, nil)
+		}
+		batches[len(batches)-1] = append(batches[len(batches)-1], i)
+	}
+	return batches
+}
+
+// Workflow for the command at args if it has steps or nil otherwise
+//
+// Steps referencing other commands are resolved like running them and
+// arguments are substituted before replacing positional parameters in steps.
+func (s *Spaceport) Workflow(args []string) (*Workflow, *Manifest, error) {
+	return s.ResolvedWorkflow(args, nil)
+}
+
+// ResolvedWorkflow like Workflow with secrets referenced by steps resolved
+// with lookup if not nil
+func (s *Spaceport) ResolvedWorkflow(args []string, lookup SecretLookup) (*Workflow, *Manifest, error) {
+	c, m, rest, err := s.commandIndex().resolve(args)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(c.Steps) == 0 {
+		return nil, m, nil
+	}
+
+	w, err := c.Policy.workflow(c.KeyPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	subs := c.substituteArgs(rest, nil)
+	used := make([]bool, len(subs))
+	replace := func(s string) string {
+		return positionalParam.ReplaceAllStringFunc(s, func(param string) string {
+			i, _ := strconv.Atoi(param[1:])
+			if i < 1 || i > len(subs) {
+				return param
+			}
+			used[i-1] = true
+			return subs[i-1]
+		})
+	}
+
+	for i, step := range c.Steps {
+		ws, err := s.resolveStep(c, step, replace, lookup)
+		if err != nil {
+			return nil, nil, fmt.Errorf("step %d of %s: %s", i+1, c.KeyPath, err)
+		}
+		w.Steps = append(w.Steps, ws)
+	}
+
+	for i, u := range used {
+		if !u {
+			return nil, nil, fmt.Errorf("steps of %s do not use argument %d '%s'", c.KeyPath, i+1, subs[i])
+		}
+	}
+
+	return w, m, nil
+}
+
+// resolveStep to the command it runs with placeholders replaced
+func (s *Spaceport) resolveStep(c *Command, step *Step, replace func(string) string, lookup SecretLookup) (*WorkflowStep, error) {
+	if (len(step.KeyPath) > 0) == (len(step.Run) > 0) {
+		return nil, fmt.Errorf("steps need either a key path or a command to run")
+	}
+
+	if len(step.Run) > 0 {
+		cmd, err := s.resolveReferences(step.Run, c, nil)
+		if err != nil {
+			return nil, err
+		}
+		if lookup == nil {
+			return &WorkflowStep{Name: step.Run, Command: replace(cmd)}, nil
+		}
+		if cmd, err = secret.ExpandShell(replace(secret.Mark(cmd)), lookup); err != nil {
+			return nil, err
+		}
+		return &WorkflowStep{Name: step.Run, Command: cmd}, nil
+	}
+
+	sc, _ := s.FindCommand(step.KeyPath)
+	if sc == nil {
+		return nil, fmt.Errorf("key path %s not found", step.KeyPath)
+	}
+	if sc == c || len(sc.Steps) > 0 {
+		return nil, fmt.Errorf("key path %s has steps which cannot be nested", step.KeyPath)
+	}
+	if disabled, n := sc.checkDisabled(); disabled {
+		return nil, fmt.Errorf("command is disabled at %s", n.KeyPath)
+	}
+
+	var args []string
+	for _, arg := range step.Args {
+		args = append(args, replace(arg))
+	}
+	cmd, err := sc.trace(args, s.FindCommand, lookup, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(cmd) == 0 {
+		return nil, fmt.Errorf("key path %s has no command to run", step.KeyPath)
+	}
+
+	name := strings.TrimSpace(strings.Join(append([]string{step.KeyPath}, args...), " "))
+	return &WorkflowStep{Name: name, Language: sc.Code.Language, Command: cmd}, nil
+}
+
+// workflow for the policy with defaults applied
+func (p *StepPolicy) workflow(keyPath string) (*Workflow, error) {
+	w := &Workflow{KeyPath: keyPath, OnFailure: StopStepPolicy}
+	if p == nil {
+		return w, nil
+	}
+
+	if len(p.OnFailure) > 0 {
+		w.OnFailure = strings.ToLower(p.OnFailure)
+		valid := false
+		for _, policy := range StepPolicies {
+			valid = valid || policy == w.OnFailure
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid step policy %s, expected one of %s", p.OnFailure, strings.Join(StepPolicies, ", "))
+		}
+	}
+	if p.Retries < 0 || p.Concurrency < 0 {
+		return nil, fmt.Errorf("step retries and concurrency cannot be negative")
+	}
+
+	w.Parallel = p.Parallel
+	w.Concurrency = p.Concurrency
+	if w.OnFailure == RetryStepPolicy {
+		w.Retries = p.Retries
+		if w.Retries == 0 {
+			w.Retries = DefaultStepRetries
+		}
+		w.Backoff = DefaultStepBackoff
+		if len(p.Backoff) > 0 {
+			d, err := time.ParseDuration(p.Backoff)
+			if err != nil || d < 0 {
+				return nil, fmt.Errorf("invalid step backoff %s", p.Backoff)
+			}
+			w.Backoff = d
+		}
+	}
+	return w, nil
+}
+
+func joinedSteps(steps []*Step) string {
+	names := []string{}
+	for _, step := range steps {
+		if len(step.KeyPath) > 0 {
+			names = append(names, step.KeyPath)
+		} else {
+			names = append(names, step.Run)
+		}
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,140 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func fakeStepSpaceport() *Spaceport {
+	m := NewManifest("manifest", "", "", nil)
+	m.AddCommand("build", "go build", "", nil, false, "")
+	m.AddCommand("build.ios", "--target ios", "", nil, false, "")
+	m.AddCommand("ci", "", "", nil, false, "")
+	m.AddCommand("ci.retry", "", "", nil, false, "")
+	m.AddCommand("ci.nested", "", "", nil, false, "")
+	m.AddCommand("ci.bad", "", "", nil, false, "")
+	m.AddCommand("ci.many", "", "", nil, false, "")
+	m.AddSubstitution("ci", "production", "prod")
+	ci := m.Find("ci")
+	ci.Steps = []*Step{
+		{KeyPath: "build.ios", Args: []string{"$1"}},
+		{Run: "deploy $2"},
+	}
+	m.Find("ci.retry").Steps = []*Step{{Run: "flaky"}}
+	m.Find("ci.retry").Policy = &StepPolicy{OnFailure: "retry", Backoff: "500ms", Parallel: true, Concurrency: 2}
+	m.Find("ci.nested").Steps = []*Step{{KeyPath: "ci"}}
+	m.Find("ci.bad").Steps = []*Step{{Run: "echo", KeyPath: "build"}}
+	m.Find("ci.many").Steps = []*Step{{Run: "echo $10 $1 $11 $2 $3 $4 $5 $6 $7 $8 $9"}}
+	m.Link()
+	return NewSpaceport([]*Manifest{m})
+}
+
+func TestSpaceportWorkflow(t *testing.T) {
+	s := fakeStepSpaceport()
+	tests := []struct {
+		name     string
+		args     []string
+		expected *Workflow
+		wantErr  bool
+	}{
+		{"no steps", []string{"build"}, nil, false},
+		{"steps", []string{"ci", "-v", "prod"}, &Workflow{
+			KeyPath:   "ci",
+			OnFailure: StopStepPolicy,
+			Steps: []*WorkflowStep{
+				{Name: "build.ios -v", Command: "go build --target ios -v"},
+				{Name: "deploy $2", Command: "deploy production"},
+			},
+		}, false},
+		{"retry", []string{"ci", "retry"}, &Workflow{
+			KeyPath:     "ci.retry",
+			OnFailure:   RetryStepPolicy,
+			Retries:     DefaultStepRetries,
+			Backoff:     500 * time.Millisecond,
+			Parallel:    true,
+			Concurrency: 2,
+			Steps:       []*WorkflowStep{{Name: "flaky", Command: "flaky"}},
+		}, false},
+		{"ten or more arguments", []string{"ci", "many", "a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"}, &Workflow{
+			KeyPath:   "ci.many",
+			OnFailure: StopStepPolicy,
+			Steps:     []*WorkflowStep{{Name: "echo $10 $1 $11 $2 $3 $4 $5 $6 $7 $8 $9", Command: "echo j a k b c d e f g h i"}},
+		}, false},
+		{"missing tenth argument", []string{"ci", "many", "a", "b", "c", "d", "e", "f", "g", "h", "i"}, &Workflow{
+			KeyPath:   "ci.many",
+			OnFailure: StopStepPolicy,
+			Steps:     []*WorkflowStep{{Name: "echo $10 $1 $11 $2 $3 $4 $5 $6 $7 $8 $9", Command: "echo $10 a $11 b c d e f g h i"}},
+		}, false},
+		{"unused argument", []string{"ci", "retry", "extra"}, nil, true},
+		{"nested steps", []string{"ci", "nested"}, nil, true},
+		{"invalid step", []string{"ci", "bad"}, nil, true},
+		{"missing command", []string{"nope"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, _, err := s.Workflow(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Workflow() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(w, tt.expected) {
+				t.Errorf("expected: %s, actual: %s", toJSON(tt.expected), toJSON(w))
+			}
+		})
+	}
+}
+
+func TestStepPolicyWorkflow(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   *StepPolicy
+		expected *Workflow
+		wantErr  bool
+	}{
+		{"default", nil, &Workflow{OnFailure: StopStepPolicy}, false},
+		{"continue", &StepPolicy{OnFailure: "Continue"}, &Workflow{OnFailure: ContinueStepPolicy}, false},
+		{"retry", &StepPolicy{OnFailure: "retry", Retries: 2}, &Workflow{OnFailure: RetryStepPolicy, Retries: 2, Backoff: DefaultStepBackoff}, false},
+		{"retries ignored", &StepPolicy{Retries: 2, Backoff: "1s"}, &Workflow{OnFailure: StopStepPolicy}, false},
+		{"invalid policy", &StepPolicy{OnFailure: "stop,continue"}, nil, true},
+		{"invalid backoff", &StepPolicy{OnFailure: "retry", Backoff: "soon"}, nil, true},
+		{"negative concurrency", &StepPolicy{Concurrency: -1}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := tt.policy.workflow("")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("workflow() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(w, tt.expected) {
+				t.Errorf("expected: %s, actual: %s", toJSON(tt.expected), toJSON(w))
+			}
+		})
+	}
+}
+
+func TestWorkflowBatches(t *testing.T) {
+	steps := []*WorkflowStep{{}, {}, {}}
+	tests := []struct {
+		name     string
+		workflow *Workflow
+		expected [][]int
+		delays   []time.Duration
+	}{
+		{"sequential", &Workflow{Steps: steps, OnFailure: StopStepPolicy}, [][]int{{0}, {1}, {2}}, nil},
+		{"parallel", &Workflow{Steps: steps, Parallel: true}, [][]int{{0, 1, 2}}, nil},
+		{"concurrency", &Workflow{Steps: steps, Parallel: true, Concurrency: 2}, [][]int{{0, 1}, {2}}, nil},
+		{"retry", &Workflow{Steps: steps, OnFailure: RetryStepPolicy, Retries: 3, Backoff: time.Second}, [][]int{{0}, {1}, {2}}, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := tt.workflow.Batches(); !reflect.DeepEqual(actual, tt.expected) {
+				t.Errorf("expected batches: %v, actual: %v", tt.expected, actual)
+			}
+			if actual := tt.workflow.Delays(); !reflect.DeepEqual(actual, tt.delays) {
+				t.Errorf("expected delays: %v, actual: %v", tt.delays, actual)
+			}
+		})
+	}
+}
@@ -1,7 +1,200 @@
 package model
 
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Supported ways a substitution alias matches arguments
+const (
+	ExactMatch = "exact"
+	RegexMatch = "regex"
+	GlobMatch  = "glob"
+)
+
+// GlobalScope is the scope reported for manifest wide substitutions
+const GlobalScope = "global"
+
+// captureGroupPattern for references like `$1` or `${1}` in substituted values
+var captureGroupPattern = regexp.MustCompile(`\$(\d+)|\$\{(\d+)\}`)
+
 // Substitution at a given scope for altering arguments
+//
+// The alias matches arguments exactly unless a regex or glob match is set in
+// which case the name can reference capture groups like `$1`. Glob wildcards
+// are capture groups in the order they appear.
 type Substitution struct {
 	Name  string
 	Alias string
+	// Match type of the alias which is exact by default
+	Match string `json:"match,omitempty" yaml:"match,omitempty"`
+	// Position restricts the substitution to the argument at this position
+	// starting from 1 or any position if 0
+	Position int `json:"position,omitempty" yaml:"position,omitempty"`
+	// Split the substituted value on whitespace into several arguments
+	Split bool `json:"split,omitempty" yaml:"split,omitempty"`
+}
+
+// SupportedMatches for substitution aliases
+func SupportedMatches() []string {
+	return []string{ExactMatch, RegexMatch, GlobMatch}
+}
+
+// validate the match type, alias pattern and position
+func (s *Substitution) validate() error {
+	if len(s.Alias) == 0 {
+		return fmt.Errorf("substitution alias is required")
+	}
+	if s.Position < 0 {
+		return fmt.Errorf("invalid substitution position %d", s.Position)
+	}
+	switch s.Match {
+	case "", ExactMatch:
+		return nil
+	case RegexMatch, GlobMatch:
+		_, err := s.pattern()
+		return err
+	}
+	return fmt.Errorf("invalid substitution match %s, expected one of %s", s.Match, strings.Join(SupportedMatches(), ", "))
+}
+
+// key of the substitution in a scope so the same alias can be restricted
+// to different positions
+func (s *Substitution) key() string {
+	if s.Position == 0 {
+		return s.Alias
+	}
+	return fmt.Sprintf("%s@%d", s.Alias, s.Position)
+}
+
+// setSubstitution in subs replacing any with the same alias and position
+func setSubstitution(subs map[string]*Substitution, sub *Substitution) {
+	for key, s := range subs {
+		if s.Alias == sub.Alias && s.Position == sub.Position {
+			delete(subs, key)
+		}
+	}
+	subs[sub.key()] = sub
+}
+
+// removeSubstitutions in subs with alias at any position returning false
+// if there were none
+func removeSubstitutions(subs map[string]*Substitution, alias string) bool {
+	removed := false
+	for key, s := range subs {
+		if s.Alias == alias {
+			delete(subs, key)
+			removed = true
+		}
+	}
+	return removed
+}
+
+// isPattern returns true if the alias is a regex or glob
+func (s *Substitution) isPattern() bool {
+	return s.Match == RegexMatch || s.Match == GlobMatch
+}
+
+// pattern compiled from the alias anchored to match whole arguments
+func (s *Substitution) pattern() (*regexp.Regexp, error) {
+	expr := s.Alias
+	if s.Match == GlobMatch {
+		expr = globExpr(s.Alias)
+	}
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid substitution pattern %s: %s", s.Alias, err)
+	}
+	return re, nil
+}
+
+// apply the substitution to arg at position returning the values it's
+// replaced with or false if it doesn't match
+func (s *Substitution) apply(arg string, position int) ([]string, bool) {
+	if s.Position > 0 && s.Position != position {
+		return nil, false
+	}
+
+	value := s.Name
+	if s.isPattern() {
+		re, err := s.pattern()
+		if err != nil {
+			return nil, false
+		}
+		groups := re.FindStringSubmatch(arg)
+		if groups == nil {
+			return nil, false
+		}
+		value = expandCaptureGroups(s.Name, groups)
+	} else if s.Alias != arg {
+		return nil, false
+	}
+
+	if s.Split {
+		return strings.Fields(value), true
+	}
+	return []string{value}, true
+}
+
+// expandCaptureGroups referenced in value leaving anything else as is so
+// shell variables aren't touched
+func expandCaptureGroups(value string, groups []string) string {
+	return captureGroupPattern.ReplaceAllStringFunc(value, func(ref string) string {
+		m := captureGroupPattern.FindStringSubmatch(ref)
+		n, _ := strconv.Atoi(m[1] + m[2])
+		if n >= len(groups) {
+			return ref
+		}
+		return groups[n]
+	})
+}
+
+// globExpr converts a glob to a regular expression with `*` and `?` as
+// capture groups
+func globExpr(glob string) string {
+	var b strings.Builder
+	for _, r := range glob {
+		switch r {
+		case '*':
+			b.WriteString("(.*)")
+		case '?':
+			b.WriteString("(.)")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return b.String()
+}
+
+// subScope of substitutions with the key path it's reported as
+type subScope struct {
+	name string
+	subs map[string]*Substitution
+}
+
+// substitutionOrder at a single scope where exact aliases are tried before
+// patterns and substitutions restricted to a position before the rest
+func substitutionOrder(subMap map[string]*Substitution) []*Substitution {
+	subs := sortedSubs(subMap)
+	rank := func(s *Substitution) int {
+		r := 0
+		if s.isPattern() {
+			r += 2
+		}
+		if s.Position == 0 {
+			r++
+		}
+		return r
+	}
+	var ordered []*Substitution
+	for r := 0; r < 4; r++ {
+		for _, s := range subs {
+			if rank(s) == r {
+				ordered = append(ordered, s)
+			}
+		}
+	}
+	return ordered
 }
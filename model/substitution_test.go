@@ -0,0 +1,160 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func fakeSubstitutionManifest() *Manifest {
+	m := NewManifest("manifest", "", "", nil)
+	m.AddCommand("git", "git", "", nil, false, "")
+	m.AddCommand("git.fetch", "fetch origin", "", nil, false, "")
+	m.AddCommand("git.push", "push", "", nil, false, "")
+	m.SetSubstitution("git", &Substitution{Name: "refs/pull/$1/head", Alias: `pr-(\d+)`, Match: RegexMatch})
+	m.SetSubstitution("git", &Substitution{Name: "release/$1.$2", Alias: "v*.*", Match: GlobMatch})
+	m.SetSubstitution("git", &Substitution{Name: "origin main", Alias: "om", Split: true})
+	m.SetSubstitution("git", &Substitution{Name: "exact-pr", Alias: "pr-1"})
+	m.SetSubstitution("git.fetch", &Substitution{Name: "fetch-pr/$1", Alias: `pr-(\d+)`, Match: RegexMatch})
+	m.SetSubstitution("git.push", &Substitution{Name: "--force", Alias: "f", Position: 1})
+	m.SetSubstitution("git.push", &Substitution{Name: "$HOME/$1", Alias: "h-*", Match: GlobMatch})
+	m.SetGlobalSubstitution(&Substitution{Name: "main-branch", Alias: "m"})
+	m.SetGlobalSubstitution(&Substitution{Name: "global-pr", Alias: "pr-*", Match: GlobMatch})
+	m.SetGlobalSubstitution(&Substitution{Name: "global-f", Alias: "f"})
+	m.Link()
+	return m
+}
+
+func TestSubstitutionPrecedence(t *testing.T) {
+	s := NewSpaceport([]*Manifest{fakeSubstitutionManifest()})
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"regex capture", []string{"git", "pr-42"}, "git refs/pull/42/head"},
+		{"glob captures", []string{"git", "v1.2"}, "git release/1.2"},
+		{"exact before pattern", []string{"git", "pr-1"}, "git exact-pr"},
+		{"closest scope pattern", []string{"git", "fetch", "pr-1"}, "git fetch origin fetch-pr/1"},
+		{"split into arguments", []string{"git", "om"}, "git origin main"},
+		{"global after scopes", []string{"git", "m"}, "git main-branch"},
+		{"global pattern", []string{"git", "pr-x"}, "git global-pr"},
+		{"position match", []string{"git", "push", "f"}, "git push --force"},
+		{"position mismatch uses global", []string{"git", "push", "x", "f"}, "git push x global-f"},
+		{"shell variables untouched", []string{"git", "push", "h-dir"}, "git push $HOME/dir"},
+		{"no match", []string{"git", "other"}, "git other"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, actual, _, err := s.ExecutionString(test.args)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if actual != test.expected {
+				t.Errorf("expected: %s, actual: %s", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSubstitutionSplitPositions(t *testing.T) {
+	m := NewManifest("manifest", "", "", nil)
+	m.AddCommand("echo", "echo [$2] [$1]", "", nil, false, ExclusiveMode.String())
+	m.SetSubstitution("echo", &Substitution{Name: "a b", Alias: "ab", Split: true})
+	m.SetSubstitution("echo", &Substitution{Name: "c d", Alias: "cd"})
+	m.Link()
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"split", []string{"echo", "ab"}, "echo [b] [a]"},
+		{"whole", []string{"echo", "cd", "e"}, "echo [e] [c d]"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, actual, err := m.ExecutionString(test.args)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if actual != test.expected {
+				t.Errorf("expected: %s, actual: %s", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSubstitutionSameAliasPositions(t *testing.T) {
+	m := NewManifest("manifest", "", "", nil)
+	m.AddCommand("deploy", "deploy", "", nil, false, "")
+	m.SetSubstitution("deploy", &Substitution{Name: "production", Alias: "prod", Position: 1})
+	m.SetSubstitution("deploy", &Substitution{Name: "--prod-db", Alias: "prod", Position: 2})
+	m.SetSubstitution("deploy", &Substitution{Name: "prod-db", Alias: "prod", Position: 2})
+	m.Link()
+
+	if n := len(m.Find("deploy").Subs); n != 2 {
+		t.Errorf("expected 2 substitutions but got %d", n)
+	}
+	if _, actual, _ := m.ExecutionString([]string{"deploy", "prod", "prod", "prod"}); actual != "deploy production prod-db prod" {
+		t.Errorf("expected substitutions at each position, actual: %s", actual)
+	}
+
+	if err := m.RemoveSubstitution("deploy", "prod"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if n := len(m.Find("deploy").Subs); n != 0 {
+		t.Errorf("expected substitutions at all positions removed but got %d", n)
+	}
+}
+
+func TestSubstitutionValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		sub     *Substitution
+		wantErr bool
+	}{
+		{"exact", &Substitution{Name: "n", Alias: "a"}, false},
+		{"explicit exact", &Substitution{Name: "n", Alias: "a", Match: ExactMatch}, false},
+		{"regex", &Substitution{Name: "n", Alias: "a(.*)", Match: RegexMatch}, false},
+		{"glob", &Substitution{Name: "n", Alias: "a[*", Match: GlobMatch}, false},
+		{"invalid regex", &Substitution{Name: "n", Alias: "a(", Match: RegexMatch}, true},
+		{"invalid match", &Substitution{Name: "n", Alias: "a", Match: "fuzzy"}, true},
+		{"negative position", &Substitution{Name: "n", Alias: "a", Position: -1}, true},
+		{"empty alias", &Substitution{Name: "n"}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.sub.validate(); (err != nil) != test.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestGlobalSubstitution(t *testing.T) {
+	m := fakeSubstitutionManifest()
+	if err := m.RemoveGlobalSubstitution("m"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := m.RemoveGlobalSubstitution("m"); err == nil {
+		t.Errorf("expected error removing missing global substitution")
+	}
+	if _, actual, _ := m.ExecutionString([]string{"git", "m"}); actual != "git m" {
+		t.Errorf("expected removed global substitution, actual: %s", actual)
+	}
+}
+
+func TestExplainPatternSubstitution(t *testing.T) {
+	s := NewSpaceport([]*Manifest{fakeSubstitutionManifest()})
+	e, err := s.Explain([]string{"git", "pr-7", "m"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := []*ExplainSubstitution{
+		{"pr-7", "refs/pull/7/head", "git", `regex pr-(\d+)`},
+		{"m", "main-branch", GlobalScope, ""},
+	}
+	if !reflect.DeepEqual(e.Substitutions, expected) {
+		t.Errorf("expected: %v, actual: %v", expected, e.Substitutions)
+	}
+}
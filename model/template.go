@@ -0,0 +1,142 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jinzhu/copier"
+)
+
+// Template is a reusable command subtree with parameters
+//
+// Commands attach a template with `use` and provide `values` for the
+// parameters. Parameters are referenced as `{{name}}` in the template.
+type Template struct {
+	Description string              `json:"description,omitempty" yaml:"description,omitempty"`
+	Params      []string            `json:"params,omitempty" yaml:"params,omitempty"`
+	Commands    map[string]*Command `json:"commands,omitempty" yaml:"commands,omitempty"`
+}
+
+// values validated for this template's parameters
+func (t *Template) values(name, keyPath string, values map[string]string) (map[string]string, error) {
+	params := map[string]bool{}
+	for _, param := range t.Params {
+		params[param] = true
+		if _, ok := values[param]; !ok {
+			return nil, fmt.Errorf("missing value for param '%s' of template '%s' at %s", param, name, keyPath)
+		}
+	}
+
+	keys := []string{}
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !params[key] {
+			return nil, fmt.Errorf("unknown param '%s' for template '%s' at %s", key, name, keyPath)
+		}
+	}
+
+	return values, nil
+}
+
+// resolveTemplates expands `use` on this command and its subtree
+//
+// Commands already defined on a node take precedence over the template's.
+// The stack tracks templates being expanded to detect cycles.
+func (c *Command) resolveTemplates(templates map[string]*Template, stack []string) error {
+	if len(c.Use) > 0 {
+		for _, name := range stack {
+			if name == c.Use {
+				return fmt.Errorf("template cycle at %s: %s", c.KeyPath, strings.Join(append(stack, c.Use), " -> "))
+			}
+		}
+
+		t := templates[c.Use]
+		if t == nil {
+			return fmt.Errorf("template '%s' not found at %s", c.Use, c.KeyPath)
+		}
+
+		values, err := t.values(c.Use, c.KeyPath, c.Values)
+		if err != nil {
+			return err
+		}
+
+		for _, tc := range sortedCommands(t.Commands) {
+			if _, exists := c.Commands[tc.Alias]; exists {
+				continue
+			}
+
+			child := tc.generate(values)
+			if child == nil {
+				return fmt.Errorf("failed to create command from template '%s' at %s", c.Use, c.KeyPath)
+			}
+			c.addCommand(child)
+
+			if err := child.resolveTemplates(templates, append(stack, c.Use)); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, cmd := range c.SortedCommands() {
+		if cmd.generated {
+			continue
+		}
+		if err := cmd.resolveTemplates(templates, stack); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// generate a deep copy of this command marked as generated with template
+// parameters replaced by values
+func (c *Command) generate(values map[string]string) *Command {
+	cmd := &Command{}
+	if err := copier.CopyWithOption(cmd, c, copier.Option{DeepCopy: true}); err != nil {
+		return nil
+	}
+
+	fill := func(s string) string {
+		for param, value := range values {
+			s = strings.ReplaceAll(s, "{{"+param+"}}", value)
+		}
+		return s
+	}
+
+	cmd.forwardWalk(func(child *Command, stop *bool) {
+		child.generated = true
+		child.Name = fill(child.Name)
+		child.Description = fill(child.Description)
+		child.Use = fill(child.Use)
+		if child.Code != nil {
+			child.Code.Snippet = fill(child.Code.Snippet)
+		}
+		for _, sub := range child.Subs {
+			sub.Name = fill(sub.Name)
+		}
+		for key, value := range child.Values {
+			child.Values[key] = fill(value)
+		}
+	})
+
+	return cmd
+}
+
+// authoredCommands omits commands that were generated when linking
+func authoredCommands(cmdMap map[string]*Command) map[string]*Command {
+	if cmdMap == nil {
+		return nil
+	}
+	cmds := map[string]*Command{}
+	for key, cmd := range cmdMap {
+		if !cmd.generated {
+			cmds[key] = cmd
+		}
+	}
+	return cmds
+}
@@ -0,0 +1,140 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pokanop/nostromo/version"
+)
+
+func TestResolveTemplates(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest *Manifest
+		args     []string
+		expErr   string
+		expected string
+	}{
+		{"no templates", fakeTemplateManifest("", nil), []string{"api", "build"}, "", "cd api && make build"},
+		{"template with values", fakeTemplateManifest("service", map[string]string{"dir": "web"}), []string{"api", "test"}, "", "cd api && make test DIR=web"},
+		{"nested template", fakeTemplateManifest("service", map[string]string{"dir": "web"}), []string{"api", "lint", "fix"}, "", "cd api && golint web --fix"},
+		{"authored command wins", fakeTemplateManifest("service", map[string]string{"dir": "web"}), []string{"api", "build"}, "", "cd api && make build"},
+		{"missing template", fakeTemplateManifest("missing", nil), nil, "template 'missing' not found at api", ""},
+		{"missing value", fakeTemplateManifest("service", nil), nil, "missing value for param 'dir' of template 'service' at api", ""},
+		{"unknown value", fakeTemplateManifest("service", map[string]string{"dir": "web", "foo": "bar"}), nil, "unknown param 'foo' for template 'service' at api", ""},
+		{"template cycle", fakeTemplateManifest("cycle", nil), nil, "template cycle at api.again: cycle -> cycle", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.manifest.Link()
+			if len(test.expErr) > 0 {
+				if err == nil || err.Error() != test.expErr {
+					t.Errorf("expected error '%s' but got '%v'", test.expErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+
+			_, actual, err := test.manifest.ExecutionString(test.args)
+			if err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+			if actual != test.expected {
+				t.Errorf("expected: %s, actual: %s", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestGeneratedCommandsNotSaved(t *testing.T) {
+	m := fakeTemplateManifest("service", map[string]string{"dir": "web"})
+	if err := m.Link(); err != nil {
+		t.Fatal(err)
+	}
+	if m.Find("api.test") == nil {
+		t.Fatalf("expected generated command")
+	}
+
+	if y := m.AsYAML(); strings.Contains(y, "DIR=web") {
+		t.Errorf("expected generated commands to be omitted from yaml: %s", y)
+	}
+	if j := m.AsJSON(); strings.Contains(j, "DIR=web") {
+		t.Errorf("expected generated commands to be omitted from json: %s", j)
+	}
+}
+
+func TestIncludedManifests(t *testing.T) {
+	inc := NewManifest("shared", "", "", &version.Info{})
+	inc.Templates = fakeTemplates()
+	inc.AddCommand("shared", "echo shared", "", nil, false, "")
+	inc.AddCommand("api", "echo shadowed", "", nil, false, "")
+
+	m := fakeTemplateManifest("service", map[string]string{"dir": "web"})
+	m.Templates = nil
+	m.Include(inc)
+	if err := m.Link(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"included command", []string{"shared"}, "echo shared"},
+		{"own command wins", []string{"api", "build"}, "cd api && make build"},
+		{"included template", []string{"api", "test"}, "cd api && make test DIR=web"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, actual, err := m.ExecutionString(test.args)
+			if err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+			if actual != test.expected {
+				t.Errorf("expected: %s, actual: %s", test.expected, actual)
+			}
+		})
+	}
+
+	if y := m.AsYAML(); strings.Contains(y, "echo shared") {
+		t.Errorf("expected included commands to be omitted from yaml: %s", y)
+	}
+}
+
+func fakeTemplates() map[string]*Template {
+	lint := newCommand("golint {{dir}}", "lint", "", nil, false, "")
+	lint.addCommand(newCommand("--fix", "fix", "", nil, false, ""))
+	again := newCommand("", "again", "", nil, false, "")
+	again.Use = "cycle"
+	return map[string]*Template{
+		"service": {
+			Params: []string{"dir"},
+			Commands: map[string]*Command{
+				"build": newCommand("make build DIR={{dir}}", "build", "", nil, false, ""),
+				"test":  newCommand("make test DIR={{dir}}", "test", "", nil, false, ""),
+				"lint":  lint,
+			},
+		},
+		"cycle": {
+			Commands: map[string]*Command{
+				"again": again,
+			},
+		},
+	}
+}
+
+func fakeTemplateManifest(use string, values map[string]string) *Manifest {
+	m := NewManifest("manifest", "file://path/to/manifest.yaml", "/path/to/manifest.yaml", &version.Info{})
+	m.Templates = fakeTemplates()
+	m.AddCommand("api", "cd api &&", "", nil, false, "")
+	m.AddCommand("api.build", "make build", "", nil, false, "")
+	api := m.Find("api")
+	api.Use = use
+	api.Values = values
+	return m
+}
@@ -0,0 +1,125 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// HelpFlag reserved to show usage instead of running a command
+const HelpFlag = "--help"
+
+// positionalParam in commands replaced by arguments like `$1`
+var positionalParam = regexp.MustCompile(`\$\d+`)
+
+// Usage of a command for help output
+type Usage struct {
+	KeyPath     string
+	Description string
+	Commands    []*UsageItem
+	Subs        []*UsageItem
+	Flags       []*UsageItem
+	Params      []string
+	Mode        string
+	Command     string
+}
+
+// UsageItem with a name and its description
+type UsageItem struct {
+	Name        string
+	Description string
+}
+
+// IsHelpRequest checks args for the help flag and returns args without it
+//
+// Arguments after `--` are passed through so the underlying command can
+// still receive the help flag.
+func IsHelpRequest(args []string) ([]string, bool) {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if arg == HelpFlag {
+			stripped := append([]string{}, args[:i]...)
+			return append(stripped, args[i+1:]...), true
+		}
+	}
+	return args, false
+}
+
+// Usage of the command at args with the command expanded for the remaining
+// arguments
+func (s *Spaceport) Usage(args []string) (*Usage, error) {
+	c, _, args, err := s.commandIndex().resolve(args)
+	if err != nil {
+		return nil, err
+	}
+	return c.usage(args)
+}
+
+func (c *Command) usage(args []string) (*Usage, error) {
+	cmd, err := c.executionString(args)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &Usage{
+		KeyPath:     c.KeyPath,
+		Description: c.Description,
+		Mode:        c.Mode.String(),
+		Command:     cmd,
+	}
+	for _, child := range c.SortedCommands() {
+		u.Commands = append(u.Commands, &UsageItem{child.Alias, child.Description})
+	}
+	for _, sub := range c.subsInScope() {
+		u.Subs = append(u.Subs, &UsageItem{sub.Alias, sub.Name})
+	}
+	for _, f := range c.flagsInScope() {
+		u.Flags = append(u.Flags, &UsageItem{f.usage(), f.Description})
+	}
+	u.Params = c.positionalParams()
+
+	return u, nil
+}
+
+// positionalParams used by the expanded command in order
+func (c *Command) positionalParams() []string {
+	cmd := c.expand()
+	if c.Mode == ExclusiveMode {
+		cmd = c.Name
+	}
+
+	seen := map[string]bool{}
+	params := []string{}
+	for _, p := range positionalParam.FindAllString(cmd, -1) {
+		if !seen[p] {
+			seen[p] = true
+			params = append(params, p)
+		}
+	}
+	sort.SliceStable(params, func(i, j int) bool {
+		a, _ := strconv.Atoi(params[i][1:])
+		b, _ := strconv.Atoi(params[j][1:])
+		return a < b
+	})
+	return params
+}
+
+// usage of the flag like `-e, --env string`
+func (f *Flag) usage() string {
+	var parts []string
+	if len(f.Shorthand) > 0 {
+		parts = append(parts, "-"+f.Shorthand+",")
+	}
+	parts = append(parts, "--"+f.Name)
+	if f.flagType() != BoolFlagType {
+		parts = append(parts, f.flagType())
+	}
+	if len(f.Default) > 0 {
+		parts = append(parts, fmt.Sprintf("(default %s)", f.Default))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,71 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIsHelpRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expArgs  []string
+		expected bool
+	}{
+		{"nil args", nil, nil, false},
+		{"no help", []string{"build", "api"}, []string{"build", "api"}, false},
+		{"help", []string{"build", "--help"}, []string{"build"}, true},
+		{"help with args", []string{"build", "--help", "api"}, []string{"build", "api"}, true},
+		{"short help passed through", []string{"ls", "-h"}, []string{"ls", "-h"}, false},
+		{"help after double dash", []string{"build", "--", "--help"}, []string{"build", "--", "--help"}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			args, actual := IsHelpRequest(test.args)
+			if actual != test.expected {
+				t.Errorf("expected: %t, actual: %t", test.expected, actual)
+			}
+			if !reflect.DeepEqual(args, test.expArgs) {
+				t.Errorf("expected: %s, actual: %s", test.expArgs, args)
+			}
+		})
+	}
+}
+
+func TestSpaceportUsage(t *testing.T) {
+	m := fakeFlagManifest()
+	m.AddCommand("deploy.status", "status $2 $1", "Show status", nil, false, "")
+	m.AddCommand("deploy.status.all", "--all", "", nil, false, "")
+	m.AddSubstitution("deploy", "production", "prod")
+	m.Link()
+	s := NewSpaceport([]*Manifest{m})
+
+	u, err := s.Usage([]string{"deploy", "status", "-e", "prod", "api"})
+	if err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+
+	expected := &Usage{
+		KeyPath:     "deploy.status",
+		Description: "Show status",
+		Commands:    []*UsageItem{{"all", ""}},
+		Subs:        []*UsageItem{{"prod", "production"}},
+		Flags: []*UsageItem{
+			{"-e, --env string (default staging)", "Target environment"},
+			{"--dry", ""},
+			{"--replicas int", ""},
+			{"--note string", ""},
+		},
+		Params:  []string{"$1", "$2"},
+		Mode:    "concatenate",
+		Command: "deploy prod status $2 api",
+	}
+	if !reflect.DeepEqual(u, expected) {
+		t.Errorf("expected: %+v, actual: %+v", expected, u)
+	}
+
+	if _, err := s.Usage([]string{"missing"}); err == nil {
+		t.Errorf("expected error for missing command")
+	}
+}
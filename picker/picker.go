@@ -0,0 +1,340 @@
+package picker
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pokanop/nostromo/stringutil"
+	"golang.org/x/term"
+)
+
+// ErrCancelled when the picker is closed without a selection
+var ErrCancelled = errors.New("picker cancelled")
+
+// Terminal control sequences used to draw the picker
+const (
+	enterScreen = "\x1b[?1049h\x1b[?25l"
+	leaveScreen = "\x1b[?25h\x1b[?1049l"
+	clearScreen = "\x1b[H\x1b[2J"
+	bold        = "\x1b[1m"
+	faint       = "\x1b[2m"
+	reverse     = "\x1b[7m"
+	reset       = "\x1b[0m"
+)
+
+// Default size when the terminal size is unknown
+const (
+	defaultWidth  = 80
+	defaultHeight = 24
+)
+
+// Item to pick with a label and other text to search
+type Item struct {
+	Label       string
+	Description string
+	Body        string
+}
+
+// Options for the picker
+type Options struct {
+	// Prompt shown before the query
+	Prompt string
+	// Query to start with
+	Query string
+	// Preview text for the item at an index
+	Preview func(index int) string
+}
+
+// Selection made in the picker
+type Selection struct {
+	// Index of the item picked
+	Index int
+	// Execute is set when picked with ctrl-x instead of enter
+	Execute bool
+}
+
+// match of an item for the query
+type match struct {
+	index   int
+	score   int
+	indexes []int
+}
+
+// key read from the terminal
+type key int
+
+const (
+	keyNone key = iota
+	keyRune
+	keyEnter
+	keyExecute
+	keyCancel
+	keyBackspace
+	keyClear
+	keyUp
+	keyDown
+)
+
+// picker state independent of the terminal
+type picker struct {
+	items   []Item
+	opts    Options
+	query   []rune
+	matches []*match
+	cursor  int
+	offset  int
+}
+
+func newPicker(items []Item, opts Options) *picker {
+	if len(opts.Prompt) == 0 {
+		opts.Prompt = "> "
+	}
+	p := &picker{items: items, opts: opts, query: []rune(opts.Query)}
+	p.filter()
+	return p
+}
+
+// Run the picker on the terminal until an item is picked or it is cancelled
+//
+// Keys are read from in which must be a terminal and the picker is drawn to
+// out using the alternate screen so the scrollback is left as is.
+func Run(in, out *os.File, items []Item, opts Options) (*Selection, error) {
+	state, err := term.MakeRaw(int(in.Fd()))
+	if err != nil {
+		return nil, fmt.Errorf("interactive picker requires a terminal: %s", err)
+	}
+	defer term.Restore(int(in.Fd()), state)
+
+	io.WriteString(out, enterScreen)
+	defer io.WriteString(out, leaveScreen)
+
+	p := newPicker(items, opts)
+	buf := make([]byte, 256)
+	for {
+		width, height, _ := term.GetSize(int(out.Fd()))
+		io.WriteString(out, p.render(width, height))
+
+		n, err := in.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		b := buf[:n]
+		for len(b) > 0 {
+			k, r, size := readKey(b)
+			b = b[size:]
+			if sel, err := p.handle(k, r); sel != nil || err != nil {
+				return sel, err
+			}
+		}
+	}
+}
+
+// readKey at the start of b returning the rune for printable keys and the
+// number of bytes read
+func readKey(b []byte) (key, rune, int) {
+	switch b[0] {
+	case '\r', '\n':
+		return keyEnter, 0, 1
+	case 0x18: // ctrl-x
+		return keyExecute, 0, 1
+	case 0x03, 0x04: // ctrl-c, ctrl-d
+		return keyCancel, 0, 1
+	case 0x7f, 0x08:
+		return keyBackspace, 0, 1
+	case 0x15: // ctrl-u
+		return keyClear, 0, 1
+	case 0x10, 0x0b: // ctrl-p, ctrl-k
+		return keyUp, 0, 1
+	case 0x0e: // ctrl-n
+		return keyDown, 0, 1
+	case 0x1b:
+		if len(b) < 3 || (b[1] != '[' && b[1] != 'O') {
+			return keyCancel, 0, 1
+		}
+		switch b[2] {
+		case 'A':
+			return keyUp, 0, 3
+		case 'B':
+			return keyDown, 0, 3
+		}
+		// Skip the rest of unsupported sequences
+		i := 2
+		for i < len(b) && (b[i] < 0x40 || b[i] > 0x7e) {
+			i++
+		}
+		if i < len(b) {
+			i++
+		}
+		return keyNone, 0, i
+	}
+
+	r, size := utf8.DecodeRune(b)
+	if r == utf8.RuneError || r < 0x20 {
+		return keyNone, 0, size
+	}
+	return keyRune, r, size
+}
+
+// handle a key returning a selection when done
+func (p *picker) handle(k key, r rune) (*Selection, error) {
+	switch k {
+	case keyRune:
+		p.query = append(p.query, r)
+		p.filter()
+	case keyBackspace:
+		if len(p.query) > 0 {
+			p.query = p.query[:len(p.query)-1]
+			p.filter()
+		}
+	case keyClear:
+		p.query = nil
+		p.filter()
+	case keyUp:
+		if p.cursor > 0 {
+			p.cursor--
+		}
+	case keyDown:
+		if p.cursor < len(p.matches)-1 {
+			p.cursor++
+		}
+	case keyEnter, keyExecute:
+		if len(p.matches) == 0 {
+			return nil, nil
+		}
+		return &Selection{Index: p.matches[p.cursor].index, Execute: k == keyExecute}, nil
+	case keyCancel:
+		return nil, ErrCancelled
+	}
+	return nil, nil
+}
+
+// filter items by the query with the best matches first
+//
+// Labels weigh more than descriptions and descriptions more than bodies.
+func (p *picker) filter() {
+	query := string(p.query)
+	p.matches = nil
+	for i, item := range p.items {
+		best := &match{index: i}
+		found := false
+		for field, s := range []string{item.Label, item.Description, item.Body} {
+			score, indexes, ok := stringutil.FuzzyMatch(s, query)
+			if !ok {
+				continue
+			}
+			score *= 3 - field
+			if !found || score > best.score {
+				best.score = score
+				best.indexes = nil
+				if field == 0 {
+					best.indexes = indexes
+				}
+			}
+			found = true
+		}
+		if found {
+			p.matches = append(p.matches, best)
+		}
+	}
+	sort.SliceStable(p.matches, func(i, j int) bool {
+		return p.matches[i].score > p.matches[j].score
+	})
+	p.cursor, p.offset = 0, 0
+}
+
+// render the picker for a terminal of width and height
+func (p *picker) render(width, height int) string {
+	if width <= 0 || height <= 0 {
+		width, height = defaultWidth, defaultHeight
+	}
+
+	var preview []string
+	if p.opts.Preview != nil && len(p.matches) > 0 {
+		preview = strings.Split(strings.TrimRight(p.opts.Preview(p.matches[p.cursor].index), "\n"), "\n")
+		if max := height / 3; len(preview) > max {
+			preview = preview[:max]
+		}
+	}
+
+	rows := height - 2
+	if len(preview) > 0 {
+		rows -= len(preview) + 1
+	}
+	if rows < 1 {
+		rows = 1
+	}
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	} else if p.cursor >= p.offset+rows {
+		p.offset = p.cursor - rows + 1
+	}
+
+	var b bytes.Buffer
+	b.WriteString(clearScreen)
+	b.WriteString(truncate(p.opts.Prompt+string(p.query), width) + "\r\n")
+	b.WriteString(faint + truncate(fmt.Sprintf("  %d/%d", len(p.matches), len(p.items)), width) + reset + "\r\n")
+	for i := p.offset; i < len(p.matches) && i < p.offset+rows; i++ {
+		b.WriteString(p.renderMatch(p.matches[i], i == p.cursor, width) + "\r\n")
+	}
+	if len(preview) > 0 {
+		for i := len(p.matches) - p.offset; i < rows; i++ {
+			b.WriteString("\r\n")
+		}
+		b.WriteString(faint + strings.Repeat("─", width) + reset)
+		for _, line := range preview {
+			b.WriteString("\r\n" + truncate(line, width))
+		}
+	}
+	return b.String()
+}
+
+// renderMatch with matched runes in bold and the description faint
+func (p *picker) renderMatch(m *match, selected bool, width int) string {
+	item := p.items[m.index]
+	marker := "  "
+	if selected {
+		marker = "> "
+	}
+
+	label := []rune(truncate(marker+item.Label, width))
+	matched := map[int]bool{}
+	for _, i := range m.indexes {
+		matched[i+2] = true
+	}
+
+	var b strings.Builder
+	if selected {
+		b.WriteString(reverse)
+	}
+	for i, r := range label {
+		if matched[i] {
+			b.WriteString(bold + string(r) + reset)
+			if selected {
+				b.WriteString(reverse)
+			}
+			continue
+		}
+		b.WriteRune(r)
+	}
+	if room := width - len(label) - 2; room > 0 && len(item.Description) > 0 {
+		b.WriteString("  " + faint + truncate(item.Description, room))
+	}
+	b.WriteString(reset)
+	return b.String()
+}
+
+// truncate s to width runes
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:width])
+}
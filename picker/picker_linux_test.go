@@ -0,0 +1,133 @@
+package picker
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// openPTY returns the master and slave ends of a new pseudo-terminal
+func openPTY(t *testing.T) (*os.File, *os.File) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		t.Skipf("pseudo-terminals not available: %s", err)
+	}
+	fd := int(master.Fd())
+	if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+		master.Close()
+		t.Skipf("unable to unlock pseudo-terminal: %s", err)
+	}
+	n, err := unix.IoctlGetInt(fd, unix.TIOCGPTN)
+	if err != nil {
+		master.Close()
+		t.Skipf("unable to get pseudo-terminal number: %s", err)
+	}
+	slave, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		t.Skipf("unable to open pseudo-terminal: %s", err)
+	}
+	unix.IoctlSetWinsize(int(slave.Fd()), unix.TIOCSWINSZ, &unix.Winsize{Row: 12, Col: 60})
+	return master, slave
+}
+
+// screen collects everything drawn to the terminal
+type screen struct {
+	sync.Mutex
+	bytes.Buffer
+}
+
+func (s *screen) String() string {
+	s.Lock()
+	defer s.Unlock()
+	return s.Buffer.String()
+}
+
+func TestRunTerminal(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected *Selection
+		err      error
+	}{
+		{"pick first", "\r", &Selection{Index: 0}, nil},
+		{"fuzzy query", "gco\r", &Selection{Index: 0}, nil},
+		{"arrow keys", "dep\x1b[B\r", &Selection{Index: 2}, nil},
+		{"edit query", "zz\x7f\x7fbuild\r", &Selection{Index: 3}, nil},
+		{"execute", "logs\x18", &Selection{Index: 2, Execute: true}, nil},
+		{"cancel", "\x1b", nil, ErrCancelled},
+		{"interrupt", "dep\x03", nil, ErrCancelled},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			master, slave := openPTY(t)
+			defer master.Close()
+			defer slave.Close()
+
+			out := &screen{}
+			go func() {
+				buf := make([]byte, 1024)
+				for {
+					n, err := master.Read(buf)
+					out.Lock()
+					out.Write(buf[:n])
+					out.Unlock()
+					if err != nil {
+						return
+					}
+				}
+			}()
+
+			type result struct {
+				sel *Selection
+				err error
+			}
+			done := make(chan result)
+			go func() {
+				sel, err := Run(slave, slave, fakeItems(), Options{
+					Preview: func(i int) string {
+						return "preview " + fakeItems()[i].Body
+					},
+				})
+				done <- result{sel, err}
+			}()
+
+			// Wait for the first draw before typing
+			for deadline := time.Now().Add(2 * time.Second); !bytes.Contains([]byte(out.String()), []byte("4/4")); {
+				if time.Now().After(deadline) {
+					t.Fatalf("picker was not drawn: %q", out.String())
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			master.Write([]byte(test.input))
+
+			select {
+			case r := <-done:
+				if r.err != test.err {
+					t.Errorf("expected error %v but got %v", test.err, r.err)
+				}
+				if fmt.Sprint(r.sel) != fmt.Sprint(test.expected) {
+					t.Errorf("expected %v but got %v", test.expected, r.sel)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatalf("picker did not finish: %q", out.String())
+			}
+
+			termios, err := unix.IoctlGetTermios(int(slave.Fd()), unix.TCGETS)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if termios.Lflag&unix.ICANON == 0 || termios.Lflag&unix.ECHO == 0 {
+				t.Errorf("expected terminal to be restored")
+			}
+			if !bytes.Contains([]byte(out.String()), []byte("preview ")) {
+				t.Errorf("expected preview to be drawn: %q", out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package picker
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func fakeItems() []Item {
+	return []Item{
+		{"git.checkout", "switch branches", "git checkout"},
+		{"deploy", "ship it", "kubectl apply -f deploy.yaml"},
+		{"deploy.logs", "tail logs", "kubectl logs -f"},
+		{"build", "compile everything", "make all"},
+	}
+}
+
+func TestReadKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		key   key
+		r     rune
+		size  int
+	}{
+		{"rune", "a", keyRune, 'a', 1},
+		{"unicode rune", "é", keyRune, 'é', 2},
+		{"enter", "\r", keyEnter, 0, 1},
+		{"execute", "\x18", keyExecute, 0, 1},
+		{"ctrl-c", "\x03", keyCancel, 0, 1},
+		{"escape", "\x1b", keyCancel, 0, 1},
+		{"escape then rune", "\x1bq", keyCancel, 0, 1},
+		{"backspace", "\x7f", keyBackspace, 0, 1},
+		{"clear", "\x15", keyClear, 0, 1},
+		{"up arrow", "\x1b[A", keyUp, 0, 3},
+		{"down arrow", "\x1bOB", keyDown, 0, 3},
+		{"ctrl-p", "\x10", keyUp, 0, 1},
+		{"ctrl-n", "\x0e", keyDown, 0, 1},
+		{"unsupported sequence", "\x1b[15~x", keyNone, 0, 5},
+		{"control", "\x01", keyNone, 0, 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			k, r, size := readKey([]byte(test.input))
+			if k != test.key || r != test.r || size != test.size {
+				t.Errorf("expected (%d, %q, %d) but got (%d, %q, %d)", test.key, test.r, test.size, k, r, size)
+			}
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{"empty query", "", []string{"git.checkout", "deploy", "deploy.logs", "build"}},
+		{"label first", "dep", []string{"deploy", "deploy.logs"}},
+		{"description", "branches", []string{"git.checkout"}},
+		{"body", "make", []string{"build"}},
+		{"fuzzy", "gco", []string{"git.checkout"}},
+		{"no match", "zzz", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			items := fakeItems()
+			p := newPicker(items, Options{Query: test.query})
+			var actual []string
+			for _, m := range p.matches {
+				actual = append(actual, items[m.index].Label)
+			}
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("expected %v but got %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestHandle(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     []key
+		runes    string
+		expected *Selection
+		err      error
+	}{
+		{"first", []key{keyEnter}, "", &Selection{Index: 0}, nil},
+		{"down", []key{keyDown, keyDown, keyEnter}, "", &Selection{Index: 2}, nil},
+		{"down past end", []key{keyDown, keyDown, keyDown, keyDown, keyDown, keyEnter}, "", &Selection{Index: 3}, nil},
+		{"up past start", []key{keyUp, keyEnter}, "", &Selection{Index: 0}, nil},
+		{"query", []key{keyRune, keyRune, keyRune, keyEnter}, "bui", &Selection{Index: 3}, nil},
+		{"backspace", []key{keyRune, keyRune, keyBackspace, keyEnter}, "gz", &Selection{Index: 0}, nil},
+		{"execute", []key{keyDown, keyExecute}, "", &Selection{Index: 1, Execute: true}, nil},
+		{"no matches", []key{keyRune, keyEnter, keyCancel}, "z", nil, ErrCancelled},
+		{"cancel", []key{keyCancel}, "", nil, ErrCancelled},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := newPicker(fakeItems(), Options{})
+			runes := []rune(test.runes)
+			var sel *Selection
+			var err error
+			for _, k := range test.keys {
+				var r rune
+				if k == keyRune {
+					r, runes = runes[0], runes[1:]
+				}
+				if sel, err = p.handle(k, r); sel != nil || err != nil {
+					break
+				}
+			}
+			if err != test.err {
+				t.Errorf("expected error %v but got %v", test.err, err)
+			}
+			if !reflect.DeepEqual(sel, test.expected) {
+				t.Errorf("expected %v but got %v", test.expected, sel)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	p := newPicker(fakeItems(), Options{
+		Query: "dep",
+		Preview: func(i int) string {
+			return "preview " + fakeItems()[i].Body
+		},
+	})
+	p.handle(keyDown, 0)
+
+	out := p.render(40, 10)
+	for _, expected := range []string{
+		"> dep",
+		"2/4",
+		"> " + bold + "d" + reset + reverse + bold + "e" + reset + reverse + bold + "p" + reset + reverse + "loy.logs",
+		"preview kubectl logs -f",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected render to contain %q in %q", expected, out)
+		}
+	}
+	for _, line := range strings.Split(out, "\r\n") {
+		if n := len([]rune(stripSequences(line))); n > 40 {
+			t.Errorf("expected line within width but got %d runes: %q", n, line)
+		}
+	}
+}
+
+// stripSequences removes terminal control sequences from s
+func stripSequences(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == 0x1b {
+			for i++; i < len(s) && (s[i] < 0x40 || s[i] > 0x7e || s[i] == '['); i++ {
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
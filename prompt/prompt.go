@@ -2,15 +2,18 @@ package prompt
 
 import (
 	"bufio"
-	"github.com/pokanop/nostromo/log"
+	"fmt"
 	"os"
 	"strconv"
 	"strings"
+
+	"github.com/pokanop/nostromo/log"
+	"golang.org/x/term"
 )
 
 func stringWithDefault(prompt, def string) string {
 	var s string
-	log.Boldf(prompt + ": ")
+	log.Promptf("%s: ", prompt)
 	reader := bufio.NewReader(os.Stdin)
 	s, _ = reader.ReadString('\n')
 	s = strings.Trim(s, "\n")
@@ -33,6 +36,24 @@ func StringRequired(prompt string) (s string) {
 	return s
 }
 
+// Secret prompts on the terminal without echoing input so it can be used
+// even when stdout is captured by the shell.
+func Secret(prompt string) (string, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", fmt.Errorf("secret prompt requires a terminal: %s", err)
+	}
+	defer tty.Close()
+
+	log.Promptf("%s: ", prompt)
+	b, err := term.ReadPassword(int(tty.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("secret prompt requires a terminal: %s", err)
+	}
+	return string(b), nil
+}
+
 func confirmWithDefault(prompt, def string) bool {
 	switch stringWithDefault(prompt, def) {
 	case "Yes", "yes", "y", "Y":
@@ -0,0 +1,56 @@
+package secret
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// envPrefix for environment variables holding secrets
+const envPrefix = "NOSTROMO_SECRET_"
+
+// env backend reading secrets from environment variables like
+// `NOSTROMO_SECRET_GITHUB_TOKEN` for `github_token`
+//
+// This is useful for CI where secrets are already provided in the
+// environment, so secrets can't be changed.
+type env struct{}
+
+func newEnv(opts *Options) (Backend, error) {
+	return &env{}, nil
+}
+
+// EnvName of the variable for a secret name
+func EnvName(name string) string {
+	return envPrefix + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(name))
+}
+
+func (e *env) Get(name string) (string, error) {
+	value, ok := os.LookupEnv(EnvName(name))
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func (e *env) Set(name, value string) error {
+	return fmt.Errorf("env secrets are read only, export %s instead", EnvName(name))
+}
+
+func (e *env) List() ([]string, error) {
+	var names []string
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, envPrefix) {
+			continue
+		}
+		name := strings.SplitN(strings.TrimPrefix(kv, envPrefix), "=", 2)[0]
+		names = append(names, strings.ToLower(name))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (e *env) Remove(name string) error {
+	return fmt.Errorf("env secrets are read only, unset %s instead", EnvName(name))
+}
@@ -0,0 +1,217 @@
+package secret
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pokanop/nostromo/stringutil"
+)
+
+// Supported backends for storing secrets
+const (
+	VaultBackend = "vault"
+	EnvBackend   = "env"
+)
+
+// ErrNotFound when a secret doesn't exist in a backend
+var ErrNotFound = errors.New("secret not found")
+
+// namePattern for valid secret names
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// referencePattern for secrets in commands like `${secret:github_token}`
+var referencePattern = regexp.MustCompile(`\$\{secret:([A-Za-z0-9_.-]+)\}`)
+
+// Backend stores secrets by name
+type Backend interface {
+	// Get the value of a secret or ErrNotFound
+	Get(name string) (string, error)
+	// Set the value of a secret creating it if needed
+	Set(name, value string) error
+	// List names of all secrets sorted alphabetically
+	List() ([]string, error)
+	// Remove a secret or ErrNotFound
+	Remove(name string) error
+}
+
+// Options for creating a backend
+type Options struct {
+	// Path of the file backing the vault
+	Path string
+	// Passphrase to unlock the vault, create is set when the vault doesn't
+	// exist yet so it can be confirmed
+	Passphrase func(create bool) (string, error)
+}
+
+// Factory creates a backend with options
+type Factory func(opts *Options) (Backend, error)
+
+var factories = map[string]Factory{
+	VaultBackend: newVault,
+	EnvBackend:   newEnv,
+}
+
+// Register a backend factory by name replacing any existing one
+func Register(name string, factory Factory) {
+	factories[name] = factory
+}
+
+// Backends registered by name sorted alphabetically
+func Backends() []string {
+	var names []string
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsBackendSupported returns true if a backend is registered with name
+func IsBackendSupported(name string) bool {
+	_, ok := factories[name]
+	return ok
+}
+
+// Open the backend with name or the vault if empty
+func Open(name string, opts *Options) (Backend, error) {
+	if len(name) == 0 {
+		name = VaultBackend
+	}
+	factory, ok := factories[name]
+	if !ok {
+		return nil, fmt.Errorf("invalid secret backend %s, expected one of %s", name, strings.Join(Backends(), ", "))
+	}
+	return factory(opts)
+}
+
+// ValidateName of a secret
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid secret name %s, only letters, digits, '_', '.' and '-' are allowed", name)
+	}
+	return nil
+}
+
+// Reference for a secret name like `${secret:name}`
+func Reference(name string) string {
+	return fmt.Sprintf("${secret:%s}", name)
+}
+
+// References to secrets in s in the order they appear without duplicates
+func References(s string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, m := range referencePattern.FindAllStringSubmatch(s, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			names = append(names, m[1])
+		}
+	}
+	return names
+}
+
+// Expand references to secrets in s with values from lookup
+func Expand(s string, lookup func(name string) (string, error)) (string, error) {
+	var err error
+	expanded := referencePattern.ReplaceAllStringFunc(s, func(ref string) string {
+		if err != nil {
+			return ref
+		}
+		name := referencePattern.FindStringSubmatch(ref)[1]
+		var value string
+		if value, err = lookup(name); err != nil {
+			err = fmt.Errorf("unable to resolve secret %s: %s", name, err)
+			return ref
+		}
+		return value
+	})
+	if err != nil {
+		return "", err
+	}
+	return expanded, nil
+}
+
+// Shell quoting contexts a secret reference can appear in
+const (
+	unquoted = iota
+	singleQuoted
+	doubleQuoted
+)
+
+// markedPattern for references marked with Mark, the NUL bytes can't be
+// passed in arguments so marks only come from commands
+var markedPattern = regexp.MustCompile("\x00secret:([A-Za-z0-9_.-]+)\x00")
+
+// Mark references to secrets in s so ExpandShell only resolves those and
+// not references added to s later like from arguments
+func Mark(s string) string {
+	return referencePattern.ReplaceAllString(s, "\x00secret:$1\x00")
+}
+
+// ExpandShell references marked with Mark in the shell command s with
+// values from lookup quoted for where they appear so the shell never
+// interprets them
+func ExpandShell(s string, lookup func(name string) (string, error)) (string, error) {
+	var b strings.Builder
+	context, last := unquoted, 0
+	for _, m := range markedPattern.FindAllStringSubmatchIndex(s, -1) {
+		context = scanQuotes(s[last:m[0]], context)
+		b.WriteString(s[last:m[0]])
+
+		name := s[m[2]:m[3]]
+		value, err := lookup(name)
+		if err != nil {
+			return "", fmt.Errorf("unable to resolve secret %s: %s", name, err)
+		}
+		b.WriteString(quote(value, context))
+		last = m[1]
+	}
+	b.WriteString(s[last:])
+	return b.String(), nil
+}
+
+// scanQuotes in s starting in context returning the context at its end
+func scanQuotes(s string, context int) int {
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case context == singleQuoted:
+			if r == '\'' {
+				context = unquoted
+			}
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			if context == doubleQuoted {
+				context = unquoted
+			} else {
+				context = doubleQuoted
+			}
+		case r == '\'' && context == unquoted:
+			context = singleQuoted
+		}
+	}
+	return context
+}
+
+// quote value so it's taken literally in context
+//
+// Inside quotes the value is quoted the same way without the surrounding
+// quotes since it's already in them.
+func quote(value string, context int) string {
+	var quoted string
+	switch context {
+	case singleQuoted:
+		quoted = stringutil.SingleQuote(value)
+	case doubleQuoted:
+		quoted = stringutil.DoubleQuote(value)
+	default:
+		return stringutil.ShellQuote(value)
+	}
+	return quoted[1 : len(quoted)-1]
+}
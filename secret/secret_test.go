@@ -0,0 +1,181 @@
+package secret
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReferences(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected []string
+	}{
+		{"none", "echo hello", nil},
+		{"single", "curl -H 'token ${secret:gh_token}'", []string{"gh_token"}},
+		{"duplicates", "${secret:a.b} ${secret:c-d} ${secret:a.b}", []string{"a.b", "c-d"}},
+		{"invalid name", "${secret:a b} ${secret:}", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := References(test.s); !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("expected: %v, actual: %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestExpand(t *testing.T) {
+	secrets := map[string]string{"a": "1", "b": "$2"}
+	lookup := func(name string) (string, error) {
+		if v, ok := secrets[name]; ok {
+			return v, nil
+		}
+		return "", ErrNotFound
+	}
+	tests := []struct {
+		name     string
+		s        string
+		expected string
+		wantErr  bool
+	}{
+		{"none", "echo $1", "echo $1", false},
+		{"values", "echo ${secret:a} ${secret:b} ${secret:a}", "echo 1 $2 1", false},
+		{"missing", "echo ${secret:c}", "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := Expand(test.s, lookup)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Expand() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if actual != test.expected {
+				t.Errorf("expected: %s, actual: %s", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestExpandShell(t *testing.T) {
+	secrets := map[string]string{"a": "abc", "b": `it's $HOME; "id" \`}
+	lookup := func(name string) (string, error) {
+		if v, ok := secrets[name]; ok {
+			return v, nil
+		}
+		return "", ErrNotFound
+	}
+	// References that weren't marked like ones from arguments are left as is
+	tests := []struct {
+		name     string
+		s        string
+		expected string
+		wantErr  bool
+	}{
+		{"none", "echo $1", "echo $1 ${secret:a}", false},
+		{"safe", "echo ${secret:a}", "echo abc ${secret:a}", false},
+		{"unquoted", "echo ${secret:b}", `echo 'it'\''s $HOME; "id" \' ${secret:a}`, false},
+		{"single quoted", "echo 'token ${secret:b}'", `echo 'token it'\''s $HOME; "id" \' ${secret:a}`, false},
+		{"double quoted", `echo "token ${secret:b}"`, `echo "token it's \$HOME; \"id\" \\" ${secret:a}`, false},
+		{"after quotes", `echo "a'b" 'c"d' ${secret:b}`, `echo "a'b" 'c"d' 'it'\''s $HOME; "id" \' ${secret:a}`, false},
+		{"missing", "echo ${secret:c}", "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := ExpandShell(Mark(test.s)+" ${secret:a}", lookup)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("ExpandShell() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if actual != test.expected {
+				t.Errorf("expected: %s, actual: %s", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestOpen(t *testing.T) {
+	if _, err := Open("keyring", &Options{}); err == nil {
+		t.Errorf("expected error opening unknown backend")
+	}
+	if _, err := Open("", &Options{}); err == nil {
+		t.Errorf("expected error opening vault without a path")
+	}
+	if b, err := Open(EnvBackend, nil); err != nil || b == nil {
+		t.Errorf("expected env backend but got %v", err)
+	}
+}
+
+func TestVault(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vault")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "vault")
+
+	passphrase := func(key string) *Options {
+		return &Options{Path: path, Passphrase: func(create bool) (string, error) {
+			return key, nil
+		}}
+	}
+
+	v, _ := Open(VaultBackend, passphrase("right"))
+	if names, err := v.List(); err != nil || len(names) != 0 {
+		t.Fatalf("expected empty vault but got %v, %v", names, err)
+	}
+	if err := v.Set("b", "two"); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Set("a", "one"); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Set("a b", "invalid"); err == nil {
+		t.Errorf("expected error setting invalid name")
+	}
+
+	if b, _ := ioutil.ReadFile(path); len(b) == 0 || strings.Contains(string(b), "one") {
+		t.Errorf("expected encrypted vault file")
+	}
+
+	v, _ = Open(VaultBackend, passphrase("right"))
+	if value, err := v.Get("a"); err != nil || value != "one" {
+		t.Errorf("expected one but got %s, %v", value, err)
+	}
+	if names, _ := v.List(); !reflect.DeepEqual(names, []string{"a", "b"}) {
+		t.Errorf("expected sorted names but got %v", names)
+	}
+	if err := v.Remove("b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Remove("b"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found but got %v", err)
+	}
+
+	v, _ = Open(VaultBackend, passphrase("wrong"))
+	if _, err := v.Get("a"); err == nil {
+		t.Errorf("expected error unlocking with wrong passphrase")
+	}
+}
+
+func TestEnv(t *testing.T) {
+	os.Setenv("NOSTROMO_SECRET_GH_TOKEN", "abc")
+	defer os.Unsetenv("NOSTROMO_SECRET_GH_TOKEN")
+
+	e, _ := Open(EnvBackend, nil)
+	if value, err := e.Get("gh-token"); err != nil || value != "abc" {
+		t.Errorf("expected abc but got %s, %v", value, err)
+	}
+	if _, err := e.Get("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found but got %v", err)
+	}
+	if names, _ := e.List(); !reflect.DeepEqual(names, []string{"gh_token"}) {
+		t.Errorf("expected gh_token but got %v", names)
+	}
+	if err := e.Set("a", "b"); err == nil {
+		t.Errorf("expected env backend to be read only")
+	}
+}
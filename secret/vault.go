@@ -0,0 +1,219 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Key derivation settings for the vault
+const (
+	vaultVersion    = 1
+	vaultIterations = 200000
+	vaultKeySize    = 32
+	vaultSaltSize   = 16
+)
+
+// vaultFile as stored on disk with secrets encrypted as a JSON object
+type vaultFile struct {
+	Version    int    `json:"version"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Data       []byte `json:"data"`
+}
+
+// vault of secrets in a local file encrypted with AES-GCM using a key
+// derived from a passphrase
+type vault struct {
+	path       string
+	passphrase func(create bool) (string, error)
+
+	// secrets once unlocked
+	secrets map[string]string
+	// key the vault was unlocked with
+	key string
+}
+
+func newVault(opts *Options) (Backend, error) {
+	if opts == nil || len(opts.Path) == 0 {
+		return nil, fmt.Errorf("vault path is required")
+	}
+	if opts.Passphrase == nil {
+		return nil, fmt.Errorf("vault passphrase is required")
+	}
+	return &vault{path: opts.Path, passphrase: opts.Passphrase}, nil
+}
+
+func (v *vault) Get(name string) (string, error) {
+	if !v.exists() {
+		return "", ErrNotFound
+	}
+	if err := v.unlock(); err != nil {
+		return "", err
+	}
+	value, ok := v.secrets[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func (v *vault) Set(name, value string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	if err := v.unlock(); err != nil {
+		return err
+	}
+	v.secrets[name] = value
+	return v.save()
+}
+
+// List names of secrets which unlocks the vault since names are encrypted
+// too
+func (v *vault) List() ([]string, error) {
+	if !v.exists() {
+		return []string{}, nil
+	}
+	if err := v.unlock(); err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for name := range v.secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (v *vault) Remove(name string) error {
+	if !v.exists() {
+		return ErrNotFound
+	}
+	if err := v.unlock(); err != nil {
+		return err
+	}
+	if _, ok := v.secrets[name]; !ok {
+		return ErrNotFound
+	}
+	delete(v.secrets, name)
+	return v.save()
+}
+
+func (v *vault) exists() bool {
+	_, err := os.Stat(v.path)
+	return err == nil
+}
+
+// unlock the vault decrypting secrets or starting empty if it doesn't exist
+func (v *vault) unlock() error {
+	if v.secrets != nil {
+		return nil
+	}
+
+	create := !v.exists()
+	key, err := v.passphrase(create)
+	if err != nil {
+		return err
+	}
+	if len(key) == 0 {
+		return fmt.Errorf("vault passphrase cannot be empty")
+	}
+
+	if create {
+		v.secrets, v.key = map[string]string{}, key
+		return nil
+	}
+
+	b, err := ioutil.ReadFile(v.path)
+	if err != nil {
+		return err
+	}
+	f := &vaultFile{}
+	if err := json.Unmarshal(b, f); err != nil {
+		return fmt.Errorf("invalid vault %s: %s", v.path, err)
+	}
+	if f.Version != vaultVersion {
+		return fmt.Errorf("unsupported vault version %d", f.Version)
+	}
+
+	gcm, err := newGCM(key, f.Salt, f.Iterations)
+	if err != nil {
+		return err
+	}
+	data, err := gcm.Open(nil, f.Nonce, f.Data, nil)
+	if err != nil {
+		return fmt.Errorf("unable to unlock vault, invalid passphrase or key")
+	}
+
+	secrets := map[string]string{}
+	if err := json.Unmarshal(data, &secrets); err != nil {
+		return fmt.Errorf("invalid vault %s: %s", v.path, err)
+	}
+	v.secrets, v.key = secrets, key
+	return nil
+}
+
+// save secrets encrypted with a new salt and nonce
+func (v *vault) save() error {
+	data, err := json.Marshal(v.secrets)
+	if err != nil {
+		return err
+	}
+
+	f := &vaultFile{
+		Version:    vaultVersion,
+		Iterations: vaultIterations,
+		Salt:       make([]byte, vaultSaltSize),
+	}
+	if _, err := rand.Read(f.Salt); err != nil {
+		return err
+	}
+	gcm, err := newGCM(v.key, f.Salt, f.Iterations)
+	if err != nil {
+		return err
+	}
+	f.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(f.Nonce); err != nil {
+		return err
+	}
+	f.Data = gcm.Seal(nil, f.Nonce, data, nil)
+
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so the vault is never left partially
+	// written
+	if err := os.MkdirAll(filepath.Dir(v.path), 0700); err != nil {
+		return err
+	}
+	tmp := v.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, v.path)
+}
+
+// newGCM cipher with a key derived from the passphrase
+func newGCM(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	if iterations <= 0 {
+		return nil, fmt.Errorf("invalid vault iterations %d", iterations)
+	}
+	block, err := aes.NewCipher(pbkdf2.Key([]byte(passphrase), salt, iterations, vaultKeySize, sha256.New))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
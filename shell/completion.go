@@ -9,6 +9,14 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// Completion requests in generated scripts that call the program itself
+var completionRequests = map[string]string{
+	"${words[0]} __complete ${args[*]}":         "nostromo __complete run ${words[0]} ${args[*]}",
+	"${words[1]} __complete ${words[2,-1]}":     "nostromo __complete run ${words[1]} ${words[2,-1]}",
+	"$args[1] __complete $args[2..-1] $lastArg": "nostromo __complete run $args[1] $args[2..-1] $lastArg",
+	"$Program __complete $Arguments":            "nostromo __complete run $Program $Arguments",
+}
+
 // CobraCompleter interface for types that can generate a cobra.Command
 type CobraCompleter interface {
 	CobraCommand() *cobra.Command
@@ -34,14 +42,39 @@ func Completion(sh string, cmd *cobra.Command) (string, error) {
 		return "", err
 	}
 
+	// Commands from manifests are completed by nostromo under `run`
 	s := buf.String()
 	if cmd.Name() != "nostromo" {
-		s = strings.ReplaceAll(s, "${words[1]} __complete ${words[2,-1]}", "nostromo __complete run ${words[1]} ${words[2,-1]}")
+		for request, replacement := range completionRequests {
+			s = strings.ReplaceAll(s, request, replacement)
+		}
 	}
 
 	return s, nil
 }
 
+// InitScript generates the init script for a shell with completions for
+// nostromo and, if provided, functions and completions for the spaceport
+func InitScript(sh string, cmd *cobra.Command, s *model.Spaceport) (string, error) {
+	script, err := Completion(sh, cmd)
+	if err != nil {
+		return "", err
+	}
+	if s == nil {
+		return script, nil
+	}
+
+	completions, err := SpaceportCompletion(sh, s)
+	if err != nil {
+		return "", err
+	}
+	for _, completion := range completions {
+		script += "\n" + completion
+	}
+
+	return script, nil
+}
+
 // SpaceportCompletion scripts for all manifests
 func SpaceportCompletion(sh string, s *model.Spaceport) ([]string, error) {
 	var completions []string
@@ -59,11 +92,12 @@ func SpaceportCompletion(sh string, s *model.Spaceport) ([]string, error) {
 // ManifestCompletion scripts for a manifest
 func ManifestCompletion(sh string, m *model.Manifest) ([]string, error) {
 	var completions []string
-	completions = append(completions, shellAliasFuncs(m))
-	for _, cmd := range m.Commands {
-		// Skip completion scripts for leaf nodes or pure aliases.
+	completions = append(completions, shellAliasFuncs(sh, m))
+	for _, cmd := range m.SortedCommands() {
+		// Skip completion scripts for leaf nodes without completion sources
+		// or pure aliases.
 		// This allows for it to fallback to the shell's lookups.
-		if cmd.AliasOnly || len(cmd.Commands) == 0 {
+		if cmd.AliasOnly || !cmd.HasCompletions() {
 			continue
 		}
 		s, err := CommandCompletion(sh, cmd)
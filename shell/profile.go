@@ -0,0 +1,68 @@
+package shell
+
+import (
+	"github.com/pokanop/nostromo/model"
+)
+
+// ProfileStatus of a shell initialization file
+type ProfileStatus struct {
+	Path      string
+	Target    string
+	Shell     string
+	Preferred bool
+	Installed bool
+	Problem   string
+}
+
+// Status of all shell initialization files that nostromo manages
+//
+// Files with problems, like a malformed nostromo section or a broken
+// symlink, are included with the problem described.
+func Status(cfg *model.Config) []*ProfileStatus {
+	var statuses []*ProfileStatus
+	for _, f := range scanStartupFiles(cfg) {
+		status := &ProfileStatus{
+			Path:      f.path,
+			Shell:     f.shell(),
+			Preferred: f.preferred,
+			Installed: !f.pristine,
+		}
+		if f.problem != nil {
+			status.Problem = f.problem.Error()
+		}
+		if f.isSymlink() {
+			target, err := f.realPath()
+			if err != nil {
+				status.Problem = err.Error()
+			}
+			status.Target = target
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// Keys as ordered list of fields for logging
+func (p *ProfileStatus) Keys() []string {
+	return []string{"path", "target", "shell", "preferred", "installed", "problem"}
+}
+
+// Fields interface for logging
+func (p *ProfileStatus) Fields() map[string]interface{} {
+	return map[string]interface{}{
+		"path":      p.Path,
+		"target":    p.Target,
+		"shell":     p.Shell,
+		"preferred": p.Preferred,
+		"installed": p.Installed,
+		"problem":   p.Problem,
+	}
+}
+
+// startupFilenameHints for error messages about missing startup files
+func startupFilenameHints(cfg *model.Config) []string {
+	if cfg != nil && len(cfg.StartupFiles) > 0 {
+		return cfg.StartupFiles
+	}
+	return preferredFilenames
+}
@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/pokanop/nostromo/config"
 	"github.com/pokanop/nostromo/log"
 	"github.com/pokanop/nostromo/model"
+	"github.com/pokanop/nostromo/stringutil"
 )
 
 // Supported shells
@@ -16,12 +18,9 @@ const (
 	Powershell = "powershell"
 )
 
-var validLanguages = []string{"sh", "ruby", "python", "perl", "js"}
+var validShells = []string{Bash, Zsh, Fish, Powershell}
 
-var (
-	initFiles = loadStartupFiles()
-	prefFiles = preferredStartupFiles(initFiles)
-)
+var validLanguages = []string{"sh", "ruby", "python", "perl", "js"}
 
 // EvalString returns the command as a string to evaluate or an error.
 func EvalString(command, language string, verbose bool) (string, error) {
@@ -39,13 +38,77 @@ func EvalString(command, language string, verbose bool) (string, error) {
 	return cmdStr, nil
 }
 
+// EnvEvalString runs cmdStr in a subshell with env variables like
+// `KEY=value` exported so they don't leak into the calling shell
+func EnvEvalString(cmdStr string, env []string) string {
+	if len(env) == 0 {
+		return cmdStr
+	}
+
+	var b strings.Builder
+	b.WriteString("(")
+	for _, kv := range env {
+		kv := strings.SplitN(kv, "=", 2)
+		fmt.Fprintf(&b, "export %s=%s; ", kv[0], stringutil.SingleQuote(kv[1]))
+	}
+	b.WriteString(cmdStr)
+	b.WriteString("\n)")
+	return b.String()
+}
+
+// RecordEvalString records the command at args in history from the shell
+// without any output
+func RecordEvalString(args []string) string {
+	quoted := []string{"command", "nostromo", "record"}
+	for _, arg := range args {
+		quoted = append(quoted, stringutil.SingleQuote(arg))
+	}
+	return strings.Join(quoted, " ") + " >/dev/null 2>&1"
+}
+
+// ConfirmEvalString asks on the terminal before running cmdStr in the
+// calling shell and fails without running it unless confirmed
+//
+// The answer is read in a subshell so it doesn't leak into the calling
+// shell.
+func ConfirmEvalString(cmdStr string, confirm *model.Confirm) string {
+	check := `case "$__nostromo_reply" in y|Y|yes|Yes) exit 0;; esac; exit 1`
+	if len(confirm.Phrase) > 0 {
+		check = fmt.Sprintf(`test "$__nostromo_reply" = %s`, stringutil.SingleQuote(confirm.Phrase))
+	}
+	ask := fmt.Sprintf("printf '%%s: ' %s >&2; read -r __nostromo_reply </dev/tty || exit 1; %s", stringutil.SingleQuote(confirm.Prompt()), check)
+	cmdStr = strings.TrimRight(strings.TrimSpace(cmdStr), ";")
+	return fmt.Sprintf("if (%s); then { %s; }; else echo 'nostromo: not confirmed' >&2; (exit 1); fi", ask, cmdStr)
+}
+
+// IsPOSIX returns true if sh can evaluate the POSIX constructs nostromo
+// resolves commands to
+func IsPOSIX(sh string) bool {
+	return sh != Fish && sh != Powershell
+}
+
+// ExecEvalString runs the command at args with `nostromo exec` for shells
+// that can't evaluate how nostromo resolves it
+func ExecEvalString(sh string, args []string, yes bool) string {
+	quoted := []string{"__nostromo_cmd", "exec"}
+	if yes {
+		quoted = append(quoted, "--yes")
+	}
+	for _, arg := range args {
+		quoted = append(quoted, quoteFor(sh, arg))
+	}
+	return strings.Join(quoted, " ")
+}
+
 // Commit manifest updates to shell initialization files
 //
 // Loads all shell config files and replaces nostromo aliases
-// with manifest's commands.
+// with manifest's commands. Startup files, backups and preferred
+// shells are taken from the manifest's config.
 func Commit(manifest *model.Manifest) error {
-	if len(prefFiles) == 0 {
-		return fmt.Errorf("could not find preferred init file [%s]", strings.Join(preferredFilenames, ", "))
+	initFiles := loadStartupFiles(manifest.Config)
+	if len(preferredStartupFiles(initFiles)) == 0 {
+		return fmt.Errorf("could not find preferred init file [%s]", strings.Join(startupFilenameHints(manifest.Config), ", "))
 	}
 
 	for _, f := range initFiles {
@@ -64,10 +127,50 @@ func Commit(manifest *model.Manifest) error {
 	return nil
 }
 
+// Uninstall removes nostromo from all shell initialization files
+func Uninstall(cfg *model.Config) error {
+	for _, f := range loadStartupFiles(cfg) {
+		if f.pristine {
+			continue
+		}
+
+		content, err := f.contentOmitted()
+		if err != nil {
+			return err
+		}
+		f.updatedContent = content
+
+		if err := f.commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Repair malformed nostromo sections in shell initialization files
+//
+// Stray section markers are removed so nostromo can be installed again.
+// Returns the paths of repaired files.
+func Repair(cfg *model.Config) ([]string, error) {
+	var repaired []string
+	for _, f := range scanStartupFiles(cfg) {
+		if f.problem == nil {
+			continue
+		}
+
+		f.updatedContent = f.contentRepaired()
+		if err := f.commit(); err != nil {
+			return repaired, err
+		}
+		repaired = append(repaired, f.path)
+	}
+	return repaired, nil
+}
+
 // InitFileLines returns the shell initialization file lines
-func InitFileLines() string {
+func InitFileLines(cfg *model.Config) string {
 	var s string
-	for _, prefFile := range prefFiles {
+	for _, prefFile := range preferredStartupFiles(loadStartupFiles(cfg)) {
 		s += fmt.Sprintf("|%s|", prefFile.name())
 		c, err := prefFile.contentBlock()
 		if err == nil {
@@ -77,6 +180,26 @@ func InitFileLines() string {
 	return s
 }
 
+// StartupFileContents of shell initialization files without the nostromo
+// section keyed by path
+func StartupFileContents(cfg *model.Config) map[string]string {
+	contents := map[string]string{}
+	for _, f := range loadStartupFiles(cfg) {
+		content, err := f.contentOmitted()
+		if err != nil {
+			log.Debugf("could not read %s: %s\n", f.path, err)
+			continue
+		}
+		contents[f.path] = content
+	}
+	return contents
+}
+
+// SupportedShells that init files are generated for
+func SupportedShells() []string {
+	return validShells
+}
+
 // SupportedLanguages that can be executed
 func SupportedLanguages() []string {
 	return validLanguages
@@ -110,24 +233,52 @@ func buildEvalCmd(cmd, language string) string {
 }
 
 func shellWrapperFunc(sh string) string {
-	// Sources completion scripts after each command in case something changes
-	return fmt.Sprintf("__nostromo_cmd() { command nostromo \"$@\"; }\nnostromo() { __nostromo_cmd \"$@\" && eval \"$(__nostromo_cmd completion %s)\"; }", sh)
+	// Sources the generated init file after each command in case something
+	// changed, the file is only regenerated when manifests change
+	initFile := config.InitFile(sh)
+	switch sh {
+	case Fish:
+		return fmt.Sprintf("function __nostromo_cmd; command nostromo $argv; end\nfunction nostromo; __nostromo_cmd $argv; and source \"%s\"; end", initFile)
+	case Powershell:
+		return fmt.Sprintf("function __nostromo_cmd { & (Get-Command nostromo -CommandType Application | Select-Object -First 1) @args }\nfunction nostromo { __nostromo_cmd @args; if ($LASTEXITCODE -eq 0) { . \"%s\" } }", initFile)
+	}
+	return fmt.Sprintf("__nostromo_cmd() { command nostromo \"$@\"; }\nnostromo() { __nostromo_cmd \"$@\" && source \"%s\"; }", initFile)
 }
 
-func shellAliasFuncs(m *model.Manifest) string {
+func shellAliasFuncs(sh string, m *model.Manifest) string {
 	var aliases []string
-	for _, c := range m.Commands {
+	for _, c := range m.SortedCommands() {
 		var alias string
 		if c.AliasOnly {
-			alias = fmt.Sprintf("alias %s='%s'", c.Alias, c.Name)
+			alias = shellAlias(sh, c.Alias, c.Name)
 		} else {
 			// This will generate a shell command provided to the completion script
 			// generation. When users run a command, it actually runs `eval` on
 			// the result of `nostromo eval` with arguments resolved.
-			cmd := fmt.Sprintf("__nostromo_cmd eval %s \"$@\"", c.Alias)
-			alias = strings.TrimSpace(fmt.Sprintf("%s() { eval $(%s); }", c.Alias, cmd))
+			alias = shellEvalFunc(sh, c.Alias)
 		}
 		aliases = append(aliases, alias)
 	}
 	return fmt.Sprintf("\n%s\n", strings.Join(aliases, "\n"))
 }
+
+func shellAlias(sh, alias, command string) string {
+	switch sh {
+	case Fish:
+		return fmt.Sprintf("alias %s '%s'", alias, command)
+	case Powershell:
+		return fmt.Sprintf("function %s { Invoke-Expression \"%s $args\" }", alias, command)
+	}
+	return fmt.Sprintf("alias %s='%s'", alias, command)
+}
+
+func shellEvalFunc(sh, alias string) string {
+	switch sh {
+	case Fish:
+		return fmt.Sprintf("function %s; __nostromo_cmd eval --shell=%s %s $argv | source; end", alias, Fish, alias)
+	case Powershell:
+		return fmt.Sprintf("function %s { __nostromo_cmd eval --shell=%s %s @args | Out-String | Invoke-Expression }", alias, Powershell, alias)
+	}
+	cmd := fmt.Sprintf("__nostromo_cmd eval %s \"$@\"", alias)
+	return strings.TrimSpace(fmt.Sprintf("%s() { eval $(%s); }", alias, cmd))
+}
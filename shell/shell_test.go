@@ -2,6 +2,7 @@ package shell
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/pokanop/nostromo/config"
@@ -79,33 +80,128 @@ func TestEvalString(t *testing.T) {
 	}
 }
 
+func TestEnvEvalString(t *testing.T) {
+	tests := []struct {
+		name string
+		env  []string
+		want string
+	}{
+		{"no env", nil, "echo $A"},
+		{"env", []string{"A=it's", "B=x=y"}, "(export A='it'\\''s'; export B='x=y'; echo $A\n)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EnvEvalString("echo $A", tt.env); got != tt.want {
+				t.Errorf("EnvEvalString() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordEvalString(t *testing.T) {
+	want := `command nostromo record 'db' 'drop' 'it'\''s' >/dev/null 2>&1`
+	if got := RecordEvalString([]string{"db", "drop", "it's"}); got != want {
+		t.Errorf("RecordEvalString() got = %v, want %v", got, want)
+	}
+}
+
+func TestExecEvalString(t *testing.T) {
+	tests := []struct {
+		name string
+		sh   string
+		yes  bool
+		want string
+	}{
+		{"bash", Bash, false, `__nostromo_cmd exec 'ci' 'it'\''s \'`},
+		{"zsh", Zsh, true, `__nostromo_cmd exec --yes 'ci' 'it'\''s \'`},
+		{"fish", Fish, false, `__nostromo_cmd exec 'ci' 'it\'s \\'`},
+		{"powershell", Powershell, true, `__nostromo_cmd exec --yes 'ci' 'it''s \'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExecEvalString(tt.sh, []string{"ci", `it's \`}, tt.yes); got != tt.want {
+				t.Errorf("ExecEvalString() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfirmEvalString(t *testing.T) {
+	tests := []struct {
+		name    string
+		confirm *model.Confirm
+		want    string
+	}{
+		{
+			"yes",
+			&model.Confirm{Message: "drop?"},
+			`if (printf '%s: ' 'drop? [y/N]' >&2; read -r __nostromo_reply </dev/tty || exit 1; case "$__nostromo_reply" in y|Y|yes|Yes) exit 0;; esac; exit 1); then { dropdb app; }; else echo 'nostromo: not confirmed' >&2; (exit 1); fi`,
+		},
+		{
+			"phrase",
+			&model.Confirm{Message: "drop?", Phrase: "it's prod"},
+			`if (printf '%s: ' 'drop? type '\''it'\''s prod'\'' to continue' >&2; read -r __nostromo_reply </dev/tty || exit 1; test "$__nostromo_reply" = 'it'\''s prod'); then { dropdb app; }; else echo 'nostromo: not confirmed' >&2; (exit 1); fi`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ConfirmEvalString("dropdb app;\n", tt.confirm); got != tt.want {
+				t.Errorf("ConfirmEvalString() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestShellWrapperFunc(t *testing.T) {
-	if shellWrapperFunc("zsh") != `__nostromo_cmd() { command nostromo "$@"; }
-nostromo() { __nostromo_cmd "$@" && eval "$(__nostromo_cmd completion zsh)"; }` {
-		t.Errorf("shell wrapper func not as expected")
+	tests := []struct {
+		name     string
+		sh       string
+		expected string
+	}{
+		{"zsh", "zsh", `__nostromo_cmd() { command nostromo "$@"; }
+nostromo() { __nostromo_cmd "$@" && source "` + config.InitFile("zsh") + `"; }`},
+		{"fish", "fish", `function __nostromo_cmd; command nostromo $argv; end
+function nostromo; __nostromo_cmd $argv; and source "` + config.InitFile("fish") + `"; end`},
+		{"powershell", "powershell", `function __nostromo_cmd { & (Get-Command nostromo -CommandType Application | Select-Object -First 1) @args }
+function nostromo { __nostromo_cmd @args; if ($LASTEXITCODE -eq 0) { . "` + config.InitFile("powershell") + `" } }`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := shellWrapperFunc(tt.sh); actual != tt.expected {
+				t.Errorf("shell wrapper func incorrect expected: %s, actual: %s", tt.expected, actual)
+			}
+		})
 	}
 }
 
-// func TestShellAliasFuncs(t *testing.T) {
-// 	tests := []struct {
-// 		name     string
-// 		manifest *model.Manifest
-// 		expected string
-// 	}{
-// 		{"no alias", fakeManifest(false), "\none() { eval $(__nostromo_cmd eval one \"$*\"); }\ntwo() { eval $(__nostromo_cmd eval two \"$*\"); }\n"},
-// 		{"alias only", fakeManifest(true), ""},
-// 	}
-
-// 	for _, tt := range tests {
-// 		t.Run(tt.name, func(t *testing.T) {
-// 			actual := shellAliasFuncs(tt.manifest)
-// 			fmt.Printf("<start>%s<end>", actual)
-// 			if tt.expected != actual {
-// 				t.Errorf("shell alias funcs incorrect expected: %s, actual: %s", tt.expected, actual)
-// 			}
-// 		})
-// 	}
-// }
+func TestShellAliasFuncs(t *testing.T) {
+	tests := []struct {
+		name     string
+		sh       string
+		manifest *model.Manifest
+		expected string
+	}{
+		{"commands", "zsh", fakeManifest(false), "\none() { eval $(__nostromo_cmd eval one \"$@\"); }\ntwo() { eval $(__nostromo_cmd eval two \"$@\"); }\n"},
+		{"ordered commands", "bash", fakeOrderedManifest(), "\nzeta() { eval $(__nostromo_cmd eval zeta \"$@\"); }\nalpha() { eval $(__nostromo_cmd eval alpha \"$@\"); }\nbeta() { eval $(__nostromo_cmd eval beta \"$@\"); }\n"},
+		{"aliases", "bash", fakeAliasManifest(), "\nalias ls='ls -la'\n"},
+		{"fish commands", "fish", fakeManifest(false), "\nfunction one; __nostromo_cmd eval --shell=fish one $argv | source; end\nfunction two; __nostromo_cmd eval --shell=fish two $argv | source; end\n"},
+		{"fish aliases", "fish", fakeAliasManifest(), "\nalias ls 'ls -la'\n"},
+		{"powershell commands", "powershell", fakeManifest(false), "\nfunction one { __nostromo_cmd eval --shell=powershell one @args | Out-String | Invoke-Expression }\nfunction two { __nostromo_cmd eval --shell=powershell two @args | Out-String | Invoke-Expression }\n"},
+		{"powershell aliases", "powershell", fakeAliasManifest(), "\nfunction ls { Invoke-Expression \"ls -la $args\" }\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Output must be stable across runs
+			for i := 0; i < 10; i++ {
+				if actual := shellAliasFuncs(tt.sh, tt.manifest); tt.expected != actual {
+					t.Fatalf("shell alias funcs incorrect expected: %s, actual: %s", tt.expected, actual)
+				}
+			}
+		})
+	}
+}
 
 func fakeManifest(aliasOnly bool) *model.Manifest {
 	m, _ := config.NewCoreManifest()
@@ -115,3 +211,45 @@ func fakeManifest(aliasOnly bool) *model.Manifest {
 	m.AddCommand("two", "command", "", &model.Code{}, false, "concatenate")
 	return m
 }
+
+func fakeAliasManifest() *model.Manifest {
+	m, _ := config.NewCoreManifest()
+	m.AddCommand("ls", "ls -la", "", &model.Code{}, true, "concatenate")
+	return m
+}
+
+func fakeOrderedManifest() *model.Manifest {
+	m, _ := config.NewCoreManifest()
+	m.AddCommand("beta", "command", "", &model.Code{}, false, "concatenate")
+	m.AddCommand("alpha", "command", "", &model.Code{}, false, "concatenate")
+	m.AddCommand("zeta", "command", "", &model.Code{}, false, "concatenate")
+	m.Find("zeta").Order = -1
+	return m
+}
+
+func TestCommandCompletion(t *testing.T) {
+	m := fakeManifest(false)
+	m.Link()
+
+	tests := []struct {
+		sh       string
+		expected string
+	}{
+		{"bash", "nostromo __complete run ${words[0]} ${args[*]}"},
+		{"zsh", "nostromo __complete run ${words[1]} ${words[2,-1]}"},
+		{"fish", "nostromo __complete run $args[1] $args[2..-1] $lastArg"},
+		{"powershell", "nostromo __complete run $Program $Arguments"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.sh, func(t *testing.T) {
+			s, err := CommandCompletion(test.sh, m.Find("one"))
+			if err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+			if !strings.Contains(s, test.expected) {
+				t.Errorf("expected completion request %s", test.expected)
+			}
+		})
+	}
+}
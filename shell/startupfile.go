@@ -8,21 +8,37 @@ import (
 	"strings"
 	"time"
 
+	"github.com/pokanop/nostromo/config"
 	"github.com/pokanop/nostromo/log"
 	"github.com/pokanop/nostromo/model"
 	"github.com/pokanop/nostromo/pathutil"
 )
 
 const (
-	beginBlockComment    = "# nostromo [section begin]"
-	endBlockComment      = "# nostromo [section end]"
-	bashSourceCompletion = "source <(nostromo completion bash)"
-	zshSourceCompletion  = "autoload -U compinit; compinit\nsource <(nostromo completion zsh)"
+	beginBlockComment  = "# nostromo [section begin]"
+	endBlockComment    = "# nostromo [section end]"
+	bashSourceInitFile = "source \"%s\""
+	zshSourceInitFile  = "autoload -U compinit; compinit\nsource \"%s\""
+	fishSourceInitFile = "source \"%s\""
+	pwshSourceInitFile = ". \"%s\""
+)
+
+const (
+	configDirPrefix    = ".config/"
+	fishFilename       = ".config/fish/conf.d/nostromo.fish"
+	powershellFilename = ".config/powershell/Microsoft.PowerShell_profile.ps1"
 )
 
 var (
-	startupFilenames   = []string{".profile", ".bash_profile", ".bashrc", ".zshrc"}
-	preferredFilenames = []string{".bashrc", ".zshrc"}
+	startupFilenames   = []string{".profile", ".bash_profile", ".bashrc", ".zshrc", fishFilename, powershellFilename}
+	preferredFilenames = []string{".bashrc", ".zshrc", filepath.Base(fishFilename), filepath.Base(powershellFilename)}
+
+	// Files that are created if missing as long as their shell's config
+	// folder exists
+	creatableFilenames = map[string]string{
+		fishFilename:       ".config/fish",
+		powershellFilename: ".config/powershell",
+	}
 )
 
 type startupFile struct {
@@ -30,6 +46,8 @@ type startupFile struct {
 	mode           os.FileMode
 	content        string
 	updatedContent string
+	backupDir      string
+	problem        error
 	commands       map[string]*model.Command
 	preferred      bool
 	pristine       bool
@@ -44,26 +62,75 @@ func isPreferredFilename(filename string) bool {
 	return false
 }
 
-func loadStartupFiles() []*startupFile {
+// loadStartupFiles configured in cfg or the default ones if not set
+//
+// Files that can't be parsed are skipped.
+func loadStartupFiles(cfg *model.Config) []*startupFile {
 	var files []*startupFile
-	for _, n := range startupFilenames {
-		path, mode, err := findStartupFile(n)
+	for _, s := range scanStartupFiles(cfg) {
+		if s.problem != nil {
+			log.Debugf("could not parse %s: %s\n", s.path, s.problem)
+			continue
+		}
+		files = append(files, s)
+	}
+	return files
+}
+
+// scanStartupFiles configured in cfg or the default ones if not set
+// including files with problems
+func scanStartupFiles(cfg *model.Config) []*startupFile {
+	find := findStartupFile
+	names := startupFilenames
+	configured := cfg != nil && len(cfg.StartupFiles) > 0
+	if configured {
+		find = findConfiguredStartupFile
+		names = cfg.StartupFiles
+	}
+
+	var files []*startupFile
+	for _, n := range names {
+		path, mode, err := find(n)
 		if err != nil {
 			log.Debugf("could not find %s: %s\n", n, err)
 			continue
 		}
 
 		s, err := parseStartupFile(path, mode)
-		if err != nil {
-			log.Debugf("could not parse %s: %s\n", n, err)
+		if s == nil {
+			log.Debugf("could not read %s: %s\n", n, err)
 			continue
 		}
+		s.problem = err
+
+		// Configured files are always preferred unless their shell isn't
+		s.preferred = (configured || s.preferred) && isPreferredShell(cfg, s.shell())
+		s.backupDir = startupBackupDir(cfg)
 
 		files = append(files, s)
 	}
 	return files
 }
 
+func isPreferredShell(cfg *model.Config, sh string) bool {
+	if cfg == nil || len(cfg.PreferredShells) == 0 {
+		return true
+	}
+	for _, preferred := range cfg.PreferredShells {
+		if preferred == sh {
+			return true
+		}
+	}
+	return false
+}
+
+func startupBackupDir(cfg *model.Config) string {
+	if cfg == nil || len(cfg.BackupDir) == 0 {
+		return config.BackupsDir()
+	}
+	return pathutil.Abs(cfg.BackupDir)
+}
+
 func preferredStartupFiles(files []*startupFile) []*startupFile {
 	var p []*startupFile
 	for _, s := range files {
@@ -75,23 +142,41 @@ func preferredStartupFiles(files []*startupFile) []*startupFile {
 }
 
 func findStartupFile(name string) (string, os.FileMode, error) {
-	home, err := pathutil.HomeDir()
+	path, err := startupFilePath(name)
 	if err != nil {
 		return "", 0, err
 	}
 
-	// zsh doesn't always have a ~/.zshrc file, and if it doesn't,
-	// it does have a $ZDOTDIR/.zshrc
-	// https://wiki.archlinux.org/index.php/Zsh#Startup.2FShutdown_files
-	zdotDir := os.Getenv("ZDOTDIR")
-	var path string
-	if zdotDir != "" {
-		path = filepath.Join(zdotDir, name)
-	} else {
-		path = filepath.Join(home, name)
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		if dir, ok := creatableFilenames[name]; ok {
+			dirPath, dirErr := startupFilePath(dir)
+			if dirErr != nil {
+				return "", 0, dirErr
+			}
+			if _, dirErr = os.Stat(dirPath); dirErr == nil {
+				return path, 0644, nil
+			}
+		}
+	}
+	if err != nil {
+		return "", 0, err
 	}
 
+	return path, info.Mode(), nil
+}
+
+// findConfiguredStartupFile at path which is created if missing as long
+// as its folder exists
+func findConfiguredStartupFile(path string) (string, os.FileMode, error) {
+	path = pathutil.Abs(path)
+
 	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		if _, dirErr := os.Stat(filepath.Dir(path)); dirErr == nil {
+			return path, 0644, nil
+		}
+	}
 	if err != nil {
 		return "", 0, err
 	}
@@ -99,22 +184,42 @@ func findStartupFile(name string) (string, os.FileMode, error) {
 	return path, info.Mode(), nil
 }
 
-func parseStartupFile(path string, mode os.FileMode) (*startupFile, error) {
-	f, err := os.Open(pathutil.Abs(path))
+func startupFilePath(name string) (string, error) {
+	home, err := pathutil.HomeDir()
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	defer f.Close()
 
-	b, err := ioutil.ReadAll(f)
-	if err != nil {
+	// fish and PowerShell keep their files in the XDG config folder
+	if strings.HasPrefix(name, configDirPrefix) {
+		configDir := os.Getenv("XDG_CONFIG_HOME")
+		if configDir == "" {
+			configDir = filepath.Join(home, configDirPrefix)
+		}
+		return filepath.Join(configDir, strings.TrimPrefix(name, configDirPrefix)), nil
+	}
+
+	// zsh doesn't always have a ~/.zshrc file, and if it doesn't,
+	// it does have a $ZDOTDIR/.zshrc
+	// https://wiki.archlinux.org/index.php/Zsh#Startup.2FShutdown_files
+	zdotDir := os.Getenv("ZDOTDIR")
+	if zdotDir != "" {
+		return filepath.Join(zdotDir, name), nil
+	}
+	return filepath.Join(home, name), nil
+}
+
+func parseStartupFile(path string, mode os.FileMode) (*startupFile, error) {
+	// Missing files are created on commit
+	b, err := ioutil.ReadFile(pathutil.Abs(path))
+	if err != nil && !os.IsNotExist(err) {
 		return nil, err
 	}
 
 	s := newStartupFile(path, string(b), mode)
 	err = s.parse()
 	if err != nil {
-		return nil, err
+		return s, err
 	}
 
 	return s, nil
@@ -135,7 +240,13 @@ func (s *startupFile) name() string {
 }
 
 func (s *startupFile) shell() string {
-	if strings.Contains(s.path, ".zshrc") {
+	name := s.name()
+	switch {
+	case strings.HasSuffix(name, ".fish"):
+		return Fish
+	case strings.HasSuffix(name, ".ps1"):
+		return Powershell
+	case strings.Contains(name, "zsh") || filepath.Base(filepath.Dir(s.path)) == "zsh":
 		return Zsh
 	}
 	return Bash
@@ -193,21 +304,87 @@ func (s *startupFile) commit() error {
 		return fmt.Errorf("commit now allowed")
 	}
 
-	// Save a timestamped backup
-	ts := time.Now().UTC().Format("20060102150405")
-	backupPath := filepath.Join("/tmp", filepath.Base(s.path)) + "_" + ts
-	err := ioutil.WriteFile(backupPath, []byte(s.content), s.mode)
+	// Edit the real file so symlinks from dotfile managers are kept
+	path, err := s.realPath()
 	if err != nil {
 		return err
 	}
 
-	// Save changes
-	err = ioutil.WriteFile(pathutil.Abs(s.path), []byte(s.updatedContent), s.mode)
+	// Save a timestamped backup of existing content
+	if len(s.content) > 0 {
+		if err := s.backup(path); err != nil {
+			return err
+		}
+	}
+
+	// Save changes, creating the parent folder for new files
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, []byte(s.updatedContent), s.perm())
+}
+
+// realPath of the startup file with symlinks resolved
+func (s *startupFile) realPath() (string, error) {
+	path := pathutil.Abs(s.path)
+	realPath, err := filepath.EvalSymlinks(path)
+	if os.IsNotExist(err) {
+		if target, linkErr := os.Readlink(path); linkErr == nil {
+			return "", fmt.Errorf("broken symlink %s -> %s", path, target)
+		}
+		return path, nil
+	}
+	return realPath, err
+}
+
+func (s *startupFile) isSymlink() bool {
+	info, err := os.Lstat(pathutil.Abs(s.path))
+	return err == nil && info.Mode()&os.ModeSymlink != 0
+}
+
+func (s *startupFile) backup(path string) error {
+	dir := s.backupDir
+	if len(dir) == 0 {
+		dir = config.BackupsDir()
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	ts := time.Now().UTC().Format("20060102150405")
+	backupPath := filepath.Join(dir, filepath.Base(path)) + "_" + ts
+	return ioutil.WriteFile(backupPath, []byte(s.content), s.perm())
+}
+
+func (s *startupFile) perm() os.FileMode {
+	if perm := s.mode.Perm(); perm != 0 {
+		return perm
+	}
+	return 0644
+}
+
+// writeFileAtomic by renaming a temporary file over path so a failed write
+// never leaves a truncated file behind
+func writeFileAtomic(path string, b []byte, perm os.FileMode) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".nostromo")
 	if err != nil {
 		return err
 	}
+	defer os.Remove(f.Name())
 
-	return nil
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(f.Name(), perm); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
 }
 
 func (s *startupFile) contentOmitted() (string, error) {
@@ -249,6 +426,10 @@ func (s *startupFile) contentIndexes() (int, int) {
 		// Malformed block
 		return start, end
 	}
+	if end < start {
+		// Malformed block with markers out of order
+		return start, -1
+	}
 
 	// Return adjusted indexes
 	start--
@@ -262,10 +443,33 @@ func (s *startupFile) contentIndexes() (int, int) {
 	return start, end
 }
 
+// contentRepaired with stray nostromo section markers removed
+func (s *startupFile) contentRepaired() string {
+	var lines []string
+	for _, line := range strings.SplitAfter(s.content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == beginBlockComment || trimmed == endBlockComment {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "")
+}
+
 func (s *startupFile) makeNostromoBlock() string {
-	sourceCompletion := bashSourceCompletion
-	if s.shell() == Zsh {
-		sourceCompletion = zshSourceCompletion
+	// Profiles only source the generated init file so startup stays fast
+	sh := s.shell()
+	var sourceInitFile string
+	switch sh {
+	case Zsh:
+		sourceInitFile = zshSourceInitFile
+	case Fish:
+		sourceInitFile = fishSourceInitFile
+	case Powershell:
+		sourceInitFile = pwshSourceInitFile
+	default:
+		sourceInitFile = bashSourceInitFile
 	}
-	return fmt.Sprintf("\n%s\n%s\n%s\n", beginBlockComment, sourceCompletion, endBlockComment)
+	sourceInitFile = fmt.Sprintf(sourceInitFile, config.InitFile(sh))
+	return fmt.Sprintf("\n%s\n%s\n%s\n", beginBlockComment, sourceInitFile, endBlockComment)
 }
@@ -1,8 +1,12 @@
 package shell
 
 import (
+	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/pokanop/nostromo/config"
@@ -34,10 +38,10 @@ func TestStartupFile(t *testing.T) {
 		{"malformed block 2", ".zshrc", "export PATH=/usr/local/bin\nexport FOO=bar\n\n# nostromo [section begin]\neval \"$(nostromo completion)\"\nalias foo='nostromo eval foo \"$*\"'\nalias bar='nostromo eval bar \"$*\"'# nostromo [section begin]", makeManifest("foo", "baz"), true, false, true, true, ""},
 		{"empty profile", ".profile", "", man(), false, true, false, false, ""},
 		{"empty bash_profile", ".bash_profile", "", man(), false, true, false, false, ""},
-		{"empty bashrc", ".bashrc", "", man(), true, true, false, false, "\n# nostromo [section begin]\nsource <(nostromo completion bash)\n# nostromo [section end]\n"},
-		{"empty zshrc", ".zshrc", "", man(), true, true, false, false, "\n# nostromo [section begin]\nautoload -U compinit; compinit\nsource <(nostromo completion zsh)\n# nostromo [section end]\n"},
+		{"empty bashrc", ".bashrc", "", man(), true, true, false, false, "\n# nostromo [section begin]\nsource \"" + config.InitFile("bash") + "\"\n# nostromo [section end]\n"},
+		{"empty zshrc", ".zshrc", "", man(), true, true, false, false, "\n# nostromo [section begin]\nautoload -U compinit; compinit\nsource \"" + config.InitFile("zsh") + "\"\n# nostromo [section end]\n"},
 		{"existing non-preferred no commands", ".profile", "export PATH=/usr/local/bin\nexport FOO=bar", man(), false, true, false, false, "export PATH=/usr/local/bin\nexport FOO=bar"},
-		{"existing preferred no commands", ".zshrc", "export PATH=/usr/local/bin\nexport FOO=bar", man(), true, true, false, false, "export PATH=/usr/local/bin\nexport FOO=bar\n# nostromo [section begin]\nautoload -U compinit; compinit\nsource <(nostromo completion zsh)\n# nostromo [section end]\n"},
+		{"existing preferred no commands", ".zshrc", "export PATH=/usr/local/bin\nexport FOO=bar", man(), true, true, false, false, "export PATH=/usr/local/bin\nexport FOO=bar\n# nostromo [section begin]\nautoload -U compinit; compinit\nsource \"" + config.InitFile("zsh") + "\"\n# nostromo [section end]\n"},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -71,6 +75,214 @@ func TestStartupFile(t *testing.T) {
 	}
 }
 
+func TestLoadStartupFiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		dirs     []string
+		files    []string
+		expFiles map[string]string
+	}{
+		{"no files", nil, nil, map[string]string{}},
+		{"bash and zsh", nil, []string{".bashrc", ".zshrc"}, map[string]string{".bashrc": Bash, ".zshrc": Zsh}},
+		{"fish config folder", []string{".config/fish"}, nil, map[string]string{"nostromo.fish": Fish}},
+		{"powershell config folder", []string{".config/powershell"}, nil, map[string]string{"Microsoft.PowerShell_profile.ps1": Powershell}},
+		{"existing powershell profile", nil, []string{powershellFilename}, map[string]string{"Microsoft.PowerShell_profile.ps1": Powershell}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			home := fakeHome(t, test.dirs, test.files)
+			defer os.RemoveAll(home)
+
+			files := loadStartupFiles(nil)
+			if len(files) != len(test.expFiles) {
+				t.Fatalf("expected %d files but got %d", len(test.expFiles), len(files))
+			}
+			for _, f := range files {
+				sh, ok := test.expFiles[f.name()]
+				if !ok {
+					t.Errorf("unexpected startup file %s", f.path)
+				} else if f.shell() != sh {
+					t.Errorf("expected shell %s for %s but got %s", sh, f.name(), f.shell())
+				}
+				if !f.preferred {
+					t.Errorf("expected %s to be preferred", f.name())
+				}
+			}
+		})
+	}
+}
+
+func TestStartupFileCommit(t *testing.T) {
+	tests := []struct {
+		name       string
+		dirs       []string
+		path       string
+		expContent string
+	}{
+		{"fish", []string{".config/fish"}, fishFilename, "\n# nostromo [section begin]\nsource \"%s\"\n# nostromo [section end]\n"},
+		{"powershell", []string{".config/powershell"}, powershellFilename, "\n# nostromo [section begin]\n. \"%s\"\n# nostromo [section end]\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			home := fakeHome(t, test.dirs, nil)
+			defer os.RemoveAll(home)
+
+			files := loadStartupFiles(nil)
+			if len(files) != 1 {
+				t.Fatalf("expected 1 file but got %d", len(files))
+			}
+
+			f := files[0]
+			if err := f.apply(makeManifest("foo")); err != nil {
+				t.Fatalf("expected no apply error but got: %s", err)
+			}
+			if !f.canCommit() {
+				t.Fatalf("expected to be able to commit")
+			}
+			if err := f.commit(); err != nil {
+				t.Fatalf("expected no commit error but got: %s", err)
+			}
+
+			b, err := ioutil.ReadFile(filepath.Join(home, test.path))
+			if err != nil {
+				t.Fatal(err)
+			}
+			expContent := fmt.Sprintf(test.expContent, config.InitFile(f.shell()))
+			if string(b) != expContent {
+				t.Errorf("expected content '%s' but got '%s'", expContent, string(b))
+			}
+		})
+	}
+}
+
+func TestLoadConfiguredStartupFiles(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       *model.Config
+		files        []string
+		expPreferred map[string]bool
+	}{
+		{"custom files", &model.Config{StartupFiles: []string{"~/.config/bash/bashrc", "~/.zshrc"}}, []string{".config/bash/bashrc", ".zshrc", ".bashrc"}, map[string]bool{"bashrc": true, ".zshrc": true}},
+		{"preferred shells", &model.Config{StartupFiles: []string{"~/.config/bash/bashrc", "~/.zshrc"}, PreferredShells: []string{Zsh}}, []string{".config/bash/bashrc", ".zshrc"}, map[string]bool{"bashrc": false, ".zshrc": true}},
+		{"default files preferred shells", &model.Config{PreferredShells: []string{Bash}}, []string{".profile", ".bashrc", ".zshrc"}, map[string]bool{".profile": false, ".bashrc": true, ".zshrc": false}},
+		{"missing custom file in existing folder", &model.Config{StartupFiles: []string{"~/.config/bash/bashrc", "~/missing/.zshrc"}}, []string{".config/bash/.keep"}, map[string]bool{"bashrc": true}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			home := fakeHome(t, nil, test.files)
+			defer os.RemoveAll(home)
+
+			files := loadStartupFiles(test.config)
+			if len(files) != len(test.expPreferred) {
+				t.Fatalf("expected %d files but got %d", len(test.expPreferred), len(files))
+			}
+			for _, f := range files {
+				preferred, ok := test.expPreferred[f.name()]
+				if !ok {
+					t.Errorf("unexpected startup file %s", f.path)
+				} else if f.preferred != preferred {
+					t.Errorf("expected preferred %t for %s but got %t", preferred, f.name(), f.preferred)
+				}
+			}
+		})
+	}
+}
+
+func TestStartupFileCommitSymlink(t *testing.T) {
+	home := fakeHome(t, []string{"dotfiles", "backups"}, nil)
+	defer os.RemoveAll(home)
+
+	target := filepath.Join(home, "dotfiles", "bashrc")
+	link := filepath.Join(home, ".bashrc")
+	ioutil.WriteFile(target, []byte("export FOO=bar\n"), 0600)
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+
+	files := loadStartupFiles(&model.Config{BackupDir: "~/backups"})
+	if len(files) != 1 {
+		t.Fatalf("expected 1 file but got %d", len(files))
+	}
+	f := files[0]
+	if err := f.apply(makeManifest("foo")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.commit(); err != nil {
+		t.Fatalf("expected no commit error but got: %s", err)
+	}
+
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("expected symlink to be kept")
+	}
+	b, _ := ioutil.ReadFile(target)
+	if !strings.Contains(string(b), beginBlockComment) {
+		t.Errorf("expected target to be updated but got '%s'", string(b))
+	}
+	if info, _ := os.Stat(target); info.Mode().Perm() != 0600 {
+		t.Errorf("expected target mode to be kept but got %s", info.Mode())
+	}
+	if backups, _ := ioutil.ReadDir(filepath.Join(home, "backups")); len(backups) != 1 {
+		t.Errorf("expected 1 backup but got %d", len(backups))
+	}
+
+	os.Remove(target)
+	if err := f.commit(); err == nil {
+		t.Errorf("expected error for broken symlink but got none")
+	}
+}
+
+func TestUninstall(t *testing.T) {
+	home := fakeHome(t, []string{"backups"}, nil)
+	defer os.RemoveAll(home)
+
+	content := "export FOO=bar\n\n# nostromo [section begin]\nsource <(nostromo completion zsh)\n# nostromo [section end]\n"
+	path := filepath.Join(home, ".zshrc")
+	ioutil.WriteFile(path, []byte(content), 0644)
+
+	cfg := &model.Config{BackupDir: "~/backups"}
+	if err := Uninstall(cfg); err != nil {
+		t.Fatalf("expected no error but got %s", err)
+	}
+
+	b, _ := ioutil.ReadFile(path)
+	if string(b) != "export FOO=bar\n" {
+		t.Errorf("expected nostromo section removed but got '%s'", string(b))
+	}
+
+	statuses := Status(cfg)
+	if len(statuses) != 1 || statuses[0].Installed {
+		t.Errorf("expected uninstalled status")
+	}
+}
+
+func TestStartupFileShell(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"~/.bashrc", Bash},
+		{"~/.profile", Bash},
+		{"~/.config/bash/bashrc", Bash},
+		{"~/.zshrc", Zsh},
+		{"~/.config/zsh/.zshrc", Zsh},
+		{"~/.config/zsh/rc", Zsh},
+		{"~/.config/fish/conf.d/nostromo.fish", Fish},
+		{"~/Documents/PowerShell/Microsoft.PowerShell_profile.ps1", Powershell},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			s := newStartupFile(test.path, "", 0644)
+			if actual := s.shell(); actual != test.expected {
+				t.Errorf("expected: %s, actual: %s", test.expected, actual)
+			}
+		})
+	}
+}
+
 func TestIsPreferredFilename(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -84,6 +296,8 @@ func TestIsPreferredFilename(t *testing.T) {
 		{"zshrc", ".zshrc", true},
 		{"substring 1", "/path/to/.zshrc", true},
 		{"substring 2", "~/.zshrc", true},
+		{"fish", "~/.config/fish/conf.d/nostromo.fish", true},
+		{"powershell", "~/.config/powershell/Microsoft.PowerShell_profile.ps1", true},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -148,6 +362,36 @@ func TestStartupFileCanCommit(t *testing.T) {
 	}
 }
 
+// fakeHome creates a temporary home folder with dirs and empty files
+func fakeHome(t *testing.T, dirs, files []string) string {
+	home, err := ioutil.TempDir("", "nostromo-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, value := range map[string]string{"HOME": home, "ZDOTDIR": "", "XDG_CONFIG_HOME": ""} {
+		key := key
+		prev, ok := os.LookupEnv(key)
+		os.Setenv(key, value)
+		t.Cleanup(func() {
+			if ok {
+				os.Setenv(key, prev)
+			} else {
+				os.Unsetenv(key)
+			}
+		})
+	}
+
+	for _, dir := range dirs {
+		os.MkdirAll(filepath.Join(home, dir), 0755)
+	}
+	for _, file := range files {
+		path := filepath.Join(home, file)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		ioutil.WriteFile(path, []byte{}, 0644)
+	}
+	return home
+}
+
 func makeManifest(cmds ...string) *model.Manifest {
 	return makeManifestLong(true, false, cmds...)
 }
@@ -176,3 +420,39 @@ func makeStartupFileCommon(name, content string, preferred bool) *startupFile {
 	s.preferred = preferred
 	return s
 }
+
+func TestRepair(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"missing end", "export FOO=bar\n# nostromo [section begin]\nsource x\n", "export FOO=bar\nsource x\n"},
+		{"missing begin", "source x\n# nostromo [section end]\nexport FOO=bar\n", "source x\nexport FOO=bar\n"},
+		{"out of order", "# nostromo [section end]\nsource x\n# nostromo [section begin]\n", "source x\n"},
+		{"valid", "export FOO=bar\n", "export FOO=bar\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			home := fakeHome(t, []string{"backups"}, nil)
+			defer os.RemoveAll(home)
+
+			path := filepath.Join(home, ".bashrc")
+			ioutil.WriteFile(path, []byte(test.content), 0644)
+
+			cfg := &model.Config{BackupDir: "~/backups"}
+			if _, err := Repair(cfg); err != nil {
+				t.Fatalf("expected no error but got %s", err)
+			}
+
+			b, _ := ioutil.ReadFile(path)
+			if string(b) != test.expected {
+				t.Errorf("expected: %q, actual: %q", test.expected, string(b))
+			}
+			if statuses := Status(cfg); len(statuses) != 1 || len(statuses[0].Problem) > 0 {
+				t.Errorf("expected no problems after repair")
+			}
+		})
+	}
+}
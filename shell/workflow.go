@@ -0,0 +1,112 @@
+package shell
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pokanop/nostromo/log"
+	"github.com/pokanop/nostromo/model"
+	"github.com/pokanop/nostromo/stringutil"
+)
+
+// retryFunc retries the command in its first argument sleeping for each of
+// the remaining arguments between attempts
+const retryFunc = `__nostromo_retry() { local __nostromo_step=$1; shift; until eval "$__nostromo_step"; do [ $# -eq 0 ] && return 1; echo "retrying in ${1}s" >&2; sleep "$1"; shift; done; }`
+
+// cleanupFunc removes what sequential steps define in the calling shell
+// keeping the exit code in __nostromo_rc
+const cleanupFunc = `unset -f __nostromo_retry; eval "unset __nostromo_rc; (exit $__nostromo_rc)"`
+
+// WorkflowCommands for each step ready to run in a shell
+func WorkflowCommands(w *model.Workflow) []string {
+	var cmds []string
+	for _, step := range w.Steps {
+		cmd := strings.TrimSuffix(step.Command, "\n")
+		cmds = append(cmds, buildEvalCmd(cmd, step.Language))
+	}
+	return cmds
+}
+
+// WorkflowEvalString for a shell to run the steps with their policy
+//
+// Sequential steps run in the current shell so commands like `cd` work as
+// usual. Parallel steps run as background jobs in a subshell waiting for
+// each batch before starting the next.
+func WorkflowEvalString(w *model.Workflow, verbose bool) (string, error) {
+	if len(w.Steps) == 0 {
+		return "", fmt.Errorf("cannot run workflow without steps")
+	}
+
+	cmds := WorkflowCommands(w)
+	for i, cmd := range cmds {
+		cmd = strings.TrimRight(strings.TrimSpace(cmd), ";")
+		if delays := w.Delays(); len(delays) > 0 {
+			args := []string{"__nostromo_retry", stringutil.SingleQuote(cmd)}
+			for _, d := range delays {
+				args = append(args, fmt.Sprintf("%g", d.Seconds()))
+			}
+			cmds[i] = strings.Join(args, " ")
+		} else {
+			cmds[i] = fmt.Sprintf("{ %s; }", cmd)
+		}
+	}
+
+	var setup string
+	if w.OnFailure == model.RetryStepPolicy {
+		setup = retryFunc + "; "
+	}
+
+	var cmdStr string
+	switch {
+	case w.Parallel:
+		cmdStr = parallelEvalString(w, cmds, setup)
+	case w.OnFailure == model.ContinueStepPolicy:
+		var parts []string
+		for _, cmd := range cmds {
+			parts = append(parts, cmd+" || __nostromo_rc=$?")
+		}
+		cmdStr = fmt.Sprintf("%s__nostromo_rc=0; %s; %s", setup, strings.Join(parts, "; "), cleanupFunc)
+	case len(setup) > 0:
+		cmdStr = fmt.Sprintf("%s%s; __nostromo_rc=$?; %s", setup, strings.Join(cmds, " && "), cleanupFunc)
+	default:
+		cmdStr = strings.Join(cmds, " && ")
+	}
+
+	if verbose {
+		log.Debugf("executing: %s\n", cmdStr)
+	}
+
+	return cmdStr, nil
+}
+
+// parallelEvalString runs batches of steps as jobs in a subshell stopping
+// after a failed batch unless the policy continues
+//
+// Setup runs first in the subshell so nothing leaks into the calling shell.
+func parallelEvalString(w *model.Workflow, cmds []string, setup string) string {
+	var parts []string
+	batches := w.Batches()
+	for i, batch := range batches {
+		for _, j := range batch {
+			parts = append(parts, fmt.Sprintf("%s & __nostromo_p%d=$!", cmds[j], j))
+		}
+		for _, j := range batch {
+			parts = append(parts, fmt.Sprintf("wait $__nostromo_p%d || __nostromo_rc=1", j))
+		}
+		if w.OnFailure != model.ContinueStepPolicy && i < len(batches)-1 {
+			parts = append(parts, "[ $__nostromo_rc -eq 0 ] || exit $__nostromo_rc")
+		}
+	}
+	return fmt.Sprintf("(%s__nostromo_rc=0; %s; exit $__nostromo_rc)", setup, strings.Join(parts, "; "))
+}
+
+// quoteFor sh since fish and PowerShell escape single quotes differently
+func quoteFor(sh, s string) string {
+	switch sh {
+	case Fish:
+		return stringutil.FishQuote(s)
+	case Powershell:
+		return stringutil.PowerShellQuote(s)
+	}
+	return stringutil.SingleQuote(s)
+}
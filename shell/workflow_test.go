@@ -0,0 +1,131 @@
+package shell
+
+import (
+	"os/exec"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pokanop/nostromo/model"
+)
+
+func fakeWorkflow(onFailure string, parallel bool, cmds ...string) *model.Workflow {
+	w := &model.Workflow{OnFailure: onFailure, Parallel: parallel}
+	if onFailure == model.RetryStepPolicy {
+		w.Retries, w.Backoff = 2, 10*time.Millisecond
+	}
+	for _, cmd := range cmds {
+		w.Steps = append(w.Steps, &model.WorkflowStep{Name: cmd, Command: cmd})
+	}
+	return w
+}
+
+func TestWorkflowEvalString(t *testing.T) {
+	tests := []struct {
+		name     string
+		workflow *model.Workflow
+		expected string
+	}{
+		{"stop", fakeWorkflow(model.StopStepPolicy, false, "echo one;", "echo two"), "{ echo one; } && { echo two; }"},
+		{"continue", fakeWorkflow(model.ContinueStepPolicy, false, "echo one", "echo two"), "__nostromo_rc=0; { echo one; } || __nostromo_rc=$?; { echo two; } || __nostromo_rc=$?; " + cleanupFunc},
+		{"retry", fakeWorkflow(model.RetryStepPolicy, false, "echo 'one'"), retryFunc + "; __nostromo_retry 'echo '\\''one'\\''' 0.01 0.02; __nostromo_rc=$?; " + cleanupFunc},
+		{"parallel retry", fakeWorkflow(model.RetryStepPolicy, true, "echo one"), "(" + retryFunc + "; __nostromo_rc=0; __nostromo_retry 'echo one' 0.01 0.02 & __nostromo_p0=$!; wait $__nostromo_p0 || __nostromo_rc=1; exit $__nostromo_rc)"},
+		{"parallel", fakeWorkflow(model.StopStepPolicy, true, "echo one", "echo two"), "(__nostromo_rc=0; { echo one; } & __nostromo_p0=$!; { echo two; } & __nostromo_p1=$!; wait $__nostromo_p0 || __nostromo_rc=1; wait $__nostromo_p1 || __nostromo_rc=1; exit $__nostromo_rc)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := WorkflowEvalString(tt.workflow, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual != tt.expected {
+				t.Errorf("expected: %s, actual: %s", tt.expected, actual)
+			}
+		})
+	}
+
+	if _, err := WorkflowEvalString(&model.Workflow{}, false); err == nil {
+		t.Errorf("expected error for workflow without steps")
+	}
+}
+
+func TestWorkflowEvalStringRuns(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not found")
+	}
+
+	concurrent := func(w *model.Workflow) *model.Workflow {
+		w.Concurrency = 1
+		return w
+	}
+	tests := []struct {
+		name     string
+		workflow *model.Workflow
+		output   []string
+		failed   bool
+	}{
+		{"stop", fakeWorkflow(model.StopStepPolicy, false, "echo one", "false", "echo three"), []string{"one"}, true},
+		{"continue", fakeWorkflow(model.ContinueStepPolicy, false, "echo one", "false", "echo three"), []string{"one", "three"}, true},
+		{"retry", fakeWorkflow(model.RetryStepPolicy, false, "echo try; false", "echo never"), []string{"retrying in 0.01s", "retrying in 0.02s", "try", "try", "try"}, true},
+		{"retry succeeds", fakeWorkflow(model.RetryStepPolicy, false, "echo one", "echo two"), []string{"one", "two"}, false},
+		{"parallel", fakeWorkflow(model.StopStepPolicy, true, "echo one", "echo two"), []string{"one", "two"}, false},
+		{"parallel stop", concurrent(fakeWorkflow(model.StopStepPolicy, true, "false", "echo two")), nil, true},
+		{"parallel continue", concurrent(fakeWorkflow(model.ContinueStepPolicy, true, "false", "echo two")), []string{"two"}, true},
+		{"sequential cd", fakeWorkflow(model.StopStepPolicy, false, "cd /", "pwd"), []string{"/"}, false},
+		{"retry cd", fakeWorkflow(model.RetryStepPolicy, false, "cd /", "pwd"), []string{"/"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmdStr, err := WorkflowEvalString(tt.workflow, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := exec.Command(sh, "-c", cmdStr).CombinedOutput()
+			if failed := err != nil; failed != tt.failed {
+				t.Errorf("expected failed %v, actual %v: %s", tt.failed, failed, out)
+			}
+			var lines []string
+			if s := strings.TrimSpace(string(out)); len(s) > 0 {
+				lines = strings.Split(s, "\n")
+			}
+			sort.Strings(lines)
+			if strings.Join(lines, ",") != strings.Join(tt.output, ",") {
+				t.Errorf("expected: %v, actual: %v", tt.output, lines)
+			}
+		})
+	}
+}
+
+func TestWorkflowEvalStringCleanup(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not found")
+	}
+
+	// Nothing defined by the construct is left in the calling shell
+	check := `; echo "rc=$?"; command -v __nostromo_retry; echo "${__nostromo_rc-unset} ${__nostromo_step-unset} $(command -v __nostromo_cmd)"`
+	tests := []struct {
+		name     string
+		workflow *model.Workflow
+		expected string
+	}{
+		{"continue", fakeWorkflow(model.ContinueStepPolicy, false, "false", "true"), "rc=1\nunset unset __nostromo_cmd"},
+		{"retry", fakeWorkflow(model.RetryStepPolicy, false, "true", "true"), "rc=0\nunset unset __nostromo_cmd"},
+		{"retry failed", fakeWorkflow(model.RetryStepPolicy, false, "false"), "rc=1\nunset unset __nostromo_cmd"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmdStr, err := WorkflowEvalString(tt.workflow, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			script := "__nostromo_cmd() { :; }; " + cmdStr + check
+			out, _ := exec.Command(sh, "-c", script).CombinedOutput()
+			if actual := strings.TrimSpace(string(out)); !strings.HasSuffix(actual, tt.expected) {
+				t.Errorf("expected: %s, actual: %s", tt.expected, actual)
+			}
+		})
+	}
+}
@@ -2,9 +2,14 @@ package stringutil
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
+	"unicode"
 )
 
+// shellSafe characters that don't need quoting in shell words
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
 // ContainsCaseInsensitive checks if a string is a substring regardless of case.
 func ContainsCaseInsensitive(s, substr string) bool {
 	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
@@ -23,6 +28,35 @@ func ReversedStrings(strs []string) []string {
 	return r
 }
 
+// ShellQuote returns s as a single shell word quoting it only if needed.
+func ShellQuote(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return SingleQuote(s)
+}
+
+// SingleQuote returns s in single quotes for POSIX shells.
+func SingleQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// DoubleQuote returns s in double quotes for POSIX shells with expansions
+// escaped.
+func DoubleQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`").Replace(s) + `"`
+}
+
+// FishQuote returns s in single quotes for fish.
+func FishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
+
+// PowerShellQuote returns s in single quotes for PowerShell.
+func PowerShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
 // ReplaceShellVars swaps command args like $1 and returns the result.
 func ReplaceShellVars(cmd string, args []string) string {
 	// Deal with $1 - $N for now, not sure if we need to deal with or how
@@ -38,3 +72,44 @@ func ReplaceShellVars(cmd string, args []string) string {
 	}
 	return strings.TrimSpace(fmt.Sprintf("%s %s", cmd, strings.Join(args[count:], " ")))
 }
+
+// FuzzyMatch checks if the runes of pattern appear in order in s regardless
+// of case and returns a score where higher is better along with the rune
+// indexes that matched.
+//
+// Consecutive matches and matches at the start of a word score higher.
+func FuzzyMatch(s, pattern string) (int, []int, bool) {
+	if len(pattern) == 0 {
+		return 0, nil, true
+	}
+
+	runes := []rune(s)
+	score, last := 0, -1
+	var indexes []int
+	for _, p := range pattern {
+		p = unicode.ToLower(p)
+		found := false
+		for i := last + 1; i < len(runes); i++ {
+			if unicode.ToLower(runes[i]) != p {
+				continue
+			}
+			score++
+			if last != -1 && i == last+1 {
+				score += 5
+			}
+			if i == 0 || strings.ContainsRune(" ._-/:", runes[i-1]) {
+				score += 8
+			}
+			indexes = append(indexes, i)
+			last, found = i, true
+			break
+		}
+		if !found {
+			return 0, nil, false
+		}
+	}
+
+	// Prefer tighter matches in shorter strings
+	score -= (last - indexes[0] + 1 - len(indexes)) + len(runes)/10
+	return score, indexes, true
+}
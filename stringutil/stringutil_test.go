@@ -80,3 +80,97 @@ func TestReplaceShellVars(t *testing.T) {
 		})
 	}
 }
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{"safe", "prod-1.2/a=b", "prod-1.2/a=b"},
+		{"empty", "", "''"},
+		{"spaces", "a b", "'a b'"},
+		{"metacharacters", "x; rm -rf $HOME `id`", "'x; rm -rf $HOME `id`'"},
+		{"single quote", "it's", `'it'\''s'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ShellQuote(tt.s); got != tt.want {
+				t.Errorf("ShellQuote() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShellQuotes(t *testing.T) {
+	s := `it's $HOME "\ ` + "`id`"
+	tests := []struct {
+		name  string
+		quote func(string) string
+		want  string
+	}{
+		{"single", SingleQuote, `'it'\''s $HOME "\ ` + "`id`'"},
+		{"double", DoubleQuote, `"it's \$HOME \"\\ \` + "`id\\`\""},
+		{"fish", FishQuote, `'it\'s $HOME "\\ ` + "`id`'"},
+		{"powershell", PowerShellQuote, `'it''s $HOME "\ ` + "`id`'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.quote(s); got != tt.want {
+				t.Errorf("quote() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		pattern  string
+		indexes  []int
+		expected bool
+	}{
+		{"empty pattern", "foo", "", nil, true},
+		{"empty string", "", "f", nil, false},
+		{"exact", "foo", "foo", []int{0, 1, 2}, true},
+		{"case insensitive", "FooBar", "fb", []int{0, 3}, true},
+		{"in order", "git.checkout", "gco", []int{0, 4, 9}, true},
+		{"out of order", "foo.bar", "rb", nil, false},
+		{"missing rune", "foo.bar", "fz", nil, false},
+		{"unicode", "déployer", "dé", []int{0, 1}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, indexes, ok := FuzzyMatch(test.s, test.pattern)
+			if ok != test.expected {
+				t.Errorf("expected match %t but got %t", test.expected, ok)
+			}
+			if !reflect.DeepEqual(indexes, test.indexes) {
+				t.Errorf("expected indexes %v but got %v", test.indexes, indexes)
+			}
+		})
+	}
+}
+
+func TestFuzzyMatchScore(t *testing.T) {
+	tests := []struct {
+		name   string
+		better string
+		worse  string
+		query  string
+	}{
+		{"consecutive", "deploy", "dxexpxloy", "dep"},
+		{"word start", "git.checkout", "gitcheckout", "c"},
+		{"shorter", "build", "build.all.targets.release", "build"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			better, _, _ := FuzzyMatch(test.better, test.query)
+			worse, _, _ := FuzzyMatch(test.worse, test.query)
+			if better <= worse {
+				t.Errorf("expected %s (%d) to score higher than %s (%d)", test.better, better, test.worse, worse)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package task
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/pokanop/nostromo/config"
+	"github.com/pokanop/nostromo/model"
+	"github.com/pokanop/nostromo/prompt"
+	"github.com/pokanop/nostromo/shell"
+)
+
+// assumeYesEnv skips confirmation when set to true like in CI
+const assumeYesEnv = "NOSTROMO_YES"
+
+var assumeYes bool
+
+// SetAssumeYes to run commands without asking for confirmation
+func SetAssumeYes(yes bool) {
+	assumeYes = yes
+}
+
+// skipConfirmation if --yes was passed or NOSTROMO_YES is true
+func skipConfirmation() bool {
+	if assumeYes {
+		return true
+	}
+	yes, _ := strconv.ParseBool(os.Getenv(assumeYesEnv))
+	return yes
+}
+
+// confirmEvalString wraps cmdStr in a shell prompt if the command at args
+// needs confirmation and records it in history
+//
+// Commands that need confirmation are recorded by the shell once confirmed
+// so declined commands never show up in history.
+func confirmEvalString(cfg *config.Config, args []string, cmdStr string) (string, error) {
+	s := cfg.Spaceport()
+	var confirm *model.Confirm
+	if !skipConfirmation() {
+		var err error
+		if confirm, err = s.Confirmation(args); err != nil {
+			return "", err
+		}
+	}
+	if confirm == nil {
+		recordHistory(cfg, newHistoryEntry(s, args))
+		return cmdStr, nil
+	}
+
+	if !s.CoreManifest().Config.DisableHistory {
+		cmdStr = shell.RecordEvalString(args) + "; " + cmdStr
+	}
+	return shell.ConfirmEvalString(cmdStr, confirm), nil
+}
+
+// confirmCommand at args before running it directly returning false if it
+// wasn't confirmed
+func confirmCommand(s *model.Spaceport, args []string) (bool, error) {
+	if skipConfirmation() {
+		return true, nil
+	}
+	confirm, err := s.Confirmation(args)
+	if err != nil {
+		return false, err
+	}
+	if confirm == nil {
+		return true, nil
+	}
+	return confirm.Accepts(prompt.String(confirm.Prompt(), "")), nil
+}
@@ -0,0 +1,212 @@
+package task
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/pokanop/nostromo/config"
+	"github.com/pokanop/nostromo/log"
+	"github.com/pokanop/nostromo/model"
+	"github.com/pokanop/nostromo/shell"
+)
+
+// doctorCheck diagnoses one part of a nostromo installation
+type doctorCheck struct {
+	name     string
+	hint     string
+	diagnose func() []string
+	fix      func() error
+}
+
+// Doctor checks the nostromo installation and prints problems with hints
+//
+// With fix set, problems that can be safely repaired are fixed and checked
+// again.
+func Doctor(fix bool) int {
+	failed := 0
+	for _, check := range doctorChecks() {
+		problems := check.diagnose()
+		if len(problems) > 0 && fix && check.fix != nil {
+			if err := check.fix(); err != nil {
+				problems = append(problems, fmt.Sprintf("unable to fix: %s", err))
+			} else if problems = check.diagnose(); len(problems) == 0 {
+				log.Highlightf("%s: fixed\n", check.name)
+				continue
+			}
+		}
+
+		if len(problems) == 0 {
+			log.Highlightf("%s: ok\n", check.name)
+			continue
+		}
+
+		failed++
+		log.Errorf("%s: failed\n", check.name)
+		for _, problem := range problems {
+			log.Regular("  " + problem)
+		}
+		if fix && check.fix == nil {
+			log.Info("cannot be fixed automatically,", check.hint)
+		} else {
+			log.Info(check.hint)
+		}
+	}
+
+	if failed > 0 {
+		return -1
+	}
+	return 0
+}
+
+func doctorChecks() []*doctorCheck {
+	// Fixes may reload the config so checks share it
+	cfg := checkConfigReadOnly(true)
+	coreConfig := func() *model.Config {
+		if cfg == nil {
+			return nil
+		}
+		return cfg.Spaceport().CoreManifest().Config
+	}
+	requireConfig := func() error {
+		if cfg == nil {
+			return fmt.Errorf("config could not be loaded")
+		}
+		return nil
+	}
+
+	return []*doctorCheck{
+		{
+			name: "manifests",
+			hint: "fix the manifest yaml or remove it with `nostromo undock <name>`",
+			diagnose: func() []string {
+				problems := []string{}
+				for _, err := range config.ManifestErrors() {
+					problems = append(problems, err.Error())
+				}
+				return problems
+			},
+		},
+		{
+			name: "spaceport",
+			hint: "run `nostromo doctor --fix` to rebuild the spaceport from docked manifests",
+			diagnose: func() []string {
+				missing, err := config.MissingManifests()
+				if err != nil {
+					return []string{fmt.Sprintf("unable to read spaceport: %s", err)}
+				}
+				problems := []string{}
+				for _, name := range missing {
+					problems = append(problems, fmt.Sprintf("sequence references missing manifest %s", name))
+				}
+				return problems
+			},
+			fix: func() error {
+				// Loading the config saves a spaceport with a valid sequence
+				c, err := config.LoadConfig()
+				if err != nil {
+					return err
+				}
+				cfg = c
+				return nil
+			},
+		},
+		{
+			name: "profile",
+			hint: "run `nostromo doctor --fix` or `nostromo profile install` to update shell profiles",
+			diagnose: func() []string {
+				problems := []string{}
+				installed := false
+				for _, status := range shell.Status(coreConfig()) {
+					if len(status.Problem) > 0 {
+						problems = append(problems, fmt.Sprintf("%s: %s", status.Path, status.Problem))
+					}
+					installed = installed || (status.Preferred && status.Installed)
+				}
+				if !installed {
+					problems = append(problems, "nostromo is not installed in any preferred shell profile")
+				}
+				return problems
+			},
+			fix: func() error {
+				if err := requireConfig(); err != nil {
+					return err
+				}
+				repaired, err := shell.Repair(coreConfig())
+				for _, path := range repaired {
+					log.Debugf("repaired nostromo section in %s\n", path)
+				}
+				if err != nil {
+					return err
+				}
+				return shell.Commit(cfg.Spaceport().CoreManifest())
+			},
+		},
+		{
+			name: "completions",
+			hint: "run `nostromo doctor --fix` or `nostromo init` to write init files",
+			diagnose: func() []string {
+				problems := []string{}
+				for _, sh := range shell.SupportedShells() {
+					if _, err := os.Stat(config.InitFile(sh)); err != nil {
+						problems = append(problems, fmt.Sprintf("missing init file for %s: %s", sh, config.InitFile(sh)))
+					}
+				}
+				return problems
+			},
+			fix: func() error {
+				if err := requireConfig(); err != nil {
+					return err
+				}
+				writeInitFiles(cfg.Spaceport())
+				return nil
+			},
+		},
+		{
+			name: "man pages",
+			hint: fmt.Sprintf("run `nostromo doctor --fix` with write access to %s", filepath.Join(config.SystemPrefixDir, "share", "man", "man1")),
+			diagnose: func() []string {
+				unlinked, err := config.UnlinkedManPages()
+				if err != nil {
+					return []string{err.Error()}
+				}
+				if len(unlinked) > 0 {
+					return []string{fmt.Sprintf("%d man pages are not linked, like %s", len(unlinked), unlinked[0])}
+				}
+				return nil
+			},
+			fix: func() error {
+				if rootCmd == nil {
+					return fmt.Errorf("missing root command")
+				}
+				for _, err := range config.UnlinkManPages() {
+					log.Debug(err)
+				}
+				if errs := generateManPages(rootCmd); len(errs) > 0 {
+					return errs[0]
+				}
+				return nil
+			},
+		},
+		{
+			name: "path",
+			hint: pathHint(),
+			diagnose: func() []string {
+				if _, err := exec.LookPath("nostromo"); err != nil {
+					return []string{"nostromo was not found in PATH"}
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// pathHint with the folder of the running binary when available
+func pathHint() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return "add the folder containing nostromo to your PATH"
+	}
+	return fmt.Sprintf("add %s to your PATH", filepath.Dir(exe))
+}
@@ -0,0 +1,44 @@
+package task
+
+import (
+	"io/ioutil"
+
+	"github.com/pokanop/nostromo/exporter"
+	"github.com/pokanop/nostromo/log"
+	"github.com/pokanop/nostromo/pathutil"
+)
+
+// Export a manifest as a standalone script in the given format
+//
+// The script is printed unless an output file is provided.
+func Export(name, format, output string) int {
+	cfg := checkConfigReadOnly(false)
+	if cfg == nil {
+		return -1
+	}
+
+	m := cfg.Spaceport().FindManifest(name)
+	if m == nil {
+		log.Errorf("no manifest named %s exists\n", name)
+		return -1
+	}
+
+	script, err := exporter.Export(m, format)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	if len(output) == 0 {
+		log.Print(script)
+		return 0
+	}
+
+	if err := ioutil.WriteFile(pathutil.Abs(output), []byte(script), 0644); err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	log.Highlightf("exported %s as %s to %s\n", m.Name, format, output)
+	return 0
+}
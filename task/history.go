@@ -0,0 +1,302 @@
+package task
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/pokanop/nostromo/config"
+	"github.com/pokanop/nostromo/keypath"
+	"github.com/pokanop/nostromo/log"
+	"github.com/pokanop/nostromo/model"
+	"github.com/pokanop/nostromo/shell"
+	"github.com/pokanop/nostromo/stringutil"
+)
+
+// Exec runs the command at args directly and records its exit code
+func Exec(args []string) int {
+	cfg := checkConfigReadOnly(false)
+	if cfg == nil {
+		return -1
+	}
+	layerProjectManifest(cfg, true)
+
+	if args, help := model.IsHelpRequest(args); help {
+		return printCommandUsage(cfg.Spaceport(), args)
+	}
+
+	return execCommand(cfg, args)
+}
+
+// RecordHistory for the command at args once the shell ran it after it was
+// confirmed
+func RecordHistory(args []string) int {
+	cfg := checkConfigReadOnly(true)
+	if cfg == nil {
+		return -1
+	}
+	layerProjectManifest(cfg, false)
+
+	recordHistory(cfg, newHistoryEntry(cfg.Spaceport(), args))
+	return 0
+}
+
+// History of resolved commands matching the query
+func History(query string, clear bool) int {
+	cfg := checkConfigReadOnly(false)
+	if cfg == nil {
+		return -1
+	}
+
+	if clear {
+		if err := config.ClearHistory(); err != nil {
+			log.Error(err)
+			return -1
+		}
+		log.Highlight("cleared history")
+		return 0
+	}
+
+	m := cfg.Spaceport().CoreManifest()
+	entries, err := config.LoadHistory(m.Config)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	rows := [][]string{}
+	for i, entry := range entries {
+		line := entry.CommandLine()
+		if !stringutil.ContainsCaseInsensitive(line, query) && !stringutil.ContainsCaseInsensitive(entry.Manifest, query) {
+			continue
+		}
+		rows = append(rows, []string{
+			strconv.Itoa(i + 1),
+			entry.Time.Local().Format("2006-01-02 15:04:05"),
+			line,
+			entry.Manifest,
+			historyStatus(entry),
+		})
+	}
+
+	if len(rows) == 0 {
+		if m.Config.DisableHistory {
+			log.Highlight("history is disabled, enable it with nostromo set disableHistory false")
+		} else {
+			log.Highlight("no matching history found")
+		}
+		return -1
+	}
+
+	logRows("history", rows)
+	return 0
+}
+
+// ReplayHistory runs the history entry referenced like `!n`, `!-n` or `!!`
+func ReplayHistory(ref string) int {
+	cfg := checkConfigReadOnly(false)
+	if cfg == nil {
+		return -1
+	}
+	layerProjectManifest(cfg, true)
+
+	entries, err := config.LoadHistory(cfg.Spaceport().CoreManifest().Config)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	entry, err := model.FindHistoryEntry(entries, ref)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	log.Regular(entry.CommandLine())
+	return execCommand(cfg, append(keypath.Keys(entry.KeyPath), entry.Args...))
+}
+
+// Stats of most used, never used and failing commands from history
+func Stats(manifest string, limit int, asJSON bool) int {
+	cfg := checkConfigReadOnly(false)
+	if cfg == nil {
+		return -1
+	}
+	layerProjectManifest(cfg, true)
+
+	s := cfg.Spaceport()
+	if len(manifest) > 0 && s.FindManifest(manifest) == nil {
+		log.Errorf("manifest %s not found\n", manifest)
+		return -1
+	}
+
+	entries, err := config.LoadHistory(s.CoreManifest().Config)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	stats := s.HistoryStats(entries, manifest, limit)
+	if asJSON {
+		b, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			log.Error(err)
+			return -1
+		}
+		log.Regular(string(b))
+		return 0
+	}
+
+	rows := [][]string{}
+	for _, u := range stats.MostUsed {
+		rows = append(rows, []string{strconv.Itoa(u.Count), u.KeyPath, u.Manifest, u.LastUsed.Local().Format("2006-01-02")})
+	}
+	logRows("most used", rowsOrNone(rows))
+
+	rows = [][]string{}
+	for _, u := range stats.Failing {
+		rows = append(rows, []string{fmt.Sprintf("%d/%d", u.Failures, u.Runs), u.KeyPath, u.Manifest})
+	}
+	logRows("failing", rowsOrNone(rows))
+
+	rows = [][]string{}
+	for _, u := range stats.NeverUsed {
+		rows = append(rows, []string{u.KeyPath, u.Manifest})
+	}
+	logRows("never used", rowsOrNone(rows))
+
+	return 0
+}
+
+// execCommand at args in a new shell recording its exit code and duration
+//
+// Commands with steps are run natively with their failure policy. Commands
+// that need confirmation ask first and secrets are resolved right before
+// running.
+func execCommand(cfg *config.Config, args []string) int {
+	s := cfg.Spaceport()
+	w, _, err := s.Workflow(args)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	confirmed, err := confirmCommand(s, args)
+	if err != nil {
+		log.Error(err)
+		return -1
+	} else if !confirmed {
+		log.Warning("not confirmed")
+		return 1
+	}
+
+	lookup := newSecretLookup(cfg)
+	env, err := commandEnvironment(s, args, lookup)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	var run func() int
+	if w != nil {
+		if w, _, err = s.ResolvedWorkflow(args, lookup); err != nil {
+			log.Error(err)
+			return -1
+		}
+		run = func() int {
+			return runWorkflow(w, env.List())
+		}
+	} else {
+		language, cmd, m, err := s.ResolvedExecutionString(args, lookup)
+		if err != nil {
+			log.Error(err)
+			return -1
+		}
+		cmdStr, err := shell.EvalString(cmd, language, m.Config.IsVerbose())
+		if err != nil {
+			log.Error(err)
+			return -1
+		}
+		run = func() int {
+			return runInShell(cmdStr, env.List())
+		}
+	}
+
+	entry := newHistoryEntry(s, args)
+	code := run()
+	if entry != nil {
+		entry.Finish(code)
+	}
+	recordHistory(cfg, entry)
+	return code
+}
+
+// newHistoryEntry for the command at args or nil if it can't be resolved
+func newHistoryEntry(s *model.Spaceport, args []string) *model.HistoryEntry {
+	c, m, rest, err := s.Resolve(args)
+	if err != nil {
+		return nil
+	}
+	return model.NewHistoryEntry(c.KeyPath, m.Name, rest)
+}
+
+// recordHistory entry without failing the command
+func recordHistory(cfg *config.Config, entry *model.HistoryEntry) {
+	if entry == nil {
+		return
+	}
+	if err := config.RecordHistory(cfg.Spaceport().CoreManifest().Config, entry); err != nil {
+		log.Debugf("unable to record history: %s\n", err)
+	}
+}
+
+// historyStatus of an entry like `exit 0 in 1.2s` or `eval`
+func historyStatus(entry *model.HistoryEntry) string {
+	if entry.ExitCode == nil {
+		return "eval"
+	}
+	d := time.Duration(entry.DurationMs) * time.Millisecond
+	return fmt.Sprintf("exit %d in %s", *entry.ExitCode, d)
+}
+
+func rowsOrNone(rows [][]string) [][]string {
+	if len(rows) == 0 {
+		return [][]string{{"none"}}
+	}
+	return rows
+}
+
+// runInShell with the user's shell attached to the terminal returning its
+// exit code
+func runInShell(cmdStr string, env []string) int {
+	return runShell(cmdStr, env, os.Stdin, os.Stdout, os.Stderr)
+}
+
+// runShell command with the user's shell and env added to the environment
+// returning its exit code
+func runShell(cmdStr string, env []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	sh := os.Getenv("SHELL")
+	if len(sh) == 0 {
+		sh = "sh"
+	}
+
+	c := exec.Command(sh, "-c", cmdStr)
+	c.Stdin, c.Stdout, c.Stderr = stdin, stdout, stderr
+	if len(env) > 0 {
+		c.Env = append(os.Environ(), env...)
+	}
+	if err := c.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+		log.Error(err)
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,266 @@
+package task
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pokanop/nostromo/config"
+	"github.com/pokanop/nostromo/importer"
+	"github.com/pokanop/nostromo/keypath"
+	"github.com/pokanop/nostromo/log"
+	"github.com/pokanop/nostromo/model"
+	"github.com/pokanop/nostromo/pathutil"
+	"github.com/pokanop/nostromo/prompt"
+	"github.com/pokanop/nostromo/shell"
+)
+
+// ImportAliases from shell startup files or the files provided into the
+// core manifest
+//
+// A file named `-` reads from stdin so output of the `alias` command can be
+// piped in. Names are split into key paths on `_` and `.` unless flat is
+// set.
+func ImportAliases(files []string, flat, yes, dryRun bool) int {
+	cfg := checkConfig()
+	if cfg == nil {
+		return -1
+	}
+
+	m := cfg.Spaceport().CoreManifest()
+	contents := map[string]string{}
+	if len(files) == 0 {
+		contents = shell.StartupFileContents(m.Config)
+	}
+	for _, f := range files {
+		var b []byte
+		var err error
+		if f == "-" {
+			b, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			b, err = ioutil.ReadFile(pathutil.Abs(f))
+		}
+		if err != nil {
+			log.Error(err)
+			return -1
+		}
+		contents[f] = string(b)
+	}
+
+	sources := []string{}
+	for source := range contents {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	var cmds []*importer.Command
+	for _, source := range sources {
+		aliases := importer.ParseAliases(contents[source], source == "-")
+		cmds = append(cmds, importer.AliasCommands(aliases, source, flat)...)
+	}
+
+	return importCommands(cfg, m, cmds, yes, dryRun)
+}
+
+// invalidKey characters replaced when naming key paths after directories
+var invalidKey = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// ImportRunner tasks from a Makefile, justfile, package.json or Taskfile
+// into the core manifest under a key path
+//
+// The key path defaults to the name of the directory the file is in and
+// `.` imports at the root. Importing again refreshes commands from a
+// previous import, removing tasks that no longer exist.
+func ImportRunner(runner, path, root string, yes, dryRun bool) int {
+	cfg := checkConfig()
+	if cfg == nil {
+		return -1
+	}
+
+	if len(path) == 0 {
+		path = "."
+	}
+	file, err := importer.RunnerFile(runner, pathutil.Abs(path))
+	if err == nil {
+		file, err = filepath.Abs(file)
+	}
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	cmds, err := importer.RunnerCommands(runner, file)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+	if len(cmds) == 0 {
+		log.Highlightf("no tasks found in %s\n", file)
+		return 0
+	}
+
+	dir := filepath.Dir(file)
+	if len(root) == 0 {
+		root = strings.Trim(invalidKey.ReplaceAllString(strings.ToLower(filepath.Base(dir)), "-"), "-")
+	}
+	if root == "." {
+		root = ""
+	}
+
+	// Build the tree in a scratch manifest so it can be attached anywhere
+	imported := model.NewManifest("import", "", "", nil)
+	for _, c := range cmds {
+		if _, err := imported.AddCommand(c.KeyPath, c.Command, c.Description, &model.Code{}, false, model.ExclusiveMode.String()); err != nil {
+			log.Error(err)
+			return -1
+		}
+	}
+
+	m := cfg.Spaceport().CoreManifest()
+	scope := m.Commands
+	if len(root) > 0 {
+		if c := m.Find(root); c != nil {
+			scope = c.Commands
+		} else {
+			scope = nil
+		}
+	}
+
+	rows := [][]string{}
+	for _, c := range cmds {
+		status := "add"
+		if existing := m.Find(keypath.KeyPath([]string{root, c.KeyPath})); existing != nil {
+			status = "update"
+		}
+		rows = append(rows, []string{status, keypath.KeyPath([]string{root, c.KeyPath}), c.Command})
+	}
+
+	// Commands replaced by the import lose anything not imported again and
+	// trees from a previous import that aren't imported again are removed
+	keyPaths := map[string]bool{}
+	for _, c := range imported.SortedCommands() {
+		c.Walk(func(cmd *model.Command, stop *bool) {
+			keyPaths[keypath.KeyPath([]string{root, cmd.KeyPath})] = true
+		})
+	}
+	prefix := importer.RunnerPrefix(runner, file)
+	var stale, removed []string
+	for alias, c := range scope {
+		if imported.Commands[alias] == nil {
+			if importedBy(c, prefix) {
+				stale = append(stale, c.KeyPath)
+				removed = append(removed, c.KeyPath)
+			}
+			continue
+		}
+		c.Walk(func(cmd *model.Command, stop *bool) {
+			if !keyPaths[cmd.KeyPath] && len(cmd.Name) > 0 {
+				removed = append(removed, cmd.KeyPath)
+			}
+		})
+	}
+	sort.Strings(removed)
+	for _, keyPath := range removed {
+		rows = append(rows, []string{"remove", keyPath, ""})
+	}
+
+	logRows(fmt.Sprintf("import %s", file), rows)
+	if dryRun {
+		return 0
+	}
+	if !yes && !prompt.Confirm("Import these commands? (y/N)", false) {
+		return 1
+	}
+
+	for _, keyPath := range stale {
+		if _, err := m.RemoveCommand(keyPath); err != nil {
+			log.Error(err)
+			return -1
+		}
+	}
+
+	description := ""
+	if len(root) > 0 {
+		description = fmt.Sprintf("%s tasks in %s", runner, dir)
+	}
+	if err := m.ImportCommands(imported.SortedCommands(), root, description, true); err != nil {
+		log.Error(err)
+		return -1
+	}
+	if err := m.Link(); err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	if err := saveConfig(cfg, true); err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	log.Highlightf("imported %d %s tasks into %s\n", len(cmds), runner, m.Name)
+	return 0
+}
+
+// importedBy returns true if every command in the tree was imported with
+// the runner prefix or only groups imported commands
+func importedBy(c *model.Command, prefix string) bool {
+	imported := true
+	c.Walk(func(cmd *model.Command, stop *bool) {
+		if len(cmd.Name) > 0 && !strings.HasPrefix(cmd.Name, prefix) {
+			imported = false
+			*stop = true
+		}
+	})
+	return imported
+}
+
+// importCommands into the manifest after previewing them
+//
+// Commands are added in exclusive mode since imported commands are complete
+// and shouldn't be concatenated with parents in the tree. Existing commands
+// are skipped.
+func importCommands(cfg *config.Config, m *model.Manifest, cmds []*importer.Command, yes, dryRun bool) int {
+	var added []*importer.Command
+	rows, skipped := [][]string{}, [][]string{}
+	for _, c := range cmds {
+		if existing := m.Find(c.KeyPath); existing != nil && len(existing.Name) > 0 {
+			skipped = append(skipped, []string{c.KeyPath, existing.Name})
+			continue
+		}
+		added = append(added, c)
+		rows = append(rows, []string{c.KeyPath, c.Command, c.Source})
+	}
+
+	logRows("existing", skipped)
+	if len(added) == 0 {
+		log.Highlight("no new commands to import")
+		return 0
+	}
+	logRows("import", rows)
+
+	if dryRun {
+		return 0
+	}
+	if !yes && !prompt.Confirm("Import these commands? (y/N)", false) {
+		return 1
+	}
+
+	for _, c := range added {
+		if _, err := m.AddCommand(c.KeyPath, c.Command, c.Description, &model.Code{}, false, model.ExclusiveMode.String()); err != nil {
+			log.Error(err)
+			return -1
+		}
+	}
+
+	if err := saveConfig(cfg, true); err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	log.Highlightf("imported %d commands into %s\n", len(added), m.Name)
+	return 0
+}
@@ -0,0 +1,114 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pokanop/nostromo/keypath"
+	"github.com/pokanop/nostromo/log"
+	"github.com/pokanop/nostromo/model"
+	"github.com/pokanop/nostromo/picker"
+)
+
+// Pick a command from all manifests with an interactive fuzzy picker
+//
+// The picked command is printed to eval unless execute is set or it was
+// picked with ctrl-x, in which case it's run in a new shell.
+func Pick(query string, execute bool) int {
+	cfg := checkConfigReadOnly(false)
+	if cfg == nil {
+		return -1
+	}
+	layerProjectManifest(cfg, true)
+
+	s := cfg.Spaceport()
+	cmds := pickableCommands(s)
+	if len(cmds) == 0 {
+		log.Highlight("no commands to pick from")
+		return -1
+	}
+
+	// The picker draws on the terminal directly so the output can be
+	// captured for eval
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		log.Error("interactive picker requires a terminal:", err)
+		return -1
+	}
+	defer tty.Close()
+
+	items := []picker.Item{}
+	for _, c := range cmds {
+		body := c.Name
+		if c.Code != nil && len(c.Code.Snippet) > 0 {
+			body = c.Code.Snippet
+		}
+		items = append(items, picker.Item{Label: c.KeyPath, Description: c.Description, Body: body})
+	}
+
+	sel, err := picker.Run(tty, tty, items, picker.Options{
+		Query: query,
+		Preview: func(i int) string {
+			return pickPreview(s, cmds[i])
+		},
+	})
+	if errors.Is(err, picker.ErrCancelled) {
+		return 1
+	} else if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	args := keypath.Keys(cmds[sel.Index].KeyPath)
+	if execute || sel.Execute {
+		return execCommand(cfg, args)
+	}
+
+	cmdStr, err := shellEvalString(cfg, args)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	log.SetEcho(true)
+	log.Print(cmdStr)
+	return 0
+}
+
+// pickableCommands from all manifests where the first manifest with a root
+// command wins like when executing
+func pickableCommands(s *model.Spaceport) []*model.Command {
+	var cmds []*model.Command
+	seen := map[string]bool{}
+	for _, m := range s.Manifests() {
+		for _, cmd := range m.SortedCommands() {
+			if seen[cmd.Alias] {
+				continue
+			}
+			seen[cmd.Alias] = true
+			cmd.Walk(func(c *model.Command, stop *bool) {
+				if !c.AliasOnly && !c.Disabled {
+					cmds = append(cmds, c)
+				}
+			})
+		}
+	}
+	return cmds
+}
+
+// pickPreview of the command expanded without arguments
+func pickPreview(s *model.Spaceport, c *model.Command) string {
+	e, err := s.Explain([]string{c.KeyPath})
+	if err != nil {
+		return err.Error()
+	}
+
+	lines := []string{fmt.Sprintf("%s (%s)", e.KeyPath, e.Manifest)}
+	if len(c.Description) > 0 {
+		lines = append(lines, c.Description)
+	}
+	lines = append(lines, "", "$ "+e.Result)
+	return strings.Join(lines, "\n")
+}
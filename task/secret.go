@@ -0,0 +1,201 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/pokanop/nostromo/config"
+	"github.com/pokanop/nostromo/log"
+	"github.com/pokanop/nostromo/model"
+	"github.com/pokanop/nostromo/prompt"
+	"github.com/pokanop/nostromo/secret"
+)
+
+// Environment variables to unlock the vault without prompting
+const (
+	vaultKeyFileEnv    = "NOSTROMO_VAULT_KEY_FILE"
+	vaultPassphraseEnv = "NOSTROMO_VAULT_PASSPHRASE"
+)
+
+// SetSecret in the configured backend reading the value from stdin or a
+// hidden prompt if empty
+func SetSecret(name, value string) int {
+	cfg := checkConfigReadOnly(false)
+	if cfg == nil {
+		return -1
+	}
+
+	if err := secret.ValidateName(name); err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	if len(value) == 0 {
+		var err error
+		if value, err = readSecretValue(name); err != nil {
+			log.Error(err)
+			return -1
+		}
+	}
+	if len(value) == 0 {
+		log.Error("secret value cannot be empty")
+		return -1
+	}
+
+	backend, err := openSecrets(cfg)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+	if err := backend.Set(name, value); err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	log.Highlightf("set secret %s, reference it in commands with %s\n", name, secret.Reference(name))
+	return 0
+}
+
+// GetSecret value from the configured backend
+func GetSecret(name string) int {
+	cfg := checkConfigReadOnly(false)
+	if cfg == nil {
+		return -1
+	}
+
+	backend, err := openSecrets(cfg)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+	value, err := backend.Get(name)
+	if err != nil {
+		log.Errorf("%s: %s\n", name, err)
+		return -1
+	}
+
+	log.Print(value + "\n")
+	return 0
+}
+
+// ListSecrets names in the configured backend without values
+func ListSecrets() int {
+	cfg := checkConfigReadOnly(false)
+	if cfg == nil {
+		return -1
+	}
+
+	backend, err := openSecrets(cfg)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+	names, err := backend.List()
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+	if len(names) == 0 {
+		log.Highlight("no secrets found")
+		return 0
+	}
+
+	rows := [][]string{}
+	for _, name := range names {
+		rows = append(rows, []string{name, secret.Reference(name)})
+	}
+	logRows("secrets", rows)
+	return 0
+}
+
+// RemoveSecret from the configured backend
+func RemoveSecret(name string) int {
+	cfg := checkConfigReadOnly(false)
+	if cfg == nil {
+		return -1
+	}
+
+	backend, err := openSecrets(cfg)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+	if err := backend.Remove(name); err != nil {
+		log.Errorf("%s: %s\n", name, err)
+		return -1
+	}
+
+	log.Highlightf("removed secret %s\n", name)
+	return 0
+}
+
+// newSecretLookup opening the configured backend only once a secret is
+// looked up
+//
+// Values are redacted from all log output.
+func newSecretLookup(cfg *config.Config) model.SecretLookup {
+	var backend secret.Backend
+	return func(name string) (string, error) {
+		if backend == nil {
+			var err error
+			if backend, err = openSecrets(cfg); err != nil {
+				return "", err
+			}
+		}
+		value, err := backend.Get(name)
+		if err == nil {
+			log.Redact(value)
+		}
+		return value, err
+	}
+}
+
+func openSecrets(cfg *config.Config) (secret.Backend, error) {
+	return config.OpenSecrets(cfg.Spaceport().CoreManifest().Config, vaultPassphrase)
+}
+
+// vaultPassphrase from a key file, the environment or a hidden prompt
+// which is confirmed when creating the vault
+func vaultPassphrase(create bool) (string, error) {
+	if path := os.Getenv(vaultKeyFileEnv); len(path) > 0 {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("unable to read vault key file: %s", err)
+		}
+		return strings.TrimSpace(string(b)), nil
+	}
+	if passphrase := os.Getenv(vaultPassphraseEnv); len(passphrase) > 0 {
+		return passphrase, nil
+	}
+
+	if !create {
+		return prompt.Secret("Vault passphrase")
+	}
+	passphrase, err := prompt.Secret("New vault passphrase")
+	if err != nil {
+		return "", err
+	}
+	confirm, err := prompt.Secret("Confirm vault passphrase")
+	if err != nil {
+		return "", err
+	}
+	if passphrase != confirm {
+		return "", errors.New("vault passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+// readSecretValue from stdin if it's piped or a hidden prompt
+func readSecretValue(name string) (string, error) {
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice == 0 {
+		b, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(b), "\r\n"), nil
+	}
+	return prompt.Secret(fmt.Sprintf("Value for %s", name))
+}
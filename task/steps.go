@@ -0,0 +1,227 @@
+package task
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pokanop/nostromo/config"
+	"github.com/pokanop/nostromo/log"
+	"github.com/pokanop/nostromo/model"
+	"github.com/pokanop/nostromo/secret"
+	"github.com/pokanop/nostromo/shell"
+)
+
+// evalShell the command is evaluated in, POSIX shells if empty
+var evalShell string
+
+// SetEvalShell the command printed by eval is evaluated in
+func SetEvalShell(sh string) {
+	evalShell = sh
+}
+
+// shellEvalString for the eval shell to run the command at args with
+// confirmation and history
+//
+// Workflows, env files, confirmation and secrets resolve to POSIX
+// constructs that fish and PowerShell can't evaluate so those run with
+// `nostromo exec` instead.
+func shellEvalString(cfg *config.Config, args []string) (string, error) {
+	s := cfg.Spaceport()
+	if exec, err := needsExec(s, args); err != nil {
+		return "", err
+	} else if exec {
+		return shell.ExecEvalString(evalShell, args, assumeYes), nil
+	}
+
+	cmdStr, err := evalCommandString(s, args, newSecretLookup(cfg))
+	if err != nil {
+		return "", err
+	}
+	return confirmEvalString(cfg, args, cmdStr)
+}
+
+// needsExec returns true if the command at args resolves to constructs the
+// eval shell can't evaluate
+func needsExec(s *model.Spaceport, args []string) (bool, error) {
+	if shell.IsPOSIX(evalShell) {
+		return false, nil
+	}
+	w, _, err := s.Workflow(args)
+	if err != nil || w != nil {
+		return w != nil, err
+	}
+	if !skipConfirmation() {
+		confirm, err := s.Confirmation(args)
+		if err != nil || confirm != nil {
+			return confirm != nil, err
+		}
+	}
+	_, cmd, _, err := s.ExecutionString(args)
+	if err != nil || len(secret.References(cmd)) > 0 {
+		return err == nil, err
+	}
+	env, err := commandEnvironment(s, args, nil)
+	if err != nil {
+		return false, err
+	}
+	return len(env.Vars) > 0, nil
+}
+
+// evalCommandString for the shell to run the command at args with steps
+// resolved into a shell construct
+//
+// Secrets and env files are resolved if lookup is set and otherwise left
+// out so values are never shown.
+func evalCommandString(s *model.Spaceport, args []string, lookup model.SecretLookup) (string, error) {
+	var env []string
+	if lookup != nil {
+		e, err := commandEnvironment(s, args, lookup)
+		if err != nil {
+			return "", err
+		}
+		env = e.List()
+	}
+
+	w, m, err := s.ResolvedWorkflow(args, lookup)
+	if err != nil {
+		return "", err
+	}
+	if w != nil {
+		cmdStr, err := shell.WorkflowEvalString(w, m.Config.IsVerbose())
+		if err != nil {
+			return "", err
+		}
+		return shell.EnvEvalString(cmdStr, env), nil
+	}
+
+	language, cmd, m, err := s.ResolvedExecutionString(args, lookup)
+	if err != nil {
+		return "", err
+	}
+	cmdStr, err := shell.EvalString(cmd, language, m.Config.IsVerbose())
+	if err != nil {
+		return "", err
+	}
+	return shell.EnvEvalString(cmdStr, env), nil
+}
+
+// commandEnvironment loaded from env files for the command at args with
+// secrets in values resolved
+//
+// Only resolved secrets are redacted from log output since plain values
+// like `1` or `true` would mask unrelated output.
+func commandEnvironment(s *model.Spaceport, args []string, lookup model.SecretLookup) (*model.Environment, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	env, err := s.Environment(args, wd, os.LookupEnv)
+	if err != nil {
+		return nil, err
+	}
+	if lookup != nil {
+		for _, v := range env.Vars {
+			if v.Value, err = secret.Expand(v.Value, lookup); err != nil {
+				return nil, fmt.Errorf("%s from %s: %s", v.Key, v.Source, err)
+			}
+		}
+	}
+	return env, nil
+}
+
+// runWorkflow steps with their policy returning the exit code of the first
+// step that failed
+//
+// Output of parallel steps is prefixed with the step name so it can be told
+// apart.
+func runWorkflow(w *model.Workflow, env []string) int {
+	cmds := shell.WorkflowCommands(w)
+	var mu sync.Mutex
+	code := 0
+	for _, batch := range w.Batches() {
+		codes := make([]int, len(batch))
+		var wg sync.WaitGroup
+		for i, j := range batch {
+			wg.Add(1)
+			go func(i, j int) {
+				defer wg.Done()
+				if len(batch) == 1 {
+					codes[i] = runStep(w, w.Steps[j], cmds[j], env, os.Stdin, os.Stdout, os.Stderr)
+					return
+				}
+				stdout := &prefixWriter{mu: &mu, w: os.Stdout, prefix: "[" + w.Steps[j].Name + "] "}
+				stderr := &prefixWriter{mu: &mu, w: os.Stderr, prefix: stdout.prefix}
+				codes[i] = runStep(w, w.Steps[j], cmds[j], env, nil, stdout, stderr)
+				stdout.Flush()
+				stderr.Flush()
+			}(i, j)
+		}
+		wg.Wait()
+
+		for _, c := range codes {
+			if c != 0 && code == 0 {
+				code = c
+			}
+		}
+		if code != 0 && w.OnFailure != model.ContinueStepPolicy {
+			return code
+		}
+	}
+	return code
+}
+
+// runStep retrying with backoff if the policy allows
+func runStep(w *model.Workflow, step *model.WorkflowStep, cmd string, env []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	delays := w.Delays()
+	for attempt := 0; ; attempt++ {
+		code := runShell(cmd, env, stdin, stdout, stderr)
+		if code == 0 || attempt >= len(delays) {
+			if code != 0 {
+				log.Errorf("step %s failed with exit %d\n", step.Name, code)
+			}
+			return code
+		}
+		log.Warningf("step %s failed with exit %d, retrying in %s\n", step.Name, code, delays[attempt])
+		time.Sleep(delays[attempt])
+	}
+}
+
+// prefixWriter writes complete lines with a prefix
+type prefixWriter struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.buf = append(p.buf, b...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i == -1 {
+			break
+		}
+		p.write(p.buf[:i+1])
+		p.buf = p.buf[i+1:]
+	}
+	return len(b), nil
+}
+
+// Flush a partial line left in the buffer
+func (p *prefixWriter) Flush() {
+	if len(p.buf) > 0 {
+		p.write(append(p.buf, '\n'))
+		p.buf = nil
+	}
+}
+
+func (p *prefixWriter) write(line []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	io.WriteString(p.w, p.prefix)
+	p.w.Write(line)
+}
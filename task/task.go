@@ -1,9 +1,11 @@
 package task
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/pokanop/nostromo/config"
@@ -11,8 +13,8 @@ import (
 	"github.com/pokanop/nostromo/model"
 	"github.com/pokanop/nostromo/pathutil"
 	"github.com/pokanop/nostromo/prompt"
+	"github.com/pokanop/nostromo/secret"
 	"github.com/pokanop/nostromo/shell"
-	"github.com/pokanop/nostromo/stringutil"
 	"github.com/pokanop/nostromo/version"
 	"github.com/shivamMg/ppds/tree"
 	"github.com/spf13/cobra"
@@ -20,12 +22,18 @@ import (
 )
 
 var ver *version.Info
+var rootCmd *cobra.Command
 
 // SetVersion should be called before any task to ensure manifest is updated
 func SetVersion(v *version.Info) {
 	ver = v
 }
 
+// SetRootCommand used to generate nostromo completions in init files
+func SetRootCommand(cmd *cobra.Command) {
+	rootCmd = cmd
+}
+
 // InitConfig of nostromo config file if not already initialized
 func InitConfig(cmd *cobra.Command) int {
 	// Attempt to load existing config
@@ -42,18 +50,14 @@ func InitConfig(cmd *cobra.Command) int {
 		log.Highlight("nostromo config exists, updating")
 	}
 
+	// Generate init files while saving
+	rootCmd = cmd
 	err = saveConfig(cfg, true)
 	if err != nil {
 		log.Error(err)
 		return -1
 	}
 
-	// Generate completion files
-	GenerateCompletions("bash", cmd, true)
-	GenerateCompletions("zsh", cmd, true)
-	GenerateCompletions("fish", cmd, true)
-	GenerateCompletions("powershell", cmd, true)
-
 	// Generate man pages
 	for _, err := range config.UnlinkManPages() {
 		log.Debug(err)
@@ -109,10 +113,11 @@ func DestroyConfig(nuke bool) int {
 
 // ShowConfig for nostromo config file
 func ShowConfig(asJSON bool, asYAML bool, asTree bool) int {
-	cfg := checkConfig()
+	cfg := checkConfigReadOnly(false)
 	if cfg == nil {
 		return -1
 	}
+	layerProjectManifest(cfg, true)
 
 	verbose := cfg.Spaceport().CoreManifest().Config.IsVerbose()
 	for i, m := range cfg.Spaceport().Manifests() {
@@ -139,7 +144,7 @@ func ShowConfig(asJSON bool, asYAML bool, asTree bool) int {
 
 			if len(m.Commands) > 0 {
 				log.Bold("\n[commands]")
-				for _, cmd := range m.Commands {
+				for _, cmd := range m.SortedCommands() {
 					cmd.Walk(func(c *model.Command, s *bool) {
 						logFields(c, verbose)
 						if verbose {
@@ -155,8 +160,8 @@ func ShowConfig(asJSON bool, asYAML bool, asTree bool) int {
 				log.Regular()
 			}
 
-			lines := shell.InitFileLines()
 			if m.IsCore() {
+				lines := shell.InitFileLines(m.Config)
 				log.Bold("[profile]")
 				if len(lines) > 0 {
 					log.Regular(strings.TrimSpace(lines))
@@ -170,6 +175,62 @@ func ShowConfig(asJSON bool, asYAML bool, asTree bool) int {
 	return 0
 }
 
+// ProfileStatus shows the shell initialization files nostromo manages
+func ProfileStatus() int {
+	cfg := checkConfigReadOnly(false)
+	if cfg == nil {
+		return -1
+	}
+
+	statuses := shell.Status(cfg.Spaceport().CoreManifest().Config)
+	if len(statuses) == 0 {
+		log.Warning("no shell initialization files found")
+		return 0
+	}
+
+	verbose := cfg.Spaceport().CoreManifest().Config.IsVerbose()
+	for _, status := range statuses {
+		logFields(status, verbose)
+		if !verbose {
+			log.Regular()
+		}
+	}
+
+	return 0
+}
+
+// InstallProfile adds nostromo to shell initialization files
+func InstallProfile() int {
+	cfg := checkConfig()
+	if cfg == nil {
+		return -1
+	}
+
+	if err := shell.Commit(cfg.Spaceport().CoreManifest()); err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	log.Highlight("installed nostromo in shell profiles")
+	return 0
+}
+
+// UninstallProfile removes nostromo from shell initialization files
+func UninstallProfile() int {
+	cfg := checkConfigReadOnly(false)
+	if cfg == nil {
+		return -1
+	}
+
+	if err := shell.Uninstall(cfg.Spaceport().CoreManifest().Config); err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	log.Highlight("uninstalled nostromo from shell profiles")
+	return 0
+}
+
 // SetConfig updates properties for nostromo settings
 func SetConfig(key, value string) int {
 	cfg := checkConfig()
@@ -210,10 +271,11 @@ func GetConfig(key string) int {
 func FetchCommands() []*cobra.Command {
 	cmds := []*cobra.Command{}
 
-	cfg := checkConfigQuiet()
+	cfg := checkConfigReadOnly(true)
 	if cfg == nil {
 		return cmds
 	}
+	layerProjectManifest(cfg, false)
 
 	for _, cmd := range cfg.Spaceport().Commands() {
 		cmds = append(cmds, cmd.CobraCommand())
@@ -224,48 +286,61 @@ func FetchCommands() []*cobra.Command {
 
 // GenerateCompletions for all manifest commands and nostromo itself.
 func GenerateCompletions(sh string, cmd *cobra.Command, writeFile bool) int {
-	// Generate completions for nostromo
-	s, err := shell.Completion(sh, cmd)
-	if err != nil {
-		return -1
-	}
-	if !writeFile {
-		log.Print(s)
-	}
-
 	// Force verbose logging off since completion output must be sourced
 	verbose := log.IsVerbose()
 	defer log.SetVerbose(verbose)
 	log.SetVerbose(false)
 
-	cfg := checkConfigQuiet()
-	if cfg == nil {
-		return 0
-	}
+	var s *model.Spaceport
+	if cfg := checkConfigReadOnly(true); cfg != nil {
+		log.SetVerbose(false)
 
-	log.SetVerbose(false)
+		// Project manifests only apply to the current session, never to the
+		// init files written to disk
+		if !writeFile {
+			layerProjectManifest(cfg, false)
+		}
+		s = cfg.Spaceport()
+	}
 
-	// Generate completions for manifest commands
-	completions, err := shell.SpaceportCompletion(sh, cfg.Spaceport())
+	script, err := shell.InitScript(sh, cmd, s)
 	if err != nil {
-		return 0
+		return -1
 	}
 
-	for _, completion := range completions {
-		if !writeFile {
-			log.Print(completion)
+	if writeFile {
+		if err := config.WriteInitFile(sh, script); err != nil {
+			log.Warningf("unable to write init file for %s\n", sh)
 		}
-		s += "\n" + completion
+		return 0
 	}
 
-	if writeFile {
-		err = config.WriteCompletion(sh, s)
+	log.Print(script)
+	return 0
+}
+
+// writeInitFiles for all shells so profiles source the latest commands
+func writeInitFiles(s *model.Spaceport) {
+	if rootCmd == nil {
+		log.Debug("skipping init files without root command")
+		return
+	}
+
+	// Docked manifests may not be linked yet
+	if err := s.Link(); err != nil {
+		log.Warningf("unable to write init files: %s\n", err)
+		return
+	}
+
+	for _, sh := range shell.SupportedShells() {
+		script, err := shell.InitScript(sh, rootCmd, s)
+		if err == nil {
+			err = config.WriteInitFile(sh, script)
+		}
 		if err != nil {
-			log.Warningf("unable to write completion file for %s\n", sh)
+			log.Warningf("unable to write init file for %s: %s\n", sh, err)
 		}
 	}
-
-	return 0
 }
 
 // generateManPages for nostromo
@@ -356,7 +431,7 @@ func AddInteractive() int {
 	alias := prompt.StringRequired("Enter the substitution")
 	log.Highlight("\nAdding substitution...\n")
 
-	return AddSubstitution(keypath, sub, alias)
+	return AddSubstitution(keypath, sub, alias, "", 0, false)
 }
 
 // AddCommand to the manifest
@@ -528,8 +603,11 @@ func RenameCommand(source, dest, description string) int {
 	return 0
 }
 
-// AddSubstitution to the manifest
-func AddSubstitution(keyPath, name, alias string) int {
+// AddSubstitution to the manifest at key path or globally if empty
+//
+// The alias matches arguments exactly unless match is regex or glob and is
+// restricted to an argument position if not 0.
+func AddSubstitution(keyPath, name, alias, match string, position int, split bool) int {
 	cfg := checkConfig()
 	if cfg == nil {
 		return -1
@@ -537,7 +615,13 @@ func AddSubstitution(keyPath, name, alias string) int {
 
 	m := cfg.Spaceport().CoreManifest()
 
-	err := m.AddSubstitution(keyPath, name, alias)
+	sub := &model.Substitution{Name: name, Alias: alias, Match: match, Position: position, Split: split}
+	var err error
+	if len(keyPath) == 0 {
+		err = m.SetGlobalSubstitution(sub)
+	} else {
+		err = m.SetSubstitution(keyPath, sub)
+	}
 	if err != nil {
 		log.Error(err)
 		return -1
@@ -549,18 +633,30 @@ func AddSubstitution(keyPath, name, alias string) int {
 		return -1
 	}
 
+	if len(keyPath) == 0 {
+		log.Highlightf("added global substitution %s\n", alias)
+		return 0
+	}
+
 	logFields(m.Find(keyPath), m.Config.IsVerbose())
 	return 0
 }
 
-// RemoveSubstitution from the manifest
+// RemoveSubstitution from the manifest at key path or globally if empty
 func RemoveSubstitution(keyPath, alias string) int {
 	cfg := checkConfig()
 	if cfg == nil {
 		return -1
 	}
 
-	err := cfg.Spaceport().CoreManifest().RemoveSubstitution(keyPath, alias)
+	m := cfg.Spaceport().CoreManifest()
+
+	var err error
+	if len(keyPath) == 0 {
+		err = m.RemoveGlobalSubstitution(alias)
+	} else {
+		err = m.RemoveSubstitution(keyPath, alias)
+	}
 	if err != nil {
 		log.Error(err)
 		return -1
@@ -572,6 +668,11 @@ func RemoveSubstitution(keyPath, alias string) int {
 		return -1
 	}
 
+	if len(keyPath) == 0 {
+		log.Highlightf("removed global substitution %s\n", alias)
+		return 0
+	}
+
 	log.Highlightf("removed substitution %s for command %s\n", alias, keyPath)
 
 	return 0
@@ -581,28 +682,18 @@ func RemoveSubstitution(keyPath, alias string) int {
 func EvalString(args []string) int {
 	log.SetEcho(true)
 
-	cfg := checkConfig()
+	cfg := checkConfigReadOnly(false)
 	if cfg == nil {
 		return -1
 	}
+	layerProjectManifest(cfg, true)
 
-	var cmdStr string
-	var err error
-	for _, m := range cfg.Spaceport().Manifests() {
-		var language, cmd string
-		language, cmd, err = m.ExecutionString(args)
-		if err != nil {
-			continue
-		}
-
-		cmdStr, err = shell.EvalString(cmd, language, m.Config.IsVerbose())
-		if err != nil {
-			continue
-		}
-		break
+	if args, help := model.IsHelpRequest(args); help {
+		return printCommandUsage(cfg.Spaceport(), args)
 	}
 
-	if len(cmdStr) == 0 && err != nil {
+	cmdStr, err := shellEvalString(cfg, args)
+	if err != nil {
 		log.Error(err)
 		return -1
 	}
@@ -611,56 +702,240 @@ func EvalString(args []string) int {
 	return 0
 }
 
-// Find matching commands and substitutions
-func Find(name string) int {
-	cfg := checkConfig()
+// Explain how the command at args is resolved without running it
+func Explain(args []string, asJSON bool) int {
+	cfg := checkConfigReadOnly(false)
 	if cfg == nil {
 		return -1
 	}
+	layerProjectManifest(cfg, true)
 
-	var matchingCmds []*model.Command
-	var matchingSubs []*model.Command
+	e, err := cfg.Spaceport().Explain(args)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+	if len(e.Workflow) > 0 {
+		e.Eval, err = evalCommandString(cfg.Spaceport(), args, nil)
+	} else {
+		e.Eval, err = shell.EvalString(e.Result, e.Language, false)
+	}
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
 
-	for _, m := range cfg.Spaceport().Manifests() {
-		for _, cmd := range m.Commands {
-			cmd.Walk(func(c *model.Command, s *bool) {
-				if stringutil.ContainsCaseInsensitive(c.Name, name) || stringutil.ContainsCaseInsensitive(c.Alias, name) {
-					matchingCmds = append(matchingCmds, c)
-				}
-				for _, sub := range c.Subs {
-					if stringutil.ContainsCaseInsensitive(sub.Name, name) || stringutil.ContainsCaseInsensitive(sub.Alias, name) {
-						matchingSubs = append(matchingSubs, c)
-					}
-				}
-			})
+	env, err := commandEnvironment(cfg.Spaceport(), args, nil)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+	e.SetEnvironment(env)
+
+	if asJSON {
+		log.Regular(e.AsJSON())
+		return 0
+	}
+
+	log.Bold("[manifest]")
+	log.Regular(" ", e.Manifest)
+	log.Bold("\n[keypath]")
+	log.Regular(" ", e.KeyPath)
+
+	steps := [][]string{}
+	for _, step := range e.Steps {
+		included := "included"
+		if !step.Included {
+			included = "skipped"
 		}
+		steps = append(steps, []string{step.KeyPath, step.Mode, included, step.Command})
 	}
+	logRows("expand", steps)
 
-	if len(matchingCmds) == 0 && len(matchingSubs) == 0 {
-		log.Highlight("no matching commands or substitutions found")
+	subs := [][]string{}
+	for _, sub := range e.Substitutions {
+		from := "from " + sub.Scope
+		if len(sub.Pattern) > 0 {
+			from += fmt.Sprintf(" (%s)", sub.Pattern)
+		}
+		subs = append(subs, []string{sub.Arg, "->", sub.Value, from})
+	}
+	logRows("substitutions", subs)
+
+	placeholders := [][]string{}
+	for _, p := range e.Placeholders {
+		placeholders = append(placeholders, []string{p.Placeholder, "->", p.Value, "from " + p.Source})
+	}
+	logRows("placeholders", placeholders)
+
+	references := [][]string{}
+	for _, r := range e.References {
+		references = append(references, []string{r.Reference, "->", r.Value, fmt.Sprintf("from %s (%s)", r.KeyPath, r.Manifest)})
+	}
+	logRows("references", references)
+
+	secrets := [][]string{}
+	for _, name := range e.Secrets {
+		secrets = append(secrets, []string{secret.Reference(name), "->", "resolved when run"})
+	}
+	logRows("secrets", secrets)
+
+	envFiles := [][]string{}
+	for _, f := range e.EnvFiles {
+		loaded := "loaded"
+		if !f.Loaded {
+			loaded = "missing"
+		}
+		envFiles = append(envFiles, []string{f.KeyPath, f.File, loaded, f.Path})
+	}
+	logRows("env files", envFiles)
+
+	vars := [][]string{}
+	for _, v := range e.Env {
+		vars = append(vars, []string{v.Key, "->", v.Value, "from " + v.Source})
+	}
+	logRows("env", vars)
+
+	confirm := [][]string{}
+	if e.Confirm != nil {
+		row := []string{e.Confirm.Message}
+		if len(e.Confirm.Phrase) > 0 {
+			row = append(row, "type "+e.Confirm.Phrase)
+		}
+		confirm = append(confirm, row)
+	}
+	logRows("confirm", confirm)
+
+	if len(e.Arguments) > 0 {
+		log.Bold("\n[arguments]")
+		log.Regular(" ", strings.Join(e.Arguments, " "))
+	}
+
+	workflow := [][]string{}
+	for i, step := range e.Workflow {
+		workflow = append(workflow, []string{strconv.Itoa(i + 1), step.Name, step.Command})
+	}
+	logRows("steps "+e.Policy, workflow)
+
+	log.Bold("\n[command]")
+	log.Highlight(e.Eval)
+
+	return 0
+}
+
+// printCommandUsage for the command at args
+//
+// Usage is written to stderr so it's shown instead of being evaluated by the
+// shell.
+func printCommandUsage(s *model.Spaceport, args []string) int {
+	log.SetEcho(false)
+	log.SetOutput(os.Stderr)
+	defer log.SetOutput(os.Stdout)
+
+	u, err := s.Usage(args)
+	if err != nil {
+		log.Error(err)
 		return -1
 	}
 
-	m := cfg.Spaceport().CoreManifest()
-	verbose := m.Config.IsVerbose()
+	log.Bold(u.KeyPath)
+	if len(u.Description) > 0 {
+		log.Regular(u.Description)
+	}
+	logUsageItems("commands", u.Commands)
+	logUsageItems("substitutions", u.Subs)
+	logUsageItems("flags", u.Flags)
+	if len(u.Params) > 0 {
+		log.Bold("\n[parameters]")
+		log.Regular("  " + strings.Join(u.Params, " "))
+	}
+	log.Bold("\n[mode]")
+	log.Regular("  " + u.Mode)
+	log.Bold("\n[command]")
+	log.Highlight(u.Command)
 
-	log.Regular("[commands]")
-	for _, cmd := range matchingCmds {
-		logFields(cmd, verbose)
-		if verbose {
-			log.Regular()
+	return 0
+}
+
+func logUsageItems(title string, items []*model.UsageItem) {
+	rows := [][]string{}
+	for _, item := range items {
+		rows = append(rows, []string{item.Name, item.Description})
+	}
+	logRows(title, rows)
+}
+
+// logRows under a title with columns aligned
+func logRows(title string, rows [][]string) {
+	if len(rows) == 0 {
+		return
+	}
+
+	widths := []int{}
+	for _, row := range rows {
+		for i, col := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			if len(col) > widths[i] {
+				widths[i] = len(col)
+			}
 		}
 	}
 
-	if !verbose {
-		log.Regular()
+	log.Bold("\n[" + title + "]")
+	for _, row := range rows {
+		cols := []string{}
+		for i, col := range row {
+			cols = append(cols, fmt.Sprintf("%-*s", widths[i], col))
+		}
+		log.Regular(strings.TrimRight("  "+strings.Join(cols, "  "), " "))
 	}
-	log.Regular("[substitutions]")
-	for _, cmd := range matchingSubs {
-		logFields(cmd, verbose)
-		if verbose {
-			log.Regular()
+}
+
+// Find commands with fields matching the query
+func Find(opts model.SearchOptions, asJSON bool) int {
+	cfg := checkConfigReadOnly(false)
+	if cfg == nil {
+		return -1
+	}
+	layerProjectManifest(cfg, true)
+
+	results, err := cfg.Spaceport().Search(opts)
+	if err != nil {
+		log.Error(err)
+		return -1
+	}
+
+	if asJSON {
+		b, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			log.Error(err)
+			return -1
 		}
+		log.Regular(string(b))
+		return 0
+	}
+
+	if len(results) == 0 {
+		log.Highlight("no matching commands or substitutions found")
+		return -1
+	}
+
+	for _, r := range results {
+		rows := [][]string{{"manifest", r.Manifest}}
+		width := len(rows[0][0])
+		for _, match := range r.Matches {
+			rows = append(rows, []string{match.Field, log.Matched(match.Value, match.Spans)})
+			if len(match.Field) > width {
+				width = len(match.Field)
+			}
+		}
+		// Indent multiline values like code snippets under the value column
+		for _, row := range rows {
+			row[1] = strings.ReplaceAll(row[1], "\n", "\n"+strings.Repeat(" ", width+4))
+		}
+		logRows(r.KeyPath, rows)
 	}
 
 	return 0
@@ -678,6 +953,8 @@ func Sync(force, keep bool, sources []string) int {
 		return -1
 	}
 
+	writeInitFiles(cfg.Spaceport())
+
 	if len(sources) == 0 {
 		log.Highlight("synchronized nostromo manifests")
 	} else {
@@ -818,21 +1095,29 @@ func Undock(names []string) int {
 		return -1
 	}
 
+	writeInitFiles(cfg.Spaceport())
+
 	log.Highlightf("undocked manifests: %s\n", undocked)
 
 	return 0
 }
 
-func checkConfigQuiet() *config.Config {
-	return checkConfigCommon(true)
+func checkConfig() *config.Config {
+	return checkConfigCommon(false, false)
 }
 
-func checkConfig() *config.Config {
-	return checkConfigCommon(false)
+// checkConfigReadOnly loads config for commands that never modify it
+func checkConfigReadOnly(quiet bool) *config.Config {
+	return checkConfigCommon(quiet, true)
 }
 
-func checkConfigCommon(quiet bool) *config.Config {
-	cfg, err := config.LoadConfig()
+func checkConfigCommon(quiet, readOnly bool) *config.Config {
+	load := config.LoadConfig
+	if readOnly {
+		load = config.LoadConfigReadOnly
+	}
+
+	cfg, err := load()
 	if err != nil {
 		if !quiet {
 			log.Error(err)
@@ -847,6 +1132,49 @@ func checkConfigCommon(quiet bool) *config.Config {
 	return cfg
 }
 
+// layerProjectManifest found from the working directory on top of the
+// spaceport for this session
+//
+// Untrusted project manifests prompt to be trusted when interactive and
+// are skipped otherwise.
+func layerProjectManifest(cfg *config.Config, interactive bool) {
+	dir, err := os.Getwd()
+	if err != nil {
+		log.Debug(err)
+		return
+	}
+
+	path := config.FindProjectManifest(dir)
+	if len(path) == 0 {
+		return
+	}
+
+	if !config.IsTrusted(path) {
+		if !interactive {
+			log.Debugf("skipping untrusted project manifest %s\n", path)
+			return
+		}
+		if !prompt.Confirm(fmt.Sprintf("Trust commands in project manifest %s (y/N)", path), false) {
+			log.Warning("ignoring untrusted project manifest", path)
+			return
+		}
+		if err := config.Trust(path); err != nil {
+			log.Warning("unable to trust project manifest:", err)
+			return
+		}
+	}
+
+	m, err := config.ParseProjectManifest(path)
+	if err != nil {
+		log.Warning("ignoring project manifest", path+":", err)
+		return
+	}
+
+	if err := cfg.Spaceport().Layer(m); err != nil {
+		log.Warning("ignoring project manifest", path+":", err)
+	}
+}
+
 func saveConfig(cfg *config.Config, commit bool) error {
 	m := cfg.Spaceport().CoreManifest()
 
@@ -855,6 +1183,8 @@ func saveConfig(cfg *config.Config, commit bool) error {
 		return err
 	}
 
+	writeInitFiles(cfg.Spaceport())
+
 	if commit {
 		err = shell.Commit(m)
 		if err != nil {